@@ -3,8 +3,10 @@ package encounter
 import (
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
@@ -61,6 +63,127 @@ func EncounterCreateHandler(db database.Service) echo.HandlerFunc {
 	}
 }
 
+func EncounterGenerateHandler(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		parties, err := models.GetAllParties(db)
+		if err != nil {
+			log.Printf("Error getting parties: %v", err)
+			return c.String(http.StatusInternalServerError, "Error getting parties")
+		}
+
+		component := EncounterGenerate(parties)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// EncounterGeneratePreview generates an encounter for the party without
+// saving it, so the GM can reroll until they like it
+func EncounterGeneratePreview(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		partyID, err := strconv.Atoi(c.FormValue("party_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid party ID")
+		}
+		threat, err := strconv.Atoi(c.FormValue("threat"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid threat")
+		}
+
+		party, err := models.GetParty(db, partyID)
+		if err != nil {
+			log.Printf("Error getting party: %v", err)
+			return c.String(http.StatusBadRequest, "Selected party does not exist")
+		}
+
+		options := models.GeneratorOptions{
+			PartyLevel:       int(math.Round(party.GetLevel())),
+			PartySize:        len(party.Players),
+			Threat:           threat,
+			Pattern:          c.FormValue("pattern"),
+			Filters:          parseSearchFilters(c),
+			AllowAdjustments: c.FormValue("allow_adjustments") != "",
+		}
+
+		// Filters that leave nothing to pick from aren't an error, the GM
+		// can loosen them and try again
+		generated, err := models.GenerateEncounter(db, options)
+		if err != nil {
+			log.Printf("Error generating encounter: %v", err)
+			component := GeneratedPreview(generated, err.Error())
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := GeneratedPreview(generated, "")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// EncounterGenerateSave creates an encounter for the party with the previewed
+// creatures and rolls their initiative
+func EncounterGenerateSave(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.FormValue("name")
+		partyID, err := strconv.Atoi(c.FormValue("party_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid party ID")
+		}
+
+		if err := c.Request().ParseForm(); err != nil {
+			log.Printf("Error parsing form: %v", err)
+		}
+		monsterIDs := c.Request().Form["monster_id[]"]
+		levelAdjustments := c.Request().Form["level_adjustment[]"]
+		quantities := c.Request().Form["quantity[]"]
+		if len(monsterIDs) == 0 || len(levelAdjustments) != len(monsterIDs) || len(quantities) != len(monsterIDs) {
+			return c.String(http.StatusBadRequest, "Invalid generated encounter")
+		}
+
+		var creatures []models.GeneratedCreature
+		var initiatives [][]models.InitiativeRoll
+		for i := range monsterIDs {
+			monsterID, err := strconv.Atoi(monsterIDs[i])
+			if err != nil {
+				return c.String(http.StatusBadRequest, "Invalid monster ID")
+			}
+			levelAdjustment, err := strconv.Atoi(levelAdjustments[i])
+			if err != nil || levelAdjustment < -1 || levelAdjustment > 1 {
+				return c.String(http.StatusBadRequest, "Invalid level adjustment")
+			}
+			quantity, err := strconv.Atoi(quantities[i])
+			if err != nil {
+				return c.String(http.StatusBadRequest, "Invalid quantity")
+			}
+			if err := models.ValidateCopies(quantity); err != nil {
+				return c.String(http.StatusBadRequest, fmt.Sprintf("Invalid quantity: %v", err))
+			}
+
+			monster, err := models.GetMonster(db, monsterID)
+			if err != nil {
+				log.Printf("Error finding monster: %v", err)
+				return c.String(http.StatusInternalServerError, "Error finding monster")
+			}
+			monster.LevelAdjustment = levelAdjustment
+
+			rolls, err := models.RollCopies(&monster, monster.GetInitiativeStatistic(), quantity, false)
+			if err != nil {
+				log.Printf("Error rolling initiative: %v", err)
+				return c.String(http.StatusInternalServerError, "Error rolling initiative")
+			}
+
+			creatures = append(creatures, models.GeneratedCreature{Monster: monster, Quantity: quantity})
+			initiatives = append(initiatives, rolls)
+		}
+
+		encounter, err := models.SaveGeneratedEncounter(db, name, partyID, creatures, initiatives)
+		if err != nil {
+			log.Printf("Error saving generated encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error saving generated encounter")
+		}
+
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/encounters/%d", encounter.ID))
+	}
+}
+
 func EncounterEditHandler(db database.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		id, err := strconv.Atoi(c.Param("encounter_id"))
@@ -159,6 +282,120 @@ func EncounterDeleteHandler(db database.Service) echo.HandlerFunc {
 	}
 }
 
+// EncounterDuplicateHandler copies the encounter's creatures into a fresh
+// encounter so the same fight can be run again
+func EncounterDuplicateHandler(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, err := strconv.Atoi(c.Param("encounter_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid encounter ID")
+		}
+
+		if _, err = models.DuplicateEncounter(db, encounterID, ""); err != nil {
+			log.Printf("Error duplicating encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error duplicating encounter")
+		}
+
+		encounters, err := models.GetAllEncounters(db)
+		if err != nil {
+			log.Printf("Error fetching encounters: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounters")
+		}
+
+		component := EncounterList(encounters)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// EncounterSaveTemplateHandler saves the encounter's creatures as a template
+// named in the prompt
+func EncounterSaveTemplateHandler(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, err := strconv.Atoi(c.Param("encounter_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid encounter ID")
+		}
+
+		if _, err = models.SaveEncounterTemplate(db, encounterID, c.Request().Header.Get("HX-Prompt")); err != nil {
+			log.Printf("Error saving template: %v", err)
+			return c.String(http.StatusInternalServerError, "Error saving template")
+		}
+
+		return c.Redirect(http.StatusSeeOther, "/encounters/templates")
+	}
+}
+
+func EncounterTemplateListHandler(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderEncounterTemplates(c, db)
+	}
+}
+
+// EncounterTemplateCreateHandler creates an encounter from the template for
+// the selected party
+func EncounterTemplateCreateHandler(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		templateID, err := strconv.Atoi(c.Param("template_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid template ID")
+		}
+		partyID, err := strconv.Atoi(c.FormValue("party_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid party ID")
+		}
+
+		exists, err := models.PartyExists(db, partyID)
+		if err != nil {
+			log.Printf("Error checking party existence: %v", err)
+			return c.String(http.StatusInternalServerError, "Error creating encounter")
+		}
+		if !exists {
+			return c.String(http.StatusBadRequest, "Selected party does not exist")
+		}
+
+		encounter, err := models.CreateEncounterFromTemplate(db, templateID, c.FormValue("name"), partyID)
+		if err != nil {
+			log.Printf("Error creating encounter from template: %v", err)
+			return c.String(http.StatusInternalServerError, "Error creating encounter")
+		}
+
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/encounters/%d", encounter.ID))
+	}
+}
+
+func EncounterTemplateDeleteHandler(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		templateID, err := strconv.Atoi(c.Param("template_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid template ID")
+		}
+
+		if err = models.DeleteEncounterTemplate(db, templateID); err != nil {
+			log.Printf("Error deleting template: %v", err)
+			return c.String(http.StatusInternalServerError, "Error deleting template")
+		}
+
+		return renderEncounterTemplates(c, db)
+	}
+}
+
+func renderEncounterTemplates(c echo.Context, db database.Service) error {
+	templates, err := models.GetAllEncounterTemplates(db)
+	if err != nil {
+		log.Printf("Error fetching templates: %v", err)
+		return c.String(http.StatusInternalServerError, "Error fetching templates")
+	}
+
+	parties, err := models.GetAllParties(db)
+	if err != nil {
+		log.Printf("Error getting parties: %v", err)
+		return c.String(http.StatusInternalServerError, "Error getting parties")
+	}
+
+	component := EncounterTemplates(templates, parties)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
 func EncounterListHandler(db database.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		// Fetch all encounters for given user
@@ -197,37 +434,7 @@ func EncounterSearchMonster(db database.Service) echo.HandlerFunc {
 		search := c.FormValue("search")
 		encounterID := c.Param("encounter_id")
 
-		// Parse the form to get array values
-		if err := c.Request().ParseForm(); err != nil {
-			log.Printf("Error parsing form: %v", err)
-		}
-
-		// Parse filter parameters
-		filters := models.MonsterSearchFilters{}
-
-		// Level range filter
-		if minLevelStr := c.FormValue("min_level"); minLevelStr != "" {
-			if minLevel, err := strconv.Atoi(minLevelStr); err == nil {
-				filters.MinLevel = &minLevel
-			}
-		}
-
-		if maxLevelStr := c.FormValue("max_level"); maxLevelStr != "" {
-			if maxLevel, err := strconv.Atoi(maxLevelStr); err == nil {
-				filters.MaxLevel = &maxLevel
-			}
-		}
-
-		// Excluded sources filter
-		if excludedSources := c.Request().Form["excluded_sources[]"]; len(excludedSources) > 0 {
-			filters.ExcludedSources = excludedSources
-		}
-
-		// Excluded sizes filter
-		if excludedSizes := c.Request().Form["excluded_sizes[]"]; len(excludedSizes) > 0 {
-			filters.ExcludedSizes = excludedSizes
-		}
-
+		filters := parseSearchFilters(c)
 		monsters, err := models.SearchMonstersWithFilters(db, search, filters)
 		if err != nil {
 			log.Printf("Error searching for monster: %v", err)
@@ -254,11 +461,58 @@ func EncounterSearchMonster(db database.Service) echo.HandlerFunc {
 	}
 }
 
+// parseSearchFilters reads the bestiary search filters from the form
+func parseSearchFilters(c echo.Context) models.MonsterSearchFilters {
+	// Parse the form to get array values
+	if err := c.Request().ParseForm(); err != nil {
+		log.Printf("Error parsing form: %v", err)
+	}
+
+	// Parse filter parameters
+	filters := models.MonsterSearchFilters{Kind: c.FormValue("kind")}
+
+	// Level range filter
+	if minLevelStr := c.FormValue("min_level"); minLevelStr != "" {
+		if minLevel, err := strconv.Atoi(minLevelStr); err == nil {
+			filters.MinLevel = &minLevel
+		}
+	}
+
+	if maxLevelStr := c.FormValue("max_level"); maxLevelStr != "" {
+		if maxLevel, err := strconv.Atoi(maxLevelStr); err == nil {
+			filters.MaxLevel = &maxLevel
+		}
+	}
+
+	// Excluded sources filter
+	if excludedSources := c.Request().Form["excluded_sources[]"]; len(excludedSources) > 0 {
+		filters.ExcludedSources = excludedSources
+	}
+
+	// Excluded sizes filter
+	if excludedSizes := c.Request().Form["excluded_sizes[]"]; len(excludedSizes) > 0 {
+		filters.ExcludedSizes = excludedSizes
+	}
+
+	// Traits filter, e.g. "undead, aquatic"
+	filters.Traits = models.ParseTraits(c.FormValue("traits"))
+
+	return filters
+}
+
 func EncounterAddMonster(db database.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
 		monsterID, _ := strconv.Atoi(c.Param("monster_id"))
 		levelAdjustment, _ := strconv.Atoi(c.FormValue("level_adjustment"))
+		quantity, err := strconv.Atoi(c.FormValue("quantity"))
+		if err != nil {
+			quantity = 1
+		}
+		grouped := c.FormValue("group_initiative") != ""
+		if err := models.ValidateCopies(quantity); err != nil {
+			return c.String(http.StatusBadRequest, fmt.Sprintf("Could not add monster: %v", err))
+		}
 
 		monster, err := models.GetMonster(db, monsterID)
 		if err != nil {
@@ -266,11 +520,27 @@ func EncounterAddMonster(db database.Service) echo.HandlerFunc {
 			return c.String(http.StatusInternalServerError, "Error finding monster")
 		}
 
-		encounter, err := models.AddMonsterToEncounter(db, encounterID, monsterID, levelAdjustment, monster.GenerateInitiative())
+		// Roll initiative with the statistic from the monster's data, once for
+		// the whole group or once per copy
+		initiatives, err := models.RollCopies(&monster, monster.GetInitiativeStatistic(), quantity, grouped)
+		if err != nil {
+			log.Printf("Error rolling initiative: %v", err)
+			return c.String(http.StatusInternalServerError, "Error rolling initiative")
+		}
+
+		undo := snapshotUndo(db, encounterID, "Add "+monster.GetName())
+		encounter, err := models.AddMonstersToEncounter(db, encounterID, monsterID, levelAdjustment, initiatives, grouped && quantity > 1)
 		if err != nil {
 			log.Printf("Error adding monster: %v", err)
 			return c.String(http.StatusInternalServerError, "Error adding monster")
 		}
+		saveUndo(db, undo)
+
+		added := monster.GetName()
+		if quantity > 1 {
+			added = fmt.Sprintf("%d × %s", quantity, added)
+		}
+		logEvents(db, &encounter, models.NewEvent(models.EventCombatant, &monster, fmt.Sprintf("%s joined the encounter", added)))
 
 		component := MonstersAdded(encounter)
 		return component.Render(c.Request().Context(), c.Response().Writer)
@@ -282,11 +552,13 @@ func EncounterRemoveMonster(db database.Service) echo.HandlerFunc {
 		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
 		associationID, _ := strconv.Atoi(c.Param("association_id"))
 
+		undo := snapshotUndo(db, encounterID, "Remove monster")
 		err := models.RemoveMonsterFromEncounter(db, encounterID, associationID)
 		if err != nil {
 			log.Printf("Error removing monster: %v", err)
 			return c.String(http.StatusInternalServerError, "Error removing monster")
 		}
+		saveUndo(db, undo)
 
 		// Fetch the encounter from the database
 		encounter, err := getEncounter(db, encounterID)
@@ -294,6 +566,88 @@ func EncounterRemoveMonster(db database.Service) echo.HandlerFunc {
 			log.Printf("Error fetching encounter: %v", err)
 			return c.String(http.StatusInternalServerError, "Error fetching encounter")
 		}
+		logEvents(db, &encounter, models.NewEvent(models.EventCombatant, nil, "A monster was removed from the encounter"))
+
+		component := MonstersAdded(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func EncounterAddCustomCombatant(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		quantity, err := strconv.Atoi(c.FormValue("quantity"))
+		if err != nil {
+			quantity = 1
+		}
+		grouped := c.FormValue("group_initiative") != ""
+		if err := models.ValidateCopies(quantity); err != nil {
+			return c.String(http.StatusBadRequest, fmt.Sprintf("Could not add custom combatant: %v", err))
+		}
+
+		custom := models.CustomCombatant{
+			Name:    c.FormValue("name"),
+			Attacks: c.FormValue("attacks"),
+		}
+		custom.Level, _ = strconv.Atoi(c.FormValue("level"))
+		custom.MaxHp, _ = strconv.Atoi(c.FormValue("hp"))
+		custom.Ac, _ = strconv.Atoi(c.FormValue("ac"))
+		custom.Fort, _ = strconv.Atoi(c.FormValue("fort"))
+		custom.Ref, _ = strconv.Atoi(c.FormValue("ref"))
+		custom.Will, _ = strconv.Atoi(c.FormValue("will"))
+		custom.Perception, _ = strconv.Atoi(c.FormValue("perception"))
+
+		// Roll initiative with Perception, once for the whole group or once
+		// per copy
+		initiatives, err := models.RollCopies(&custom, models.InitiativePerception, quantity, grouped)
+		if err != nil {
+			log.Printf("Error rolling initiative: %v", err)
+			return c.String(http.StatusInternalServerError, "Error rolling initiative")
+		}
+
+		undo := snapshotUndo(db, encounterID, "Add "+strings.TrimSpace(custom.Name))
+		if err := models.AddCustomCombatantsToEncounter(db, encounterID, custom, initiatives, grouped); err != nil {
+			log.Printf("Error adding custom combatant: %v", err)
+			return c.String(http.StatusBadRequest, fmt.Sprintf("Could not add custom combatant: %v", err))
+		}
+		saveUndo(db, undo)
+
+		encounter, err := models.GetEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		added := strings.TrimSpace(custom.Name)
+		if quantity > 1 {
+			added = fmt.Sprintf("%d × %s", quantity, added)
+		}
+		logEvents(db, &encounter, models.NewEvent(models.EventCombatant, nil, fmt.Sprintf("%s joined the encounter", added)))
+
+		component := MonstersAdded(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func EncounterRemoveCustomCombatant(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		associationID, _ := strconv.Atoi(c.Param("association_id"))
+
+		undo := snapshotUndo(db, encounterID, "Remove custom combatant")
+		err := models.RemoveCustomCombatantFromEncounter(db, encounterID, associationID)
+		if err != nil {
+			log.Printf("Error removing custom combatant: %v", err)
+			return c.String(http.StatusInternalServerError, "Error removing custom combatant")
+		}
+		saveUndo(db, undo)
+
+		encounter, err := models.GetEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+		logEvents(db, &encounter, models.NewEvent(models.EventCombatant, nil, "A custom combatant was removed from the encounter"))
 
 		component := MonstersAdded(encounter)
 		return component.Render(c.Request().Context(), c.Response().Writer)
@@ -306,28 +660,56 @@ func EncounterRemoveCombatant(db database.Service) echo.HandlerFunc {
 		associationID, _ := strconv.Atoi(c.Param("association_id"))
 		isMonster, _ := strconv.ParseBool(c.Param("is_monster"))
 
-		if isMonster {
-			log.Printf("Removing monster: %v", associationID)
-			err := models.RemoveMonsterFromEncounter(db, encounterID, associationID)
-			if err != nil {
-				log.Printf("Error removing monster: %v", err)
-				return c.String(http.StatusInternalServerError, "Error removing monster")
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		// Hand the turn to the next combatant when removing the active one.
+		// The combatant's minions leave with it when asked to, otherwise they
+		// take their own turns from now on.
+		var removed []models.EncounterEvent
+		withMinions := c.QueryParam("minions") == "remove"
+		for _, combatant := range encounter.Combatants {
+			if combatant.GetAssociationID() != associationID || combatant.IsMonster() != isMonster {
+				continue
 			}
-		} else {
-			log.Printf("Removing player: %v", associationID)
-			err := models.RemovePlayerFromEncounter(db, encounterID, associationID)
-			if err != nil {
-				log.Printf("Error removing monster: %v", err)
-				return c.String(http.StatusInternalServerError, "Error removing monster")
+
+			undo := snapshotUndo(db, encounterID, "Remove "+combatant.GetName())
+			leaving := []models.Combatant{combatant}
+			if withMinions {
+				leaving = append(leaving, models.MinionsOf(encounter.Combatants, combatant)...)
+			} else if err := models.ReleaseMinions(db, encounter.Combatants, combatant); err != nil {
+				log.Printf("Error releasing minions: %v", err)
+				return c.String(http.StatusInternalServerError, "Error releasing minions")
+			}
+
+			for _, leaver := range leaving {
+				started, err := models.PassTurnFrom(db, &encounter, leaver)
+				if err != nil {
+					log.Printf("Error passing turn: %v", err)
+					return c.String(http.StatusInternalServerError, "Error updating turn and round")
+				}
+				if err := removeCombatant(db, encounterID, leaver); err != nil {
+					log.Printf("Error removing combatant: %v", err)
+					return c.String(http.StatusInternalServerError, "Error removing combatant")
+				}
+				removed = append(removed, models.NewEvent(models.EventCombatant, leaver, fmt.Sprintf("%s was removed from the encounter", leaver.GetName())))
+				removed = append(removed, models.NewRuleEvents(started)...)
 			}
+			saveUndo(db, undo)
+			break
 		}
 
 		// Fetch the encounter from the database
-		encounter, err := getEncounter(db, encounterID)
+		encounter, err = getEncounter(db, encounterID)
 		if err != nil {
 			log.Printf("Error fetching encounter: %v", err)
 			return c.String(http.StatusInternalServerError, "Error fetching encounter")
 		}
+		logEvents(db, &encounter, removed...)
 
 		// Render and return the updated combatant list
 		component := CombatantList(encounter)
@@ -348,26 +730,92 @@ func UpdateCombatant(db database.Service) echo.HandlerFunc {
 		}
 
 		// Update the specific combatant's values
-		if combatantIndex < len(encounter.Combatants) {
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, updateLabel(c, encounter.Combatants[combatantIndex]))
+			changed := false
+
 			// Check if initiative was provided
 			if initiativeStr := c.FormValue("initiative"); initiativeStr != "" {
 				if newInitiative, err := strconv.Atoi(initiativeStr); err == nil {
-					if err := encounter.Combatants[combatantIndex].SetInitiative(db, newInitiative); err != nil {
+					combatant := encounter.Combatants[combatantIndex]
+					if statistic := c.FormValue("statistic"); statistic != "" {
+						err = models.RecordInitiative(db, combatant, statistic, newInitiative)
+					} else {
+						err = combatant.SetInitiative(db, newInitiative)
+					}
+					if err != nil {
 						log.Printf("Error updating initiative: %v", err)
+					} else {
+						changed = true
+						logEvents(db, &encounter, models.NewInitiativeEvent(combatant))
+					}
+					// Re-sort combatants by initiative only if initiative was updated,
+					// the combatant's minions move along with it
+					if err := models.SyncMinionInitiative(db, &encounter); err != nil {
+						log.Printf("Error updating minion initiative: %v", err)
+					}
+				}
+			}
+
+			// Check if temporary hit points were granted
+			if tempHpStr := c.FormValue("temp_hp"); tempHpStr != "" {
+				if tempHp, err := strconv.Atoi(tempHpStr); err == nil {
+					combatant := encounter.Combatants[combatantIndex]
+					if err := combatant.GainTempHp(db, tempHp); err != nil {
+						log.Printf("Error updating temp hp: %v", err)
+					} else {
+						changed = true
+						logEvents(db, &encounter, models.NewEvent(models.EventHp, combatant, fmt.Sprintf("%s gains %d temporary HP", combatant.GetName(), tempHp)))
 					}
-					// Re-sort combatants by initiative only if initiative was updated
-					models.SortCombatantsByInitiative(encounter.Combatants)
 				}
 			}
 
-			// Check if damage was provided
-			if damageStr := c.FormValue("damage"); damageStr != "" {
+			combatant := encounter.Combatants[combatantIndex]
+			setHp := combatant.SetHp
+			if c.FormValue("critical") != "" {
+				setHp = combatant.SetHpCritical
+			}
+			wasDying := models.GetDyingValue(combatant)
+			source := strings.TrimSpace(c.FormValue("source"))
+
+			// Check if typed damage was provided, e.g. "8 slashing + 4 fire"
+			if typedDamage := c.FormValue("typed_damage"); typedDamage != "" {
+				instances, err := models.ParseDamage(typedDamage)
+				if err != nil {
+					encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not apply damage: %v", err))
+				} else {
+					result := models.ApplyDamageDefenses(instances, combatant.GetDamageDefenses())
+					if err := setHp(db, result.Total); err != nil {
+						log.Printf("Error updating hp: %v", err)
+					} else {
+						changed = true
+						event := models.NewHpEvent(combatant, result.Total, models.DamageTypes(instances), source)
+						event.Description = result.Summary(combatant.GetName())
+						logEvents(db, &encounter, event)
+					}
+					encounter.Messages = append(encounter.Messages, result.Summary(combatant.GetName()))
+				}
+			} else if damageStr := c.FormValue("damage"); damageStr != "" {
+				// Otherwise fall back to plain damage or healing
 				if damage, err := strconv.Atoi(damageStr); err == nil {
-					if err := encounter.Combatants[combatantIndex].SetHp(db, damage); err != nil {
+					if err := setHp(db, damage); err != nil {
 						log.Printf("Error updating hp: %v", err)
+					} else {
+						changed = true
+						logEvents(db, &encounter, models.NewHpEvent(combatant, damage, "", source))
 					}
 				}
 			}
+
+			// Report any change to the combatant's dying value
+			if dying := models.GetDyingValue(combatant); dying != wasDying && dying > 0 {
+				encounter.Messages = append(encounter.Messages, models.DyingStatus(combatant))
+				logEvents(db, &encounter, models.NewEvent(models.EventCondition, combatant, models.DyingStatus(combatant)))
+			}
+
+			if changed {
+				saveUndo(db, undo)
+			}
 		}
 
 		// Render and return the updated combatant list
@@ -387,22 +835,89 @@ func BulkUpdateInitiative(db database.Service) echo.HandlerFunc {
 			return c.String(http.StatusInternalServerError, "Error fetching encounter")
 		}
 
+		// Roll for the monsters when asked to, players roll their own dice
+		rollForMonsters := c.FormValue("roll") != ""
+		undo := snapshotUndo(db, encounterID, "Initiative")
+
 		// Update the each combatant's initiative
+		var events []models.EncounterEvent
 		for _, combatant := range encounter.Combatants {
-			newInitiative, _ := strconv.Atoi(c.FormValue("initiative-" + strconv.Itoa(combatant.GetAssociationID())))
-			if err := combatant.SetInitiative(db, newInitiative); err != nil {
-				log.Printf("Error updating initiative: %v", err)
+			// Minions follow their owner's initiative
+			if models.HasOwner(encounter.Combatants, combatant) {
+				continue
 			}
-		}
 
-		// Re-sort combatants by initiative
-		models.SortCombatantsByInitiative(encounter.Combatants)
+			id := initiativeKey(combatant)
+			statistic := c.FormValue("statistic-" + id)
+			if statistic == "" {
+				statistic = combatant.GetInitiativeStatistic()
+			}
+
+			if rollForMonsters && combatant.IsMonster() {
+				roll, err := models.RollInitiative(combatant, statistic)
+				if err == nil {
+					err = combatant.SetInitiativeRoll(db, roll)
+				}
+				if err != nil {
+					encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not roll initiative for %s: %v", combatant.GetName(), err))
+				} else {
+					events = append(events, models.NewInitiativeEvent(combatant))
+				}
+				continue
+			}
+
+			previous := combatant.GetInitiativeRoll()
+			newInitiative, _ := strconv.Atoi(c.FormValue("initiative-" + id))
+			if err := models.RecordInitiative(db, combatant, statistic, newInitiative); err != nil {
+				log.Printf("Error updating initiative: %v", err)
+			} else if combatant.GetInitiativeRoll() != previous {
+				events = append(events, models.NewInitiativeEvent(combatant))
+			}
+		}
+		if len(events) > 0 {
+			saveUndo(db, undo)
+		}
+		logEvents(db, &encounter, events...)
+
+		// Re-sort combatants by initiative, keeping minions with their owners
+		if err := models.SyncMinionInitiative(db, &encounter); err != nil {
+			log.Printf("Error updating minion initiative: %v", err)
+		}
 
 		component := CombatantList(encounter)
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 }
 
+func NextGroupTurn(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		// End the turns of the whole group and start the next one
+		undo := snapshotUndo(db, encounterID, "Next turn")
+		previous := encounter
+		changes, err := models.NextGroupTurn(db, &encounter)
+		encounter.Messages = append(encounter.Messages, changes...)
+		logEvents(db, &previous, models.NewRuleEvents(changes)...)
+		if err != nil {
+			log.Printf("Error changing turn: %v", err)
+			return c.String(http.StatusInternalServerError, "Error changing turn")
+		}
+		saveUndo(db, undo)
+		logEvents(db, &encounter, models.NewTurnEvent(&encounter))
+
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
 func ChangeTurn(db database.Service, next bool) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
@@ -417,40 +932,193 @@ func ChangeTurn(db database.Service, next bool) echo.HandlerFunc {
 		numberOfCombatants := len(encounter.Combatants)
 		fmt.Printf("Combatants: %d", numberOfCombatants)
 
+		if numberOfCombatants == 0 {
+			component := EncounterShow(encounter)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		label := "Previous turn"
 		if next {
-			if encounter.Turn == numberOfCombatants-1 {
-				encounter.Turn = 0
-				encounter.Round += 1
-			} else {
-				encounter.Turn += 1
+			label = "Next turn"
+		}
+		undo := snapshotUndo(db, encounterID, label)
+
+		if next {
+			// End the current turn and start the next one
+			previous := encounter
+			changes, err := models.NextTurn(db, &encounter)
+			encounter.Messages = append(encounter.Messages, changes...)
+			logEvents(db, &previous, models.NewRuleEvents(changes)...)
+			if err != nil {
+				log.Printf("Error changing turn: %v", err)
+				return c.String(http.StatusInternalServerError, "Error changing turn")
 			}
 		} else {
-			if encounter.Turn == 0 {
-				encounter.Turn = numberOfCombatants - 1
-				encounter.Round -= 1
-			} else {
-				encounter.Turn -= 1
+			// Step back over minions, they act on their owner's turn
+			for range numberOfCombatants {
+				if encounter.Turn == 0 {
+					encounter.Turn = numberOfCombatants - 1
+					encounter.Round -= 1
+				} else {
+					encounter.Turn -= 1
+				}
+
+				if encounter.Round < 0 {
+					encounter.Round = 0
+					encounter.Turn = 0
+					break
+				}
+
+				if !models.HasOwner(encounter.Combatants, encounter.Combatants[encounter.Turn]) {
+					break
+				}
 			}
-		}
 
-		if encounter.Round < 0 {
-			encounter.Round = 0
-			encounter.Turn = 0
+			// Persist turn and round
+			err = models.UpdateTurnAndRound(db, &encounter)
+			if err != nil {
+				log.Printf("Error updating turn and round: %v", err)
+				return c.String(http.StatusInternalServerError, "Error updating turn and round")
+			}
 		}
+		saveUndo(db, undo)
 
 		fmt.Printf("Turn: %d", encounter.Turn)
+		logEvents(db, &encounter, models.NewTurnEvent(&encounter))
+
+		// Render and return the updated combatant list
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+
+	}
+}
+
+func StartEncounter(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
 
-		// Persist turn and round
-		err = models.UpdateTurnAndRound(db, encounter.Turn, encounter.Round, encounterID)
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
 		if err != nil {
-			log.Printf("Error updating turn and round: %v", err)
-			return c.String(http.StatusInternalServerError, "Error updating turn and round")
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		// Roll the missing initiatives and start round 1
+		undo := snapshotUndo(db, encounterID, "Start combat")
+		changes, err := models.StartEncounter(db, &encounter)
+		encounter.Messages = append(encounter.Messages, changes...)
+		if err != nil {
+			encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not start combat: %v", err))
+		} else {
+			saveUndo(db, undo)
+			events := append(models.NewRuleEvents(changes), models.NewEvent(models.EventLifecycle, nil, "Combat started"))
+			logEvents(db, &encounter, events...)
+		}
+		encounter.SortCombatants()
+
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func EndEncounter(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		undo := snapshotUndo(db, encounterID, "End combat")
+		if err := models.EndEncounter(db, &encounter); err != nil {
+			encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not end combat: %v", err))
+		} else {
+			saveUndo(db, undo)
+			logEvents(db, &encounter, models.NewEvent(models.EventLifecycle, nil, "Combat ended"))
+		}
+
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func ResetEncounter(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+
+		// The reset can be undone like any other change
+		undo := snapshotUndo(db, encounterID, "Reset")
+		if err := models.ResetEncounter(db, encounterID); err != nil {
+			log.Printf("Error resetting encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error resetting encounter")
+		}
+		saveUndo(db, undo)
+
+		// Fetch the fresh encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+		logEvents(db, &encounter, models.NewEvent(models.EventLifecycle, nil, "The encounter was reset"))
+
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func AwardXp(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		// The GM may have adjusted the XP the party earned
+		xp, err := strconv.Atoi(strings.TrimSpace(c.FormValue("xp")))
+		if err != nil {
+			xp = encounter.GetEarnedXp().Xp
+		}
+
+		if err := models.AwardXp(db, &encounter, xp); err != nil {
+			encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not award XP: %v", err))
+		} else {
+			message := fmt.Sprintf("%s earned %d XP", encounter.GetPartyName(), xp)
+			encounter.Messages = append(encounter.Messages, message)
+			logEvents(db, &encounter, models.NewEvent(models.EventLifecycle, nil, message))
 		}
 
-		// Render and return the updated combatant list
 		component := EncounterShow(encounter)
 		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// RejectFinished keeps a finished encounter frozen: changes to it are
+// rejected until it is reset
+func RejectFinished(db database.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+
+			status, err := models.GetEncounterStatus(db, encounterID)
+			if err != nil {
+				log.Printf("Error fetching encounter status: %v", err)
+				return c.String(http.StatusInternalServerError, "Error fetching encounter")
+			}
+			if status == models.EncounterFinished {
+				return c.String(http.StatusConflict, "The encounter has finished, reset it to change it")
+			}
 
+			return next(c)
+		}
 	}
 }
 
@@ -475,6 +1143,7 @@ func AddCondition(db database.Service) echo.HandlerFunc {
 		}
 
 		combatant := encounter.Combatants[combatantIndex]
+		undo := snapshotUndo(db, encounterID, fmt.Sprintf("%s on %s", condition.GetName(), combatant.GetName()))
 		hasCondition := combatant.HasCondition(conditionID)
 		isValued := condition.IsValued()
 
@@ -499,6 +1168,13 @@ func AddCondition(db database.Service) echo.HandlerFunc {
 			}
 		}
 
+		if isValued || !hasCondition {
+			saveUndo(db, undo)
+			if updated, ok := findCondition(combatant, conditionID); ok {
+				logEvents(db, &encounter, models.NewConditionEvent(combatant, updated, false))
+			}
+		}
+
 		// Render and return the updated combatant list
 		component := CombatantList(encounter)
 		return component.Render(c.Request().Context(), c.Response().Writer)
@@ -519,11 +1195,21 @@ func RemoveCondition(db database.Service) echo.HandlerFunc {
 		}
 
 		// Update the specific combatant's values
-		err = encounter.Combatants[combatantIndex].RemoveCondition(db, encounterID, conditionID)
+		combatant := encounter.Combatants[combatantIndex]
+		removed, hadCondition := findCondition(combatant, conditionID)
+		var undo *models.UndoSnapshot
+		if hadCondition {
+			undo = snapshotUndo(db, encounterID, fmt.Sprintf("Remove %s from %s", removed.GetName(), combatant.GetName()))
+		}
+		err = combatant.RemoveCondition(db, encounterID, conditionID)
 		if err != nil {
 			log.Printf("Error removing condition: %v", err)
 			return c.String(http.StatusInternalServerError, "Error removing condition")
 		}
+		if hadCondition {
+			saveUndo(db, undo)
+			logEvents(db, &encounter, models.NewConditionEvent(combatant, removed, true))
+		}
 
 		// Render and return the updated combatant list
 		component := CombatantList(encounter)
@@ -531,24 +1217,663 @@ func RemoveCondition(db database.Service) echo.HandlerFunc {
 	}
 }
 
-func getEncounter(db database.Service, encounterID int) (models.Encounter, error) {
-	// Fetch the encounter from the database
-	encounter, err := models.GetEncounterWithCombatants(db, encounterID)
-	if err != nil {
-		log.Printf("Error fetching encounter: %v", err)
-		return models.Encounter{}, err
+func AddPersistentDamage(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			combatant := encounter.Combatants[combatantIndex]
+			undo := snapshotUndo(db, encounterID, "Persistent damage on "+combatant.GetName())
+			err := models.AddPersistentDamage(db, encounterID, combatant, c.FormValue("formula"), c.FormValue("damage_type"))
+			if err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not add persistent damage: %v", err))
+			} else {
+				saveUndo(db, undo)
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
+}
 
-	// Get all conditions
-	groupedConditions, err := models.GetGroupedConditions(db)
-	if err != nil {
-		log.Printf("Error fetching grouped conditions: %v", err)
-		return models.Encounter{}, err
+func RemovePersistentDamage(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		persistentDamageID, _ := strconv.Atoi(c.Param("persistent_damage_id"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, "Remove persistent damage from "+encounter.Combatants[combatantIndex].GetName())
+			if err := models.RemovePersistentDamage(db, encounterID, encounter.Combatants[combatantIndex], persistentDamageID); err != nil {
+				log.Printf("Error removing persistent damage: %v", err)
+				return c.String(http.StatusInternalServerError, "Error removing persistent damage")
+			}
+			saveUndo(db, undo)
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
-	encounter.GroupedConditions = groupedConditions
+}
+
+func AssistPersistentDamage(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		persistentDamageID, _ := strconv.Atoi(c.Param("persistent_damage_id"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			combatant := encounter.Combatants[combatantIndex]
+
+			// Toggle assisted recovery for the next flat check
+			assisted := false
+			for _, p := range combatant.GetPersistentDamage() {
+				if p.ID == persistentDamageID {
+					assisted = !p.Assisted
+				}
+			}
+
+			undo := snapshotUndo(db, encounterID, "Assisted recovery for "+combatant.GetName())
+			if err := models.SetPersistentDamageAssisted(db, encounterID, combatant, persistentDamageID, assisted); err != nil {
+				log.Printf("Error updating persistent damage: %v", err)
+				return c.String(http.StatusInternalServerError, "Error updating persistent damage")
+			}
+			saveUndo(db, undo)
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func AddEffect(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		rounds, _ := strconv.Atoi(c.FormValue("rounds"))
+		anchorIndex, _ := strconv.Atoi(c.FormValue("anchor_index"))
+		modifierValue, _ := strconv.Atoi(c.FormValue("modifier_value"))
+		modifier := models.EffectModifier{
+			Selector: c.FormValue("selector"),
+			Type:     c.FormValue("modifier_type"),
+			Value:    modifierValue,
+		}
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) && anchorIndex >= 0 && anchorIndex < len(encounter.Combatants) {
+			combatant := encounter.Combatants[combatantIndex]
+			undo := snapshotUndo(db, encounterID, "Effect on "+combatant.GetName())
+			err := models.AddEffect(db, encounterID, combatant, c.FormValue("name"), rounds, c.FormValue("anchor"), encounter.Combatants[anchorIndex], modifier)
+			if err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not add effect: %v", err))
+			} else {
+				saveUndo(db, undo)
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func RemoveEffect(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		effectID, _ := strconv.Atoi(c.Param("effect_id"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, "Remove effect from "+encounter.Combatants[combatantIndex].GetName())
+			if err := models.RemoveEffect(db, encounterID, encounter.Combatants[combatantIndex], effectID); err != nil {
+				log.Printf("Error removing effect: %v", err)
+				return c.String(http.StatusInternalServerError, "Error removing effect")
+			}
+			saveUndo(db, undo)
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func DelayTurn(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
 
-	// Sort the combatants by initiative
-	models.SortCombatantsByInitiative(encounter.Combatants)
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		undo := snapshotUndo(db, encounterID, "Delay")
+		previous := encounter
+		changes, err := models.Delay(db, &encounter)
+		encounter.Messages = append(encounter.Messages, changes...)
+		logEvents(db, &previous, models.NewRuleEvents(changes)...)
+		if err != nil {
+			encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not delay: %v", err))
+		} else {
+			saveUndo(db, undo)
+			logEvents(db, &encounter, models.NewTurnEvent(&encounter))
+		}
+
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func ReturnFromDelay(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, encounter.Combatants[combatantIndex].GetName()+" returns from delay")
+			changes, err := models.ReturnFromDelay(db, &encounter, encounter.Combatants[combatantIndex])
+			encounter.Messages = append(encounter.Messages, changes...)
+			if err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not return to the initiative order: %v", err))
+			} else {
+				saveUndo(db, undo)
+				logEvents(db, &encounter, append(models.NewRuleEvents(changes), models.NewTurnEvent(&encounter))...)
+			}
+		}
+
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func SpendActions(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		cost, _ := strconv.Atoi(c.FormValue("cost"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, "Actions of "+encounter.Combatants[combatantIndex].GetName())
+			if err := models.SpendActions(db, encounter.Combatants[combatantIndex], cost); err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not spend actions: %v", err))
+			} else {
+				saveUndo(db, undo)
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func MakeAttack(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		cost, _ := strconv.Atoi(c.FormValue("cost"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, "Attack by "+encounter.Combatants[combatantIndex].GetName())
+			if err := models.MakeAttack(db, encounter.Combatants[combatantIndex], cost); err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not make attack: %v", err))
+			} else {
+				saveUndo(db, undo)
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func UseReaction(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		used, _ := strconv.ParseBool(c.FormValue("used"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, "Reaction of "+encounter.Combatants[combatantIndex].GetName())
+			if err := models.UseReaction(db, encounter.Combatants[combatantIndex], used); err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not use reaction: %v", err))
+			} else {
+				saveUndo(db, undo)
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func MoveInTie(db database.Service, up bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			moved := encounter.Combatants[combatantIndex]
+			undo := snapshotUndo(db, encounterID, "Reorder "+moved.GetName())
+
+			if other, err := models.MoveInTie(db, &encounter, combatantIndex, up); err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not reorder combatant: %v", err))
+			} else {
+				saveUndo(db, undo)
+				// The two swapped combatants, in their new order
+				before, after := moved, other
+				if !up {
+					before, after = other, moved
+				}
+				logEvents(db, &encounter, models.NewEvent(models.EventInitiative, before, fmt.Sprintf("%s now acts before %s", before.GetName(), after.GetName())))
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func ReadyAction(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, "Ready action for "+encounter.Combatants[combatantIndex].GetName())
+			if err := models.ReadyAction(db, encounterID, encounter.Combatants[combatantIndex], c.FormValue("action")); err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not ready action: %v", err))
+			} else {
+				saveUndo(db, undo)
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func RecoveryCheck(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			undo := snapshotUndo(db, encounterID, "Recovery check for "+encounter.Combatants[combatantIndex].GetName())
+			result, err := models.ApplyRecoveryCheck(db, encounterID, encounter.Combatants[combatantIndex], c.FormValue("outcome"))
+			if err != nil {
+				log.Printf("Error applying recovery check: %v", err)
+				return c.String(http.StatusInternalServerError, "Error applying recovery check")
+			}
+			saveUndo(db, undo)
+			encounter.Messages = append(encounter.Messages, result)
+			logEvents(db, &encounter, models.NewEvent(models.EventCondition, encounter.Combatants[combatantIndex], result))
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func AddCompanion(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			owner := encounter.Combatants[combatantIndex]
+			companion := models.Player{
+				Name:   c.FormValue("name"),
+				Minion: models.MinionLink{Kind: c.FormValue("kind")},
+			}
+			companion.Level, _ = strconv.Atoi(c.FormValue("level"))
+			companion.MaxHp, _ = strconv.Atoi(c.FormValue("hp"))
+			companion.Ac, _ = strconv.Atoi(c.FormValue("ac"))
+			companion.Fort, _ = strconv.Atoi(c.FormValue("fort"))
+			companion.Ref, _ = strconv.Atoi(c.FormValue("ref"))
+			companion.Will, _ = strconv.Atoi(c.FormValue("will"))
+			companion.Perception, _ = strconv.Atoi(c.FormValue("perception"))
+
+			undo := snapshotUndo(db, encounterID, "Add "+strings.TrimSpace(companion.Name))
+			if err := models.AddCompanionToEncounter(db, encounterID, &companion, owner); err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not add companion: %v", err))
+			} else {
+				saveUndo(db, undo)
+				messages := encounter.Messages
+				encounter, err = getEncounter(db, encounterID)
+				if err != nil {
+					log.Printf("Error fetching encounter: %v", err)
+					return c.String(http.StatusInternalServerError, "Error fetching encounter")
+				}
+				encounter.Messages = messages
+				logEvents(db, &encounter, models.NewEvent(models.EventCombatant, &companion, fmt.Sprintf("%s joined as %s's %s", companion.GetName(), owner.GetName(), companion.Minion.Kind)))
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func SearchSummons(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+
+		monsters, err := models.SearchMonsters(db, c.FormValue("search"))
+		if err != nil {
+			log.Printf("Error searching for monster: %v", err)
+			return c.String(http.StatusInternalServerError, "Error searching for monster")
+		}
+
+		component := SummonSearchResults(encounterID, combatantIndex, monsters)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func AddSummon(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+		combatantIndex, _ := strconv.Atoi(c.Param("index"))
+		monsterID, _ := strconv.Atoi(c.Param("monster_id"))
+		levelAdjustment, _ := strconv.Atoi(c.FormValue("level_adjustment"))
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if combatantIndex >= 0 && combatantIndex < len(encounter.Combatants) {
+			owner := encounter.Combatants[combatantIndex]
+			monster, err := models.GetMonster(db, monsterID)
+			if err != nil {
+				log.Printf("Error finding monster: %v", err)
+				return c.String(http.StatusInternalServerError, "Error finding monster")
+			}
+
+			undo := snapshotUndo(db, encounterID, "Summon "+monster.GetName())
+			if err := models.AddSummonToEncounter(db, encounterID, monsterID, levelAdjustment, owner); err != nil {
+				encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not summon %s: %v", monster.GetName(), err))
+			} else {
+				saveUndo(db, undo)
+				encounter, err = getEncounter(db, encounterID)
+				if err != nil {
+					log.Printf("Error fetching encounter: %v", err)
+					return c.String(http.StatusInternalServerError, "Error fetching encounter")
+				}
+				logEvents(db, &encounter, models.NewEvent(models.EventCombatant, &monster, fmt.Sprintf("%s summoned %s", owner.GetName(), monster.GetName())))
+			}
+		}
+
+		// Render and return the updated combatant list
+		component := CombatantList(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func EncounterEvents(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+
+		events, err := models.GetEncounterEvents(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter events: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter events")
+		}
+
+		component := EventTimeline(events)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func ChangeHistory(db database.Service, undo bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		encounterID, _ := strconv.Atoi(c.Param("encounter_id"))
+
+		// Step back or forward through the encounter's history
+		travel, action, verb := models.Redo, "redo", "Redid"
+		if undo {
+			travel, action, verb = models.Undo, "undo", "Undid"
+		}
+		label, historyErr := travel(db, encounterID)
+
+		// Fetch the encounter from the database
+		encounter, err := getEncounter(db, encounterID)
+		if err != nil {
+			log.Printf("Error fetching encounter: %v", err)
+			return c.String(http.StatusInternalServerError, "Error fetching encounter")
+		}
+
+		if historyErr != nil {
+			encounter.Messages = append(encounter.Messages, fmt.Sprintf("Could not %s: %v", action, historyErr))
+		} else {
+			message := fmt.Sprintf("%s: %s", verb, label)
+			encounter.Messages = append(encounter.Messages, message)
+			logEvents(db, &encounter, models.NewEvent(models.EventHistory, nil, message))
+		}
+
+		component := EncounterShow(encounter)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// logEvents adds the events to the encounter's combat log. A failure to log
+// doesn't undo the change itself, so it is only reported.
+func logEvents(db database.Service, encounter *models.Encounter, events ...models.EncounterEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	if err := models.LogEvents(db, encounter, events...); err != nil {
+		log.Printf("Error logging encounter events: %v", err)
+	}
+}
+
+// snapshotUndo takes the encounter's state before a change, saveUndo adds it
+// to the undo history once the change succeeded. The change goes ahead even
+// if the state couldn't be taken.
+func snapshotUndo(db database.Service, encounterID int, label string) *models.UndoSnapshot {
+	snapshot, err := models.SnapshotUndo(db, encounterID, label)
+	if err != nil {
+		log.Printf("Error taking undo snapshot: %v", err)
+		return nil
+	}
+
+	return &snapshot
+}
+
+// saveUndo adds the snapshot of a successful change to the undo history, so
+// failed changes leave nothing to undo
+func saveUndo(db database.Service, snapshot *models.UndoSnapshot) {
+	if snapshot == nil {
+		return
+	}
+
+	if err := snapshot.Save(db); err != nil {
+		log.Printf("Error recording undo history: %v", err)
+	}
+}
+
+// initiativeKey identifies the combatant's fields in the initiative form.
+// Monsters and players have ids of their own, so the key includes the kind.
+func initiativeKey(combatant models.Combatant) string {
+	kind := "p"
+	if combatant.IsMonster() {
+		kind = "m"
+	}
+
+	return kind + "-" + strconv.Itoa(combatant.GetAssociationID())
+}
+
+// updateLabel describes a combatant update for the undo history
+func updateLabel(c echo.Context, combatant models.Combatant) string {
+	switch {
+	case c.FormValue("initiative") != "":
+		return "Initiative of " + combatant.GetName()
+	case c.FormValue("temp_hp") != "":
+		return "Temporary HP for " + combatant.GetName()
+	case strings.HasPrefix(strings.TrimSpace(c.FormValue("damage")), "-"):
+		return "Healing of " + combatant.GetName()
+	default:
+		return "Damage to " + combatant.GetName()
+	}
+}
+
+// removeCombatant deletes the combatant from the encounter's monsters, custom
+// combatants or players
+func removeCombatant(db database.Service, encounterID int, combatant models.Combatant) error {
+	if combatant.GetType() == models.CustomType {
+		log.Printf("Removing custom combatant: %v", combatant.GetAssociationID())
+		return models.RemoveCustomCombatantFromEncounter(db, encounterID, combatant.GetAssociationID())
+	}
+
+	if combatant.IsMonster() {
+		log.Printf("Removing monster: %v", combatant.GetAssociationID())
+		return models.RemoveMonsterFromEncounter(db, encounterID, combatant.GetAssociationID())
+	}
+
+	log.Printf("Removing player: %v", combatant.GetAssociationID())
+	return models.RemovePlayerFromEncounter(db, encounterID, combatant.GetAssociationID())
+}
+
+func findCondition(combatant models.Combatant, conditionID int) (models.Condition, bool) {
+	for _, condition := range combatant.GetConditions() {
+		if condition.ID == conditionID {
+			return condition, true
+		}
+	}
+
+	return models.Condition{}, false
+}
+
+func getEncounter(db database.Service, encounterID int) (models.Encounter, error) {
+	// Fetch the encounter from the database
+	encounter, err := models.GetEncounterWithCombatants(db, encounterID)
+	if err != nil {
+		log.Printf("Error fetching encounter: %v", err)
+		return models.Encounter{}, err
+	}
+
+	// Get all conditions
+	groupedConditions, err := models.GetGroupedConditions(db)
+	if err != nil {
+		log.Printf("Error fetching grouped conditions: %v", err)
+		return models.Encounter{}, err
+	}
+	encounter.GroupedConditions = groupedConditions
+
+	// Sort the combatants by initiative and find whose turn it is
+	encounter.SortCombatants()
+
+	// A finished encounter shows the XP the party earned, if it was awarded
+	if err := models.LoadXpAward(db, &encounter); err != nil {
+		log.Printf("Error fetching XP award: %v", err)
+	}
 
 	return encounter, nil
 }
@@ -68,6 +68,7 @@ func PartyEditHandler(db database.Service) echo.HandlerFunc {
 		}
 
 		// Render the template with the party
+		loadProgress(db, &party)
 		component := PartyEdit(party)
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
@@ -103,6 +104,7 @@ func PartyUpdateHandler(db database.Service) echo.HandlerFunc {
 		playerRef := c.Request().Form["players[]ref"]
 		playerWill := c.Request().Form["players[]will"]
 		playerPerception := c.Request().Form["players[]perception"]
+		playerSkills := c.Request().Form["players[]skills"]
 
 		// Create a map of existing player IDs for tracking deletions
 		existingPlayers := make(map[int]bool)
@@ -122,6 +124,14 @@ func PartyUpdateHandler(db database.Service) echo.HandlerFunc {
 			will, _ := strconv.Atoi(playerWill[i])
 			perception, _ := strconv.Atoi(playerPerception[i])
 
+			var skills map[string]int
+			if i < len(playerSkills) {
+				skills, err = models.ParseSkills(playerSkills[i])
+				if err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid skills for %s: %v", playerNames[i], err))
+				}
+			}
+
 			player := models.Player{
 				ID:         playerID,
 				Name:       playerNames[i],
@@ -132,6 +142,7 @@ func PartyUpdateHandler(db database.Service) echo.HandlerFunc {
 				Ref:        ref,
 				Will:       will,
 				Perception: perception,
+				Skills:     skills,
 				PartyID:    party.ID,
 			}
 
@@ -155,6 +166,7 @@ func PartyUpdateHandler(db database.Service) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update party")
 		}
 
+		loadProgress(db, &party)
 		component := PartyEdit(party)
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
@@ -207,11 +219,33 @@ func PlayerDeleteHandler(db database.Service) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusNotFound, "Party not found")
 		}
 
+		loadProgress(db, &party)
 		component := PartyEdit(party)
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 }
 
+func PartyLevelUpHandler(db database.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		partyID, _ := strconv.Atoi(c.Param("party_id"))
+
+		if err := models.LevelUpParty(db, partyID); err != nil {
+			log.Printf("Failed to level up party: %v", err)
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to level up party")
+		}
+
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/parties/%d/edit", partyID))
+	}
+}
+
+// loadProgress adds the party's XP and its history to the party page. The
+// party can still be edited when they can't be loaded.
+func loadProgress(db database.Service, party *models.Party) {
+	if err := models.LoadPartyProgress(db, party); err != nil {
+		log.Printf("Error fetching party XP: %v", err)
+	}
+}
+
 func PartyExportHandler(db database.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		userID := 1 // TODO: Get from session
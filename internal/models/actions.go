@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+const actionsPerTurn = 3
+
+// ActionsPerTurn returns how many actions the combatant gets on their turn
+// before slowed and stunned take any away
+func ActionsPerTurn(c Combatant) int {
+	if _, ok := findCondition(c, "quickened"); ok {
+		return actionsPerTurn + 1
+	}
+
+	return actionsPerTurn
+}
+
+// SpendActions spends actions from the combatant's turn. A negative cost
+// gives back actions that were spent by mistake.
+func SpendActions(db database.Service, c Combatant, cost int) error {
+	if cost == 0 {
+		return errors.New("no actions to spend")
+	}
+
+	remaining := c.GetActionsRemaining() - cost
+	if remaining < 0 {
+		return fmt.Errorf("%s has only %d %s left", c.GetName(), c.GetActionsRemaining(), pluralizeActions(c.GetActionsRemaining()))
+	}
+	if remaining > ActionsPerTurn(c) {
+		return fmt.Errorf("%s can't have more than %d actions", c.GetName(), ActionsPerTurn(c))
+	}
+
+	return c.SetActions(db, remaining, c.IsReactionUsed())
+}
+
+// UseReaction marks the combatant's reaction as used until the start of
+// their next turn, or gives it back when used is false
+func UseReaction(db database.Service, c Combatant, used bool) error {
+	if used && c.IsReactionUsed() {
+		return fmt.Errorf("%s already used their reaction this round", c.GetName())
+	}
+
+	return c.SetActions(db, c.GetActionsRemaining(), used)
+}
+
+// MultipleAttackPenalty returns the penalty for an attack made after the
+// given number of attacks on the same turn: 0, then -5 and -10, or -4 and -8
+// for agile weapons
+func MultipleAttackPenalty(attacksMade int, agile bool) int {
+	step := min(max(attacksMade, 0), 2)
+
+	if agile {
+		return step * -4
+	}
+
+	return step * -5
+}
+
+// MakeAttack spends the actions of an attack and counts it towards the
+// combatant's multiple attack penalty
+func MakeAttack(db database.Service, c Combatant, cost int) error {
+	if cost < 1 {
+		return errors.New("an attack takes at least 1 action")
+	}
+
+	if err := SpendActions(db, c, cost); err != nil {
+		return err
+	}
+
+	return c.SetAttacksMade(db, c.GetAttacksMade()+1)
+}
@@ -12,8 +12,28 @@ type Combatant interface {
 	GetInitiative() int
 	SetInitiative(database.Service, int) error
 	GenerateInitiative() int
+	GetInitiativeStatistic() string
+	GetInitiativeModifier(statistic string) int
+	GetInitiativeRoll() InitiativeRoll
+	SetInitiativeRoll(database.Service, InitiativeRoll) error
+	GetInitiativeOrder() int
+	GetMinionLink() MinionLink
+	SetMinionLink(database.Service, MinionLink) error
+	SetInitiativeOrder(database.Service, int) error
+	GetInitiativeGroup() int
+	GetDelayState() string
+	SetDelayState(database.Service, string) error
+	GetActionsRemaining() int
+	IsReactionUsed() bool
+	SetActions(db database.Service, actions int, reactionUsed bool) error
+	GetAttacksMade() int
+	SetAttacksMade(database.Service, int) error
 	GetHp() int
 	SetHp(database.Service, int) error
+	SetHpCritical(database.Service, int) error
+	GetTempHp() int
+	GainTempHp(database.Service, int) error
+	SetTempHp(database.Service, int) error
 	GetMaxHp() int
 	GetAc() int
 	GetAcDetails() string
@@ -38,6 +58,7 @@ type Combatant interface {
 	GetImmunities() string
 	GetResistances() string
 	GetWeaknesses() string
+	GetDamageDefenses() DamageDefenses
 	GetSpeed() string
 	GetOtherSpeeds() string
 	GetAttacks() []Item
@@ -49,6 +70,10 @@ type Combatant interface {
 	GetInventory() string
 	GetConditions() []Condition
 	SetConditions([]Condition)
+	GetPersistentDamage() []PersistentDamage
+	SetPersistentDamage([]PersistentDamage)
+	GetEffects() []Effect
+	SetEffects([]Effect)
 	SetCondition(db database.Service, encounterID int, conditionID int, conditionValue int) error
 	RemoveCondition(db database.Service, encounterID int, conditionID int) error
 	HasCondition(conditionID int) bool
@@ -61,8 +86,43 @@ type Combatant interface {
 	GetAssociationID() int
 }
 
+// SortCombatantsByInitiative sorts the combatants from highest to lowest
+// initiative. Ties are broken by the order the GM set, then monsters before
+// players, as enemies win ties against PCs, and finally by the order they
+// were added, so the order is the same every time the encounter is loaded.
+// Minions follow right behind their owner.
 func SortCombatantsByInitiative(combatants []Combatant) {
-	sort.Slice(combatants, func(i, j int) bool {
-		return combatants[i].GetInitiative() > combatants[j].GetInitiative()
+	sort.SliceStable(combatants, func(i, j int) bool {
+		a, b := combatants[i], combatants[j]
+
+		if a.GetInitiative() != b.GetInitiative() {
+			return a.GetInitiative() > b.GetInitiative()
+		}
+		if a.GetInitiativeOrder() != b.GetInitiativeOrder() {
+			return a.GetInitiativeOrder() < b.GetInitiativeOrder()
+		}
+		if a.IsMonster() != b.IsMonster() {
+			return a.IsMonster()
+		}
+
+		return a.GetAssociationID() < b.GetAssociationID()
 	})
+
+	placeMinions(combatants)
+}
+
+// HpPercent returns the combatant's current HP as a percentage of their
+// maximum HP
+func HpPercent(c Combatant) int {
+	if c.GetMaxHp() <= 0 {
+		return 0
+	}
+
+	return max(0, c.GetHp()) * 100 / c.GetMaxHp()
+}
+
+// IsBloodied reports whether the combatant is down to half their maximum HP
+// or less but still standing
+func IsBloodied(c Combatant) bool {
+	return c.GetHp() > 0 && HpPercent(c) <= 50
 }
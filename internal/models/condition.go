@@ -5,14 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"pf2.encounterbrew.com/internal/database"
 	"pf2.encounterbrew.com/internal/utils"
 )
 
 type Condition struct {
-	ID   int `json:"id"`
-	Data struct {
+	ID        int `json:"id"`
+	GrantedBy int `json:"granted_by,omitempty"`
+	Data      struct {
 		ID     string `json:"_id"`
 		Img    string `json:"img"`
 		Name   string `json:"name"`
@@ -68,6 +70,16 @@ func (c Condition) GetName() string {
 	return utils.RemoveHTML(c.Data.Name)
 }
 
+// GetSlug returns the condition's name in the lower-case, hyphenated form
+// used by the rules data (e.g. "Off-Guard" becomes "off-guard")
+func (c Condition) GetSlug() string {
+	return slugify(c.GetName())
+}
+
+func slugify(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), " ", "-")
+}
+
 func (c Condition) IsValued() bool {
 	return c.Data.System.Value.IsValued
 }
@@ -107,6 +119,35 @@ func GetCondition(db database.Service, conditionID int) (Condition, error) {
 	return c, nil
 }
 
+func GetConditionByName(db database.Service, name string) (Condition, error) {
+	if db == nil {
+		return Condition{}, errors.New("database service is nil")
+	}
+
+	var c Condition
+	var jsonData []byte
+
+	err := db.QueryRow(`
+        SELECT id, data
+        FROM conditions
+        WHERE name = $1
+    `, name).Scan(&c.ID, &jsonData)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Condition{}, fmt.Errorf("no condition found with name %s", name)
+		}
+		return Condition{}, fmt.Errorf("error scanning condition row: %v", err)
+	}
+
+	err = json.Unmarshal(jsonData, &c.Data)
+	if err != nil {
+		return Condition{}, fmt.Errorf("error unmarshaling condition data: %w", err)
+	}
+
+	return c, nil
+}
+
 func GetGroupedConditions(db database.Service) (map[string][]ConditionInfo, error) {
 	if db == nil {
 		return nil, errors.New("database service is nil")
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+	"pf2.encounterbrew.com/internal/utils"
+)
+
+// GetGrantedConditions returns the names of the conditions this condition
+// gives the combatant, e.g. grabbed gives off-guard and immobilized
+func (c Condition) GetGrantedConditions() []string {
+	var granted []string
+
+	for _, r := range c.Data.System.Rules {
+		rule, ok := r.(map[string]any)
+		if !ok || rule["key"] != "GrantItem" {
+			continue
+		}
+
+		uuid, ok := rule["uuid"].(string)
+		if !ok {
+			continue
+		}
+
+		// e.g. "Compendium.pf2e.conditionitems.Item.Off-Guard"
+		granted = append(granted, uuid[strings.LastIndex(uuid, ".")+1:])
+	}
+
+	return granted
+}
+
+// GetOverrides returns the slugs of the conditions this condition overrides
+func (c Condition) GetOverrides() []string {
+	var overrides []string
+
+	for _, o := range c.Data.System.Overrides {
+		if slug, ok := o.(string); ok {
+			overrides = append(overrides, slug)
+		}
+	}
+
+	return overrides
+}
+
+func (c Condition) Overrides(other Condition) bool {
+	return utils.Contains(c.GetOverrides(), other.GetSlug())
+}
+
+// IsOverridden reports whether another of the conditions overrides this one,
+// like blinded does dazzled. Overridden conditions stay on the combatant but
+// have no effect.
+func (c Condition) IsOverridden(conditions []Condition) bool {
+	for _, other := range conditions {
+		if other.ID != c.ID && other.Overrides(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// activeConditions leaves out the overridden conditions
+func activeConditions(conditions []Condition) []Condition {
+	var active []Condition
+
+	for _, c := range conditions {
+		if !c.IsOverridden(conditions) {
+			active = append(active, c)
+		}
+	}
+
+	return active
+}
+
+// linkConditions applies the links of a condition that was just added to the
+// combatant. Conditions that are mutually exclusive with it, like the
+// attitudes, are replaced and the conditions it grants are added as its
+// children.
+func linkConditions(db database.Service, encounterID int, c Combatant, condition Condition) error {
+	existing := append([]Condition{}, c.GetConditions()...)
+
+	for _, other := range existing {
+		if other.ID != condition.ID && condition.Overrides(other) && other.Overrides(condition) {
+			if err := c.RemoveCondition(db, encounterID, other.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return grantConditions(db, encounterID, c, condition)
+}
+
+// grantConditions adds the conditions granted by a condition that the
+// combatant doesn't have yet
+func grantConditions(db database.Service, encounterID int, c Combatant, condition Condition) error {
+	for _, name := range condition.GetGrantedConditions() {
+		if _, ok := findCondition(c, slugify(name)); ok {
+			continue
+		}
+
+		child, err := GetConditionByName(db, name)
+		if err != nil {
+			return err
+		}
+
+		if err := grantCondition(db, encounterID, c, child, condition.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// grantCondition adds a condition granted by a parent condition, which is
+// removed again together with its parent
+func grantCondition(db database.Service, encounterID int, c Combatant, child Condition, parentID int) error {
+	value := 0
+	if child.IsValued() {
+		value = 1
+	}
+
+	child.Data.System.Value.Value = value
+	child.GrantedBy = parentID
+
+	_, err := db.Exec(`
+        INSERT INTO combatant_conditions (encounter_id, `+associationColumn(c)+`, condition_id, condition_value, granted_by)
+        VALUES ($1, $2, $3, $4, $5)
+    `, encounterID, c.GetAssociationID(), child.ID, value, parentID)
+
+	if err != nil {
+		return fmt.Errorf("error inserting granted condition into combatant_conditions: %v", err)
+	}
+
+	c.SetConditions(append(c.GetConditions(), child))
+
+	return linkConditions(db, encounterID, c, child)
+}
+
+// unlinkConditions removes the conditions granted by a condition that was
+// just removed from the combatant
+func unlinkConditions(db database.Service, encounterID int, c Combatant, parentID int) error {
+	var children []Condition
+	for _, condition := range c.GetConditions() {
+		if condition.GrantedBy == parentID {
+			children = append(children, condition)
+		}
+	}
+
+	for _, child := range children {
+		if err := c.RemoveCondition(db, encounterID, child.ID); err != nil {
+			return err
+		}
+	}
+
+	// Another remaining condition might grant one of the children as well,
+	// e.g. prone and grabbed both give off-guard
+	for _, child := range children {
+		for _, condition := range append([]Condition{}, c.GetConditions()...) {
+			if _, ok := findCondition(c, child.GetSlug()); ok {
+				break
+			}
+
+			if utils.Contains(condition.GetGrantedConditions(), child.GetName()) {
+				if err := grantCondition(db, encounterID, c, child, condition.ID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,736 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+// CustomType is the type of combatants made up by the GM without a bestiary
+// entry
+const CustomType = "custom"
+
+// CustomCombatant is a creature made up on the fly for a single encounter,
+// with only the statistics the GM entered. It takes its association id from
+// the same sequence as the encounter's monsters and counts as a monster for
+// turns and owners, but has its own columns for conditions and effects.
+type CustomCombatant struct {
+	AssociationID       int                `json:"association_id"`
+	EncounterID         int                `json:"encounter_id"`
+	Name                string             `json:"name"`
+	Level               int                `json:"level"`
+	Hp                  int                `json:"hp"`
+	MaxHp               int                `json:"max_hp"`
+	TempHp              int                `json:"temp_hp"`
+	Ac                  int                `json:"ac"`
+	Fort                int                `json:"fort"`
+	Ref                 int                `json:"ref"`
+	Will                int                `json:"will"`
+	Perception          int                `json:"perception"`
+	Attacks             string             `json:"attacks"`
+	Enumeration         int                `json:"enumeration"`
+	Initiative          int                `json:"initiative"`
+	InitiativeOrder     int                `json:"initiative_order"`
+	InitiativeStatistic string             `json:"initiative_statistic"`
+	InitiativeDie       int                `json:"initiative_die"`
+	InitiativeGroup     int                `json:"initiative_group"`
+	Minion              MinionLink         `json:"minion"`
+	Conditions          []Condition        `json:"conditions"`
+	PersistentDamage    []PersistentDamage `json:"persistent_damage"`
+	Effects             []Effect           `json:"effects"`
+	DelayState          string             `json:"delay_state"`
+	ActionsRemaining    int                `json:"actions_remaining"`
+	ReactionUsed        bool               `json:"reaction_used"`
+	AttacksMade         int                `json:"attacks_made"`
+}
+
+// Implement the Combatant interface
+
+func (cc CustomCombatant) GetName() string {
+	if cc.Enumeration > 0 {
+		return fmt.Sprintf("%s %d", cc.Name, cc.Enumeration)
+	}
+
+	return cc.Name
+}
+
+func (cc CustomCombatant) GetType() string {
+	return CustomType
+}
+
+func (cc CustomCombatant) GetInitiative() int {
+	return cc.Initiative
+}
+
+func (cc *CustomCombatant) SetInitiative(db database.Service, i int) error {
+	cc.Initiative = i
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET initiative = $1
+        WHERE id = $2
+    `, i, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant initiative in database: %v", err)
+	}
+
+	return nil
+}
+
+func (cc CustomCombatant) GetInitiativeOrder() int {
+	return cc.InitiativeOrder
+}
+
+func (cc *CustomCombatant) SetInitiativeOrder(db database.Service, order int) error {
+	cc.InitiativeOrder = order
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET initiative_order = $1
+        WHERE id = $2
+    `, order, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant initiative order in database: %v", err)
+	}
+
+	return nil
+}
+
+// GetInitiativeGroup returns the group of custom combatants that were added
+// together with one initiative roll, 0 when it rolled on its own
+func (cc CustomCombatant) GetInitiativeGroup() int {
+	return cc.InitiativeGroup
+}
+
+func (cc CustomCombatant) GetMinionLink() MinionLink {
+	return cc.Minion
+}
+
+func (cc *CustomCombatant) SetMinionLink(db database.Service, link MinionLink) error {
+	cc.Minion = link
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET minion_kind = $1, owner_association_id = $2, owner_is_monster = $3
+        WHERE id = $4
+    `, link.Kind, link.OwnerAssociationID, link.OwnerIsMonster, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant owner in database: %v", err)
+	}
+
+	return nil
+}
+
+func (cc CustomCombatant) GetDelayState() string {
+	return cc.DelayState
+}
+
+func (cc *CustomCombatant) SetDelayState(db database.Service, state string) error {
+	cc.DelayState = state
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET delay_state = $1
+        WHERE id = $2
+    `, state, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant delay state in database: %v", err)
+	}
+
+	return nil
+}
+
+func (cc CustomCombatant) GetActionsRemaining() int {
+	return cc.ActionsRemaining
+}
+
+func (cc CustomCombatant) IsReactionUsed() bool {
+	return cc.ReactionUsed
+}
+
+func (cc *CustomCombatant) SetActions(db database.Service, actions int, reactionUsed bool) error {
+	cc.ActionsRemaining = actions
+	cc.ReactionUsed = reactionUsed
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET actions_remaining = $1, reaction_used = $2
+        WHERE id = $3
+    `, actions, reactionUsed, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant actions in database: %v", err)
+	}
+
+	return nil
+}
+
+func (cc CustomCombatant) GetAttacksMade() int {
+	return cc.AttacksMade
+}
+
+func (cc *CustomCombatant) SetAttacksMade(db database.Service, attacks int) error {
+	cc.AttacksMade = attacks
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET attacks_made = $1
+        WHERE id = $2
+    `, attacks, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant attacks in database: %v", err)
+	}
+
+	return nil
+}
+
+func (cc CustomCombatant) GetHp() int {
+	return cc.Hp
+}
+
+func (cc *CustomCombatant) SetHp(db database.Service, i int) error {
+	// Temporary hit points are lost first
+	if i > 0 && cc.TempHp > 0 {
+		absorbed := min(cc.TempHp, i)
+		i -= absorbed
+
+		if err := cc.SetTempHp(db, cc.TempHp-absorbed); err != nil {
+			return err
+		}
+	}
+
+	// Healing can't raise a custom combatant above its maximum HP
+	before := cc.Hp
+	cc.Hp = max(0, cc.Hp-i)
+	if i < 0 {
+		cc.Hp = min(cc.Hp, max(cc.MaxHp, before))
+	}
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET hp = $1
+        WHERE id = $2
+    `, cc.Hp, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant hp in database: %v", err)
+	}
+
+	return nil
+}
+
+// SetHpCritical applies damage from a critical hit, custom combatants don't
+// track dying
+func (cc *CustomCombatant) SetHpCritical(db database.Service, i int) error {
+	return cc.SetHp(db, i)
+}
+
+func (cc CustomCombatant) GetTempHp() int {
+	return cc.TempHp
+}
+
+func (cc *CustomCombatant) GainTempHp(db database.Service, i int) error {
+	// Temporary hit points don't stack, the higher value is kept
+	if i <= cc.TempHp {
+		return nil
+	}
+
+	return cc.SetTempHp(db, i)
+}
+
+func (cc *CustomCombatant) SetTempHp(db database.Service, i int) error {
+	cc.TempHp = max(0, i)
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET temp_hp = $1
+        WHERE id = $2
+    `, cc.TempHp, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant temp hp in database: %v", err)
+	}
+
+	return nil
+}
+
+func (cc CustomCombatant) GetMaxHp() int {
+	return cc.MaxHp
+}
+
+func (cc CustomCombatant) GetAc() int {
+	return cc.Ac + cc.AdjustConditions()["ac"]
+}
+
+func (cc CustomCombatant) GetAcDetails() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetLevel() int {
+	return cc.Level
+}
+
+func (cc CustomCombatant) GetSize() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetTraits() []string {
+	return []string{}
+}
+
+func (cc CustomCombatant) GetPerceptionMod() int {
+	return cc.Perception + cc.AdjustConditions()["perception"]
+}
+
+func (cc CustomCombatant) GetPerceptionSenses() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetLanguages() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetSkills() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetLores() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetStr() int {
+	return 0
+}
+
+func (cc CustomCombatant) GetDex() int {
+	return 0
+}
+
+func (cc CustomCombatant) GetCon() int {
+	return 0
+}
+
+func (cc CustomCombatant) GetInt() int {
+	return 0
+}
+
+func (cc CustomCombatant) GetWis() int {
+	return 0
+}
+
+func (cc CustomCombatant) GetCha() int {
+	return 0
+}
+
+func (cc CustomCombatant) GetFort() int {
+	return cc.Fort + cc.AdjustConditions()["fort"]
+}
+
+func (cc CustomCombatant) GetRef() int {
+	return cc.Ref + cc.AdjustConditions()["ref"]
+}
+
+func (cc CustomCombatant) GetWill() int {
+	return cc.Will + cc.AdjustConditions()["will"]
+}
+
+func (cc CustomCombatant) GetImmunities() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetResistances() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetWeaknesses() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetDamageDefenses() DamageDefenses {
+	return DamageDefenses{}
+}
+
+func (cc CustomCombatant) GetSpeed() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetOtherSpeeds() string {
+	return ""
+}
+
+// GetAttacks returns no attack items, the attacks of a custom combatant are
+// free text, see GetAttackText
+func (cc CustomCombatant) GetAttacks() []Item {
+	return []Item{}
+}
+
+// GetAttackText returns the attacks as the GM wrote them
+func (cc CustomCombatant) GetAttackText() string {
+	return cc.Attacks
+}
+
+func (cc CustomCombatant) GetSpellSchool() Item {
+	return Item{}
+}
+
+func (cc CustomCombatant) GetSpells() OrderedItemMap {
+	return CreateSortedOrderedItemMap(map[int][]Item{})
+}
+
+func (cc CustomCombatant) GetDefensiveActions() []map[string]string {
+	return []map[string]string{}
+}
+
+func (cc CustomCombatant) GetOffensiveActions() []map[string]string {
+	return []map[string]string{}
+}
+
+func (cc CustomCombatant) GetInteractions() []map[string]string {
+	return []map[string]string{}
+}
+
+func (cc CustomCombatant) GetInventory() string {
+	return ""
+}
+
+func (cc CustomCombatant) GetConditions() []Condition {
+	return cc.Conditions
+}
+
+func (cc *CustomCombatant) SetConditions(conditions []Condition) {
+	cc.Conditions = conditions
+}
+
+func (cc CustomCombatant) GetPersistentDamage() []PersistentDamage {
+	return cc.PersistentDamage
+}
+
+func (cc *CustomCombatant) SetPersistentDamage(persistentDamage []PersistentDamage) {
+	cc.PersistentDamage = persistentDamage
+}
+
+func (cc CustomCombatant) GetEffects() []Effect {
+	return cc.Effects
+}
+
+func (cc *CustomCombatant) SetEffects(effects []Effect) {
+	cc.Effects = effects
+}
+
+func (cc *CustomCombatant) SetCondition(db database.Service, encounterID int, conditionID int, conditionValue int) error {
+	if cc.Conditions == nil {
+		cc.Conditions = make([]Condition, 0)
+	}
+
+	// Increment the value of a condition the combatant already has
+	for i, c := range cc.Conditions {
+		if c.ID == conditionID {
+			newValue := c.GetValue() + conditionValue
+			c.SetValue(newValue)
+			cc.Conditions[i] = c
+
+			_, err := db.Exec(`
+				UPDATE combatant_conditions
+				SET condition_value = $1
+				WHERE encounter_id = $2 AND encounter_custom_combatant_id = $3 AND condition_id = $4
+			`, newValue, encounterID, cc.AssociationID, conditionID)
+
+			if err != nil {
+				return fmt.Errorf("error updating condition in combatant_conditions: %v", err)
+			}
+
+			return nil
+		}
+	}
+
+	condition, err := GetCondition(db, conditionID)
+	if err != nil {
+		return err
+	}
+
+	condition.Data.System.Value.Value = conditionValue
+	cc.Conditions = append(cc.Conditions, condition)
+
+	_, err = db.Exec(`
+        INSERT INTO combatant_conditions (encounter_id, encounter_custom_combatant_id, condition_id, condition_value)
+        VALUES ($1, $2, $3, $4)
+    `, encounterID, cc.AssociationID, conditionID, conditionValue)
+
+	if err != nil {
+		return fmt.Errorf("error inserting condition into combatant_conditions: %v", err)
+	}
+
+	return linkConditions(db, encounterID, cc, condition)
+}
+
+func (cc *CustomCombatant) RemoveCondition(db database.Service, encounterID int, conditionID int) error {
+	for i, c := range cc.Conditions {
+		if c.ID == conditionID {
+			cc.Conditions = append(cc.Conditions[:i], cc.Conditions[i+1:]...)
+			break
+		}
+	}
+
+	_, err := db.Exec(`
+        DELETE FROM combatant_conditions
+        WHERE encounter_id = $1 AND encounter_custom_combatant_id = $2 AND condition_id = $3
+    `, encounterID, cc.AssociationID, conditionID)
+
+	if err != nil {
+		return fmt.Errorf("error removing condition from combatant_conditions: %v", err)
+	}
+
+	return unlinkConditions(db, encounterID, cc, conditionID)
+}
+
+func (cc *CustomCombatant) HasCondition(conditionID int) bool {
+	for _, c := range cc.Conditions {
+		if c.ID == conditionID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cc *CustomCombatant) GetConditionValue(conditionID int) int {
+	for _, c := range cc.Conditions {
+		if c.ID == conditionID {
+			return c.GetValue()
+		}
+	}
+
+	return 0
+}
+
+func (cc *CustomCombatant) SetConditionValue(conditionID int, conditionValue int) int {
+	for _, c := range cc.Conditions {
+		if c.ID == conditionID {
+			c.SetValue(conditionValue)
+			return c.GetValue()
+		}
+	}
+
+	return 0
+}
+
+func (cc CustomCombatant) GetAdjustmentModifier() int {
+	return 0
+}
+
+func (cc *CustomCombatant) SetEnumeration(value int) {
+	cc.Enumeration = value
+}
+
+// IsMonster returns true, custom combatants are on the monsters' side of
+// the initiative order
+func (cc CustomCombatant) IsMonster() bool {
+	return true
+}
+
+func (cc CustomCombatant) IsOffGuard() bool {
+	_, ok := findCondition(&cc, "off-guard")
+	return ok
+}
+
+// AdjustConditions returns the modifiers the custom combatant's conditions
+// and effects apply to its AC, saves and perception
+func (cc CustomCombatant) AdjustConditions() map[string]int {
+	return adjustConditions(cc.Conditions, cc.Effects)
+}
+
+func (cc CustomCombatant) GenerateInitiative() int {
+	roll, _ := RollInitiative(&cc, cc.GetInitiativeStatistic())
+	return roll.Total
+}
+
+// GetInitiativeStatistic returns the statistic the custom combatant rolled
+// initiative with, Perception unless the GM said otherwise
+func (cc CustomCombatant) GetInitiativeStatistic() string {
+	if cc.InitiativeStatistic != "" {
+		return cc.InitiativeStatistic
+	}
+
+	return InitiativePerception
+}
+
+func (cc CustomCombatant) GetInitiativeModifier(statistic string) int {
+	if statistic == InitiativePerception {
+		return cc.GetPerceptionMod()
+	}
+
+	return untrainedSkillModifier(&cc, statistic)
+}
+
+func (cc CustomCombatant) GetInitiativeRoll() InitiativeRoll {
+	return InitiativeRoll{Statistic: cc.InitiativeStatistic, Die: cc.InitiativeDie, Total: cc.Initiative}
+}
+
+func (cc *CustomCombatant) SetInitiativeRoll(db database.Service, roll InitiativeRoll) error {
+	cc.Initiative = roll.Total
+	cc.InitiativeStatistic = roll.Statistic
+	cc.InitiativeDie = roll.Die
+
+	_, err := db.Exec(`
+        UPDATE encounter_custom_combatants
+        SET initiative = $1, initiative_statistic = $2, initiative_die = $3
+        WHERE id = $4
+    `, roll.Total, roll.Statistic, roll.Die, cc.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating custom combatant initiative in database: %v", err)
+	}
+
+	return nil
+}
+
+func (cc *CustomCombatant) GetAssociationID() int {
+	return cc.AssociationID
+}
+
+// Database interactions
+
+// getEncounterCustomCombatants loads the custom combatants of the encounter
+func getEncounterCustomCombatants(db database.Service, encounterID int) ([]*CustomCombatant, error) {
+	rows, err := db.Query(`
+        SELECT cc.id, cc.name, cc.level, cc.max_hp, cc.hp, cc.temp_hp, cc.ac, cc.fort, cc.ref, cc.will, cc.perception, cc.attacks, cc.enumeration, cc.initiative, cc.initiative_statistic, cc.initiative_die, cc.initiative_order, cc.initiative_group, cc.delay_state, cc.actions_remaining, cc.reaction_used, cc.attacks_made, cc.minion_kind, cc.owner_association_id, cc.owner_is_monster
+        FROM encounter_custom_combatants cc
+        WHERE cc.encounter_id = $1
+    `, encounterID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying custom combatants: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var customs []*CustomCombatant
+	for rows.Next() {
+		custom := CustomCombatant{EncounterID: encounterID}
+		err := rows.Scan(
+			&custom.AssociationID,
+			&custom.Name,
+			&custom.Level,
+			&custom.MaxHp,
+			&custom.Hp,
+			&custom.TempHp,
+			&custom.Ac,
+			&custom.Fort,
+			&custom.Ref,
+			&custom.Will,
+			&custom.Perception,
+			&custom.Attacks,
+			&custom.Enumeration,
+			&custom.Initiative,
+			&custom.InitiativeStatistic,
+			&custom.InitiativeDie,
+			&custom.InitiativeOrder,
+			&custom.InitiativeGroup,
+			&custom.DelayState,
+			&custom.ActionsRemaining,
+			&custom.ReactionUsed,
+			&custom.AttacksMade,
+			&custom.Minion.Kind,
+			&custom.Minion.OwnerAssociationID,
+			&custom.Minion.OwnerIsMonster,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning custom combatant row: %v", err)
+		}
+		customs = append(customs, &custom)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating custom combatant rows: %v", err)
+	}
+
+	return customs, nil
+}
+
+// AddCustomCombatantsToEncounter adds one copy of the custom combatant per
+// initiative roll, numbered after the copies with the same name already in
+// the encounter. Grouped copies share an initiative group like monsters.
+func AddCustomCombatantsToEncounter(db database.Service, encounterID int, custom CustomCombatant, initiatives []InitiativeRoll, grouped bool) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	custom.Name = strings.TrimSpace(custom.Name)
+	if custom.Name == "" {
+		return errors.New("the custom combatant needs a name")
+	}
+	if custom.MaxHp < 1 {
+		return errors.New("the custom combatant needs at least 1 HP")
+	}
+	if len(initiatives) < 1 || len(initiatives) > maxMonsterCopies {
+		return fmt.Errorf("can add between 1 and %d copies of a custom combatant at once", maxMonsterCopies)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	// Find the highest enumeration of custom combatants with the same name
+	var maxEnumeration int
+	err = tx.QueryRow(`
+		SELECT COALESCE(MAX(enumeration), 0)
+		FROM encounter_custom_combatants
+		WHERE encounter_id = $1 AND name = $2
+	`, encounterID, custom.Name).Scan(&maxEnumeration)
+	if err != nil {
+		return fmt.Errorf("failed to get max enumeration: %v", err)
+	}
+
+	group := 0
+	if grouped && len(initiatives) > 1 {
+		if group, err = nextInitiativeGroup(tx, encounterID); err != nil {
+			return err
+		}
+	}
+
+	for i, initiative := range initiatives {
+		// Number each copy after the highest existing enumeration
+		_, err = tx.Exec(`
+            INSERT INTO encounter_custom_combatants (encounter_id, name, level, max_hp, hp, ac, fort, ref, will, perception, attacks, enumeration, initiative, initiative_statistic, initiative_die, initiative_group)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+        `, encounterID, custom.Name, custom.Level, custom.MaxHp, custom.MaxHp, custom.Ac, custom.Fort, custom.Ref, custom.Will, custom.Perception, strings.TrimSpace(custom.Attacks),
+			maxEnumeration+i+1, initiative.Total, initiative.Statistic, initiative.Die, group)
+
+		if err != nil {
+			return fmt.Errorf("failed to add custom combatant to encounter: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveCustomCombatantFromEncounter deletes the custom combatant, its
+// conditions and effects are removed along with it
+func RemoveCustomCombatantFromEncounter(db database.Service, encounterID int, associationID int) error {
+	_, err := db.Exec(`
+        DELETE FROM encounter_custom_combatants
+        WHERE id = $1 AND encounter_id = $2
+    `, associationID, encounterID)
+
+	if err != nil {
+		return fmt.Errorf("error removing custom combatant from encounter: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/utils"
+)
+
+// damageGroups maps the grouped IWR types to the damage types they cover
+var damageGroups = map[string][]string{
+	"physical": {"bludgeoning", "piercing", "slashing"},
+	"energy":   {"acid", "cold", "electricity", "fire", "sonic", "vitality", "void", "force"},
+}
+
+// DamageInstance is a single typed amount of damage, e.g. "8 slashing".
+// Tags hold the damage type followed by any traits or materials
+// (e.g. "cold-iron", "magical") that immunities, resistances and
+// weaknesses can refer to.
+type DamageInstance struct {
+	Amount int
+	Type   string
+	Tags   []string
+}
+
+type DamageModifier struct {
+	Type       string
+	Value      int
+	Exceptions []string
+	DoubleVs   []string
+}
+
+// DamageDefenses are a combatant's immunities, resistances and weaknesses
+type DamageDefenses struct {
+	Immunities  []string
+	Resistances []DamageModifier
+	Weaknesses  []DamageModifier
+	// Hardness reduces the damage of each hit on a hazard or object
+	Hardness int
+}
+
+type DamageResult struct {
+	Total     int
+	Breakdown []string
+}
+
+// ParseDamage reads damage notation such as "8 slashing + 4 fire".
+// Untyped amounts like "12" are allowed as well.
+func ParseDamage(input string) ([]DamageInstance, error) {
+	var instances []DamageInstance
+
+	parts := strings.FieldsFunc(input, func(r rune) bool {
+		return r == '+' || r == ','
+	})
+
+	for _, part := range parts {
+		fields := strings.Fields(strings.ToLower(part))
+		if len(fields) == 0 {
+			continue
+		}
+
+		amount, err := strconv.Atoi(fields[0])
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid damage amount %q", fields[0])
+		}
+
+		instance := DamageInstance{Amount: amount, Tags: fields[1:]}
+		if len(instance.Tags) > 0 {
+			instance.Type = instance.Tags[0]
+		}
+
+		instances = append(instances, instance)
+	}
+
+	if len(instances) == 0 {
+		return nil, errors.New("no damage given")
+	}
+
+	return instances, nil
+}
+
+// matches reports whether an IWR type applies to the damage instance
+func (d DamageInstance) matches(iwrType string) bool {
+	if iwrType == "all-damage" {
+		return true
+	}
+
+	if utils.Contains(d.Tags, iwrType) {
+		return true
+	}
+
+	return utils.Contains(damageGroups[iwrType], d.Type)
+}
+
+func (d DamageInstance) hasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		if utils.Contains(d.Tags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d DamageInstance) label() string {
+	if d.Type == "" {
+		return "untyped"
+	}
+
+	return d.Type
+}
+
+// ApplyDamageDefenses works out the damage each instance deals after
+// immunities, weaknesses and resistances. Only the highest applicable
+// weakness and resistance count for each instance.
+func ApplyDamageDefenses(instances []DamageInstance, defenses DamageDefenses) DamageResult {
+	result := DamageResult{}
+
+	for _, instance := range instances {
+		immune := false
+		for _, immunity := range defenses.Immunities {
+			if instance.matches(immunity) {
+				result.Breakdown = append(result.Breakdown, fmt.Sprintf("immune to %s, %d %s ignored", immunity, instance.Amount, instance.label()))
+				immune = true
+				break
+			}
+		}
+
+		if immune || instance.Amount == 0 {
+			continue
+		}
+
+		amount := instance.Amount
+
+		var weakness *DamageModifier
+		for i, w := range defenses.Weaknesses {
+			if instance.matches(w.Type) && !instance.hasAnyTag(w.Exceptions) && (weakness == nil || w.Value > weakness.Value) {
+				weakness = &defenses.Weaknesses[i]
+			}
+		}
+
+		if weakness != nil {
+			amount += weakness.Value
+			result.Breakdown = append(result.Breakdown, fmt.Sprintf("weak %s %d applied", weakness.Type, weakness.Value))
+		}
+
+		var resistance *DamageModifier
+		resistanceValue := 0
+		for i, r := range defenses.Resistances {
+			if !instance.matches(r.Type) || instance.hasAnyTag(r.Exceptions) {
+				continue
+			}
+
+			value := r.Value
+			if instance.hasAnyTag(r.DoubleVs) {
+				value *= 2
+			}
+
+			if resistance == nil || value > resistanceValue {
+				resistance = &defenses.Resistances[i]
+				resistanceValue = value
+			}
+		}
+
+		if resistance != nil {
+			amount = max(0, amount-resistanceValue)
+			result.Breakdown = append(result.Breakdown, fmt.Sprintf("resist %s %d applied", resistance.Type, resistanceValue))
+		}
+
+		result.Total += amount
+	}
+
+	if defenses.Hardness > 0 && result.Total > 0 {
+		result.Breakdown = append(result.Breakdown, fmt.Sprintf("hardness %d applied", defenses.Hardness))
+		result.Total = max(0, result.Total-defenses.Hardness)
+	}
+
+	return result
+}
+
+// DamageTypes lists the types of the damage instances, e.g. "slashing, fire"
+func DamageTypes(instances []DamageInstance) string {
+	var types []string
+	for _, instance := range instances {
+		if instance.Type != "" && !utils.Contains(types, instance.Type) {
+			types = append(types, instance.Type)
+		}
+	}
+
+	return strings.Join(types, ", ")
+}
+
+// Summary describes the damage a combatant took, including the breakdown
+func (r DamageResult) Summary(name string) string {
+	summary := fmt.Sprintf("%s takes %d damage", name, r.Total)
+
+	if len(r.Breakdown) > 0 {
+		summary += " (" + strings.Join(r.Breakdown, ", ") + ")"
+	}
+
+	return summary
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+const (
+	DelayStateDelaying = "delaying"
+	DelayStateReturned = "returned"
+)
+
+func IsDelaying(c Combatant) bool {
+	return c.GetDelayState() == DelayStateDelaying
+}
+
+// Delay takes the active combatant out of the initiative order and moves on
+// to the next combatant. Delaying can't be used to dodge anything that would
+// happen on the combatant's turn, so the end of turn rules like persistent
+// damage are applied right away and effects that end on their turn expire.
+func Delay(db database.Service, e *Encounter) ([]string, error) {
+	if len(e.Combatants) == 0 {
+		return nil, errors.New("there is no active combatant")
+	}
+
+	c := e.Combatants[e.Turn]
+	if c.GetDelayState() != "" {
+		return nil, fmt.Errorf("%s can't delay again this turn", c.GetName())
+	}
+
+	changes := []string{fmt.Sprintf("%s delays", c.GetName())}
+
+	// Minions acting on the combatant's turn delay along with them
+	for _, acting := range actingCombatants(e) {
+		processed, err := ProcessTurnEnd(db, e.ID, acting)
+		changes = append(changes, processed...)
+		if err != nil {
+			return changes, fmt.Errorf("error processing end of turn: %v", err)
+		}
+
+		expired, err := ProcessEffects(db, e.ID, e.Combatants, acting, EffectAnchorEnd)
+		changes = append(changes, expired...)
+		if err != nil {
+			return changes, fmt.Errorf("error processing effects: %v", err)
+		}
+	}
+
+	if err := c.SetDelayState(db, DelayStateDelaying); err != nil {
+		return changes, err
+	}
+
+	if err := advanceTurn(db, e); err != nil {
+		return changes, err
+	}
+
+	started, err := startTurn(db, e)
+	return append(changes, started...), err
+}
+
+// ReturnFromDelay ends the active combatant's turn and brings the delaying
+// combatant back into the initiative order right after them. The delaying
+// combatant takes the active combatant's initiative for good, ordered right
+// after them in the tie, and continues the turn they delayed.
+func ReturnFromDelay(db database.Service, e *Encounter, c Combatant) ([]string, error) {
+	if !IsDelaying(c) {
+		return nil, fmt.Errorf("%s is not delaying", c.GetName())
+	}
+
+	previous := e.Combatants[e.Turn]
+
+	changes, err := endTurn(db, e)
+	if err != nil {
+		return changes, err
+	}
+
+	if err := c.SetInitiative(db, previous.GetInitiative()); err != nil {
+		return changes, err
+	}
+	if err := c.SetDelayState(db, DelayStateReturned); err != nil {
+		return changes, err
+	}
+
+	// Place the combatant right after the previous one among everyone who
+	// shares their initiative
+	var tied []Combatant
+	for _, combatant := range tiedWith(e.Combatants, previous) {
+		if combatant == c {
+			continue
+		}
+		tied = append(tied, combatant)
+		if combatant == previous {
+			tied = append(tied, c)
+		}
+	}
+	if err := storeTieOrder(db, tied); err != nil {
+		return changes, fmt.Errorf("error reordering tied combatants: %v", err)
+	}
+
+	// The combatant's minions follow them to their new place
+	e.setTurnOf(c)
+	if err := SyncMinionInitiative(db, e); err != nil {
+		return changes, fmt.Errorf("error moving minions: %v", err)
+	}
+
+	if err := UpdateTurnAndRound(db, e); err != nil {
+		return changes, fmt.Errorf("error updating turn and round: %v", err)
+	}
+
+	return append(changes, fmt.Sprintf("%s returns to the initiative order after %s with initiative %d", c.GetName(), previous.GetName(), c.GetInitiative())), nil
+}
+
+// ReadyAction records an action the combatant readied, which lasts until the
+// start of their next turn
+func ReadyAction(db database.Service, encounterID int, c Combatant, action string) error {
+	action = strings.TrimSpace(action)
+	if action == "" {
+		return errors.New("readied action needs a description")
+	}
+
+	return AddEffect(db, encounterID, c, "Readied: "+action, 1, EffectAnchorStart, c, EffectModifier{})
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// RollDie rolls a single die with the given number of sides. It's a variable
+// so tests can replace it with a predictable roll.
+var RollDie = func(sides int) int {
+	//nolint:gosec
+	return rand.Intn(sides) + 1
+}
+
+// A dice formula can roll at most 100 dice of at most 100 faces
+const (
+	maxFormulaDice  = 100
+	maxFormulaFaces = 100
+)
+
+// diceTerm is a term of a dice formula, either dice or a flat value
+type diceTerm struct {
+	sign  int
+	dice  int
+	faces int
+	value int
+}
+
+// parseFormula splits dice notation into its terms without rolling them
+func parseFormula(formula string) ([]diceTerm, error) {
+	formula = strings.ReplaceAll(strings.ToLower(formula), " ", "")
+	if strings.Trim(formula, "+-") == "" {
+		return nil, errors.New("empty dice formula")
+	}
+
+	var terms []diceTerm
+	totalDice := 0
+
+	// Turn subtractions into negative terms so we can split on "+"
+	for _, term := range strings.Split(strings.ReplaceAll(formula, "-", "+-"), "+") {
+		if term == "" {
+			continue
+		}
+
+		sign := 1
+		if strings.HasPrefix(term, "-") {
+			sign = -1
+			term = term[1:]
+		}
+
+		count, sides, isDice := strings.Cut(term, "d")
+		if !isDice {
+			value, err := strconv.Atoi(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dice formula %q", formula)
+			}
+			terms = append(terms, diceTerm{sign: sign, value: value})
+			continue
+		}
+
+		dice := 1
+		if count != "" {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid dice formula %q", formula)
+			}
+			dice = n
+		}
+
+		faces, err := strconv.Atoi(sides)
+		if err != nil || faces < 1 {
+			return nil, fmt.Errorf("invalid dice formula %q", formula)
+		}
+		if faces > maxFormulaFaces {
+			return nil, fmt.Errorf("dice can have at most %d faces", maxFormulaFaces)
+		}
+
+		if totalDice += dice; totalDice > maxFormulaDice {
+			return nil, fmt.Errorf("a formula can roll at most %d dice", maxFormulaDice)
+		}
+		terms = append(terms, diceTerm{sign: sign, dice: dice, faces: faces})
+	}
+
+	return terms, nil
+}
+
+// ValidateFormula checks dice notation without rolling it
+func ValidateFormula(formula string) error {
+	_, err := parseFormula(formula)
+	return err
+}
+
+// RollFormula rolls dice notation such as "2d6", "1d8+3" or "5" and returns
+// the total
+func RollFormula(formula string) (int, error) {
+	terms, err := parseFormula(formula)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, term := range terms {
+		total += term.sign * term.value
+		for i := 0; i < term.dice; i++ {
+			total += term.sign * RollDie(term.faces)
+		}
+	}
+
+	return max(0, total), nil
+}
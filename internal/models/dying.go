@@ -0,0 +1,147 @@
+package models
+
+import (
+	"fmt"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+// baseDeathThreshold is the dying value at which a creature dies, before doomed
+const baseDeathThreshold = 4
+
+// recoveryCheckChanges maps the degrees of success of a recovery check to
+// the change in the dying value
+var recoveryCheckChanges = map[string]int{
+	"critical-success": -2,
+	"success":          -1,
+	"failure":          1,
+	"critical-failure": 2,
+}
+
+// DeathThreshold returns the dying value at which the combatant dies,
+// reduced by its doomed value
+func DeathThreshold(c Combatant) int {
+	threshold := baseDeathThreshold
+	if doomed, ok := findCondition(c, "doomed"); ok {
+		threshold -= doomed.GetValue()
+	}
+
+	return max(threshold, 1)
+}
+
+func GetDyingValue(c Combatant) int {
+	if dying, ok := findCondition(c, "dying"); ok {
+		return dying.GetValue()
+	}
+
+	return 0
+}
+
+func IsDying(c Combatant) bool {
+	return GetDyingValue(c) > 0 && !IsDead(c)
+}
+
+func IsDead(c Combatant) bool {
+	return GetDyingValue(c) >= DeathThreshold(c)
+}
+
+// RecoveryCheckDC returns the DC of the combatant's next recovery check
+func RecoveryCheckDC(c Combatant) int {
+	return 10 + GetDyingValue(c)
+}
+
+// DyingStatus describes how close a dying combatant is to death, or returns
+// an empty string if it isn't dying
+func DyingStatus(c Combatant) string {
+	dying := GetDyingValue(c)
+
+	switch {
+	case dying == 0:
+		return ""
+	case IsDead(c):
+		return fmt.Sprintf("%s has died (dying %d)", c.GetName(), dying)
+	default:
+		return fmt.Sprintf("%s is dying %d and dies at dying %d", c.GetName(), dying, DeathThreshold(c))
+	}
+}
+
+// addValuedCondition increases a valued condition by the given amount,
+// adding it first if the combatant doesn't have it yet
+func addValuedCondition(db database.Service, encounterID int, c Combatant, name string, amount int) error {
+	if condition, ok := findCondition(c, slugify(name)); ok {
+		return c.SetCondition(db, encounterID, condition.ID, amount)
+	}
+
+	condition, err := GetConditionByName(db, name)
+	if err != nil {
+		return err
+	}
+
+	return c.SetCondition(db, encounterID, condition.ID, amount)
+}
+
+// applyDamageWhileDown applies the dying rules for a combatant that was
+// damaged down to or while at 0 HP. Dying starts at 1 plus wounded and a
+// critical hit adds 1 more. Damage while already dying raises dying by 1,
+// or by 2 on a critical hit.
+func applyDamageWhileDown(db database.Service, encounterID int, c Combatant, critical bool) error {
+	increase := 1
+	if critical {
+		increase = 2
+	}
+
+	if GetDyingValue(c) == 0 {
+		if wounded, ok := findCondition(c, "wounded"); ok {
+			increase += wounded.GetValue()
+		}
+	}
+
+	if err := addValuedCondition(db, encounterID, c, "Dying", increase); err != nil {
+		return fmt.Errorf("error applying dying: %v", err)
+	}
+
+	return nil
+}
+
+// recoverFromDying removes dying and increases wounded by 1
+func recoverFromDying(db database.Service, encounterID int, c Combatant) (string, error) {
+	dying, ok := findCondition(c, "dying")
+	if !ok {
+		return "", nil
+	}
+
+	if err := c.RemoveCondition(db, encounterID, dying.ID); err != nil {
+		return "", fmt.Errorf("error removing dying: %v", err)
+	}
+
+	if err := addValuedCondition(db, encounterID, c, "Wounded", 1); err != nil {
+		return "", fmt.Errorf("error applying wounded: %v", err)
+	}
+
+	wounded, _ := findCondition(c, "wounded")
+	return fmt.Sprintf("%s is no longer dying and is now wounded %d", c.GetName(), wounded.GetValue()), nil
+}
+
+// ApplyRecoveryCheck applies the outcome of a dying combatant's recovery
+// check and returns a description of the result
+func ApplyRecoveryCheck(db database.Service, encounterID int, c Combatant, outcome string) (string, error) {
+	change, ok := recoveryCheckChanges[outcome]
+	if !ok {
+		return "", fmt.Errorf("unknown recovery check outcome %q", outcome)
+	}
+
+	dying, ok := findCondition(c, "dying")
+	if !ok || IsDead(c) {
+		return "", fmt.Errorf("%s is not dying", c.GetName())
+	}
+
+	if dying.GetValue()+change <= 0 {
+		return recoverFromDying(db, encounterID, c)
+	}
+
+	if err := c.SetCondition(db, encounterID, dying.ID, change); err != nil {
+		return "", fmt.Errorf("error updating dying: %v", err)
+	}
+
+	return DyingStatus(c), nil
+}
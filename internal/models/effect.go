@@ -0,0 +1,327 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+const (
+	EffectAnchorStart = "start"
+	EffectAnchorEnd   = "end"
+)
+
+// EffectSelectors are the statistics an effect's modifier can apply to, in
+// the order the effect form offers them
+var EffectSelectors = []string{"attack", "ac", "saving-throw", "perception", "skill-check", "spell-dc", "all"}
+
+var effectSelectorLabels = map[string]string{
+	"attack":       "attack rolls",
+	"ac":           "AC",
+	"saving-throw": "saves",
+	"perception":   "Perception",
+	"skill-check":  "skill checks",
+	"spell-dc":     "spell DCs",
+	"all":          "all checks and DCs",
+}
+
+// EffectModifierTypes are the bonus and penalty types an effect can apply
+var EffectModifierTypes = []string{"status", "circumstance", "item", "untyped"}
+
+// EffectModifier is the bonus or penalty an effect applies, such as +1
+// status to attack rolls for Bless. A zero value applies nothing.
+type EffectModifier struct {
+	Selector string `json:"selector,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Value    int    `json:"value,omitempty"`
+}
+
+func (m EffectModifier) String() string {
+	if m.Type == "untyped" {
+		return fmt.Sprintf("%+d to %s", m.Value, EffectSelectorLabel(m.Selector))
+	}
+
+	return fmt.Sprintf("%+d %s to %s", m.Value, m.Type, EffectSelectorLabel(m.Selector))
+}
+
+// EffectSelectorLabel names the statistic a selector applies to, e.g.
+// "attack rolls"
+func EffectSelectorLabel(selector string) string {
+	if label, ok := effectSelectorLabels[selector]; ok {
+		return label
+	}
+
+	return selector
+}
+
+// Effect is a timed effect on a combatant, such as "Bless" for 10 rounds.
+// Its rounds count down at the start or end of the anchor combatant's turn,
+// usually the one who created the effect, and it expires when they run out.
+// When the anchor combatant is removed, the effect counts down on its
+// target's own turn instead.
+type Effect struct {
+	ID                  int            `json:"id"`
+	Name                string         `json:"name"`
+	Rounds              int            `json:"rounds"`
+	Anchor              string         `json:"anchor"`
+	AnchorAssociationID int            `json:"anchor_association_id"`
+	AnchorIsMonster     bool           `json:"anchor_is_monster"`
+	AnchorName          string         `json:"anchor_name,omitempty"`
+	Modifier            EffectModifier `json:"modifier"`
+}
+
+func (e Effect) String() string {
+	if e.Rounds == 1 {
+		return fmt.Sprintf("%s (1 round)", e.Name)
+	}
+
+	return fmt.Sprintf("%s (%d rounds)", e.Name, e.Rounds)
+}
+
+// Description explains when the effect counts down, e.g. "Counts down at the
+// start of Valeros's turn"
+func (e Effect) Description() string {
+	anchor := e.AnchorName
+	if anchor == "" {
+		anchor = "the anchor combatant"
+	}
+
+	description := fmt.Sprintf("Counts down at the %s of %s's turn", e.Anchor, anchor)
+	if e.Modifier.Value != 0 {
+		description = e.Modifier.String() + ". " + description
+	}
+
+	return description
+}
+
+// GetFlatModifiers returns the effect's modifier in the form of the
+// condition rules, so both count towards the same statistics
+func (e Effect) GetFlatModifiers() []FlatModifier {
+	if e.Modifier.Value == 0 {
+		return nil
+	}
+
+	return []FlatModifier{{
+		Slug:      e.Name,
+		Type:      e.Modifier.Type,
+		Selectors: []string{e.Modifier.Selector},
+		Value:     e.Modifier.Value,
+	}}
+}
+
+// IsAnchoredTo reports whether the effect counts down on the combatant's turn
+func (e Effect) IsAnchoredTo(c Combatant) bool {
+	return e.AnchorAssociationID == c.GetAssociationID() && e.AnchorIsMonster == c.IsMonster()
+}
+
+func AddEffect(db database.Service, encounterID int, c Combatant, name string, rounds int, anchor string, anchorCombatant Combatant, modifier EffectModifier) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("effect needs a name")
+	}
+	if rounds < 1 {
+		return errors.New("effect needs to last at least 1 round")
+	}
+	if anchor != EffectAnchorStart && anchor != EffectAnchorEnd {
+		return fmt.Errorf("unknown effect anchor %q", anchor)
+	}
+	if modifier.Value == 0 {
+		modifier = EffectModifier{}
+	} else {
+		if !slices.Contains(EffectSelectors, modifier.Selector) {
+			return fmt.Errorf("unknown effect selector %q", modifier.Selector)
+		}
+		if !slices.Contains(EffectModifierTypes, modifier.Type) {
+			return fmt.Errorf("unknown modifier type %q", modifier.Type)
+		}
+	}
+
+	e := Effect{
+		Name:                name,
+		Rounds:              rounds,
+		Anchor:              anchor,
+		AnchorAssociationID: anchorCombatant.GetAssociationID(),
+		AnchorIsMonster:     anchorCombatant.IsMonster(),
+		AnchorName:          anchorCombatant.GetName(),
+		Modifier:            modifier,
+	}
+
+	modifierType := modifier.Type
+	if modifierType == "" {
+		modifierType = "untyped"
+	}
+
+	err := db.QueryRow(`
+        INSERT INTO combatant_effects (encounter_id, `+associationColumn(c)+`, name, rounds, anchor, `+anchorColumn(anchorCombatant)+`, selector, modifier_type, modifier_value)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id
+    `, encounterID, c.GetAssociationID(), name, rounds, anchor, e.AnchorAssociationID, modifier.Selector, modifierType, modifier.Value).Scan(&e.ID)
+
+	if err != nil {
+		return fmt.Errorf("error inserting effect: %v", err)
+	}
+
+	c.SetEffects(append(c.GetEffects(), e))
+
+	return nil
+}
+
+func RemoveEffect(db database.Service, encounterID int, c Combatant, effectID int) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	_, err := db.Exec(`
+        DELETE FROM combatant_effects
+        WHERE id = $1 AND encounter_id = $2 AND `+associationColumn(c)+` = $3
+    `, effectID, encounterID, c.GetAssociationID())
+
+	if err != nil {
+		return fmt.Errorf("error removing effect: %v", err)
+	}
+
+	var remaining []Effect
+	for _, e := range c.GetEffects() {
+		if e.ID != effectID {
+			remaining = append(remaining, e)
+		}
+	}
+	c.SetEffects(remaining)
+
+	return nil
+}
+
+// GetCombatantEffects loads the combatant's effects. Effects whose anchor
+// combatant was removed are anchored to the combatant itself.
+func GetCombatantEffects(db database.Service, encounterID int, associationID int, isMonster bool) ([]Effect, error) {
+	rows, err := db.Query(`
+        SELECT id, name, rounds, anchor, COALESCE(anchor_monster_id, anchor_custom_combatant_id, anchor_player_id), anchor_player_id IS NULL,
+               selector, modifier_type, modifier_value
+        FROM combatant_effects
+        WHERE encounter_id = $1 AND `+associationKey(isMonster)+` = $2
+        ORDER BY id
+    `, encounterID, associationID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying effects: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var effects []Effect
+	for rows.Next() {
+		var e Effect
+		var anchorID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Name, &e.Rounds, &e.Anchor, &anchorID, &e.AnchorIsMonster,
+			&e.Modifier.Selector, &e.Modifier.Type, &e.Modifier.Value); err != nil {
+			return nil, fmt.Errorf("error scanning effect row: %v", err)
+		}
+
+		e.AnchorAssociationID = int(anchorID.Int64)
+		if !anchorID.Valid {
+			e.AnchorAssociationID = associationID
+			e.AnchorIsMonster = isMonster
+		}
+		if e.Modifier.Value == 0 {
+			e.Modifier = EffectModifier{}
+		}
+		effects = append(effects, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating effect rows: %v", err)
+	}
+
+	return effects, nil
+}
+
+// anchorColumn returns the column referencing the anchor combatant's row in
+// encounter_monsters, encounter_custom_combatants or encounter_players
+func anchorColumn(c Combatant) string {
+	if _, ok := c.(*CustomCombatant); ok {
+		return "anchor_custom_combatant_id"
+	}
+	if c.IsMonster() {
+		return "anchor_monster_id"
+	}
+
+	return "anchor_player_id"
+}
+
+// nameEffectAnchors fills in the anchor combatant names of the combatants'
+// effects
+func nameEffectAnchors(combatants []Combatant) {
+	for _, c := range combatants {
+		effects := c.GetEffects()
+		for i := range effects {
+			for _, anchor := range combatants {
+				if effects[i].IsAnchoredTo(anchor) {
+					effects[i].AnchorName = anchor.GetName()
+				}
+			}
+		}
+	}
+}
+
+// ProcessEffects counts down the effects of all combatants that are anchored
+// to the start or end of the given combatant's turn, removes the ones that
+// run out and returns a description of what expired
+func ProcessEffects(db database.Service, encounterID int, combatants []Combatant, current Combatant, anchor string) ([]string, error) {
+	var changes []string
+
+	for _, c := range combatants {
+		// Work on a copy, removing an effect modifies the combatant's slice
+		effects := append([]Effect{}, c.GetEffects()...)
+
+		for _, e := range effects {
+			if e.Anchor != anchor || !e.IsAnchoredTo(current) {
+				continue
+			}
+
+			if e.Rounds <= 1 {
+				if err := RemoveEffect(db, encounterID, c, e.ID); err != nil {
+					return changes, err
+				}
+				changes = append(changes, fmt.Sprintf("%s on %s has expired", e.Name, c.GetName()))
+				continue
+			}
+
+			if err := setEffectRounds(db, encounterID, c, e.ID, e.Rounds-1); err != nil {
+				return changes, err
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func setEffectRounds(db database.Service, encounterID int, c Combatant, effectID int, rounds int) error {
+	_, err := db.Exec(`
+        UPDATE combatant_effects
+        SET rounds = $1
+        WHERE id = $2 AND encounter_id = $3 AND `+associationColumn(c)+` = $4
+    `, rounds, effectID, encounterID, c.GetAssociationID())
+
+	if err != nil {
+		return fmt.Errorf("error updating effect: %v", err)
+	}
+
+	effects := c.GetEffects()
+	for i := range effects {
+		if effects[i].ID == effectID {
+			effects[i].Rounds = rounds
+		}
+	}
+
+	return nil
+}
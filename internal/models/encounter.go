@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"pf2.encounterbrew.com/internal/database"
 )
@@ -18,10 +19,19 @@ type Encounter struct {
 	Party             *Party                     `json:"party,omitempty"`
 	Monsters          []*Monster                 `json:"monsters,omitempty"`
 	Players           []*Player                  `json:"players,omitempty"`
+	Companions        []*Player                  `json:"companions,omitempty"`
+	Customs           []*CustomCombatant         `json:"customs,omitempty"`
 	Combatants        []Combatant                `json:"combatants,omitempty"`
 	Round             int                        `json:"round"`
 	Turn              int                        `json:"turn"`
+	TurnAssociationID int                        `json:"turn_association_id"`
+	TurnIsMonster     bool                       `json:"turn_is_monster"`
 	GroupedConditions map[string][]ConditionInfo `json:"grouped_conditions"`
+	Messages          []string                   `json:"messages,omitempty"`
+	Status            string                     `json:"status"`
+	StartedAt         *time.Time                 `json:"started_at,omitempty"`
+	FinishedAt        *time.Time                 `json:"finished_at,omitempty"`
+	XpAward           *XpAward                   `json:"xp_award,omitempty"`
 }
 
 func CreateEncounter(db database.Service, name string, partyId int) (Encounter, error) {
@@ -29,11 +39,34 @@ func CreateEncounter(db database.Service, name string, partyId int) (Encounter,
 		return Encounter{}, errors.New("database service is nil")
 	}
 
+	tx, err := db.Begin()
+	if err != nil {
+		return Encounter{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	e, err := insertEncounter(tx, name, partyId)
+	if err != nil {
+		return Encounter{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Encounter{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return e, nil
+}
+
+// insertEncounter creates the encounter with the players of its party, so
+// callers can fill it in the same transaction
+func insertEncounter(tx *sql.Tx, name string, partyId int) (Encounter, error) {
 	var e Encounter
 	e.Name = name
 	e.PartyID = partyId
+	e.Status = EncounterPreparing
 
-	err := db.QueryRow(`
+	err := tx.QueryRow(`
 	    INSERT INTO encounters (name, party_id, user_id)
 	    VALUES ($1, $2, $3)
 	    RETURNING id
@@ -44,7 +77,7 @@ func CreateEncounter(db database.Service, name string, partyId int) (Encounter,
 	}
 
 	// Get all players from the new party
-	rows, err := db.Query(`
+	rows, err := tx.Query(`
 		SELECT id, hp FROM players
 		WHERE party_id = $1
 	`, partyId)
@@ -74,7 +107,7 @@ func CreateEncounter(db database.Service, name string, partyId int) (Encounter,
 
 	// Add each player from the new party to the encounter with their initial HP
 	for i, playerID := range playerIDs {
-		_, err = db.Exec(`
+		_, err = tx.Exec(`
 			INSERT INTO encounter_players (encounter_id, player_id, initiative, hp)
 			VALUES ($1, $2, $3, $4)
 		`, e.ID, playerID, 0, playerHPs[i])
@@ -127,10 +160,26 @@ func UpdateEncounter(db database.Service, encounterId int, name string, partyId
 			}
 		}()
 
-		// First, remove all existing players from the encounter
+		// The minions of the leaving players take their own turns from now
+		// on, like when a single player is removed
+		for _, table := range []string{"encounter_monsters", "encounter_players", "encounter_custom_combatants"} {
+			_, err = tx.Exec(`
+				UPDATE `+table+`
+				SET owner_association_id = 0, owner_is_monster = false
+				WHERE encounter_id = $1 AND NOT owner_is_monster AND owner_association_id IN (
+					SELECT id FROM encounter_players
+					WHERE encounter_id = $1 AND player_id IS NOT NULL
+				)
+			`, encounterId)
+			if err != nil {
+				return fmt.Errorf("error releasing minions: %v", err)
+			}
+		}
+
+		// Remove the players of the old party, companions stay in the encounter
 		_, err = tx.Exec(`
 			DELETE FROM encounter_players
-			WHERE encounter_id = $1
+			WHERE encounter_id = $1 AND player_id IS NOT NULL
 		`, encounterId)
 		if err != nil {
 			return fmt.Errorf("error removing existing players: %v", err)
@@ -188,6 +237,16 @@ func UpdateEncounter(db database.Service, encounterId int, name string, partyId
 			return fmt.Errorf("failed to update encounter: %w", err)
 		}
 
+		// Snapshots from before hold the players of the old party, so they
+		// can't be undone or redone anymore
+		_, err = tx.Exec(`
+			DELETE FROM encounter_history
+			WHERE encounter_id = $1
+		`, encounterId)
+		if err != nil {
+			return fmt.Errorf("error clearing encounter history: %v", err)
+		}
+
 		// Commit the transaction
 		if err = tx.Commit(); err != nil {
 			return fmt.Errorf("error committing transaction: %v", err)
@@ -209,22 +268,31 @@ func UpdateEncounter(db database.Service, encounterId int, name string, partyId
 	return nil
 }
 
-func UpdateTurnAndRound(db database.Service, turn int, round int, id int) error {
-	if id == 0 {
+// UpdateTurnAndRound stores the round and whose turn it is. The turn is
+// stored as the active combatant rather than their position, which changes
+// when combatants are added, removed or reordered.
+func UpdateTurnAndRound(db database.Service, e *Encounter) error {
+	if e.ID == 0 {
 		return errors.New("invalid encounter ID")
 	}
 
-	if turn < 0 || round < 0 {
+	if e.Turn < 0 || e.Round < 0 || (len(e.Combatants) > 0 && e.Turn >= len(e.Combatants)) {
 		return errors.New("invalid turn or round")
 	}
 
-	fmt.Printf("Turn and round updates: %d %d", turn, round)
+	e.TurnAssociationID, e.TurnIsMonster = 0, false
+	if len(e.Combatants) > 0 {
+		active := e.Combatants[e.Turn]
+		e.TurnAssociationID, e.TurnIsMonster = active.GetAssociationID(), active.IsMonster()
+	}
+
+	fmt.Printf("Turn and round updates: %d %d", e.Turn, e.Round)
 
 	_, err := db.Exec(`
 		UPDATE encounters
-		SET turn = $1, round = $2
-		WHERE id = $3
-	`, turn, round, id)
+		SET turn_association_id = $1, turn_is_monster = $2, round = $3
+		WHERE id = $4
+	`, e.TurnAssociationID, e.TurnIsMonster, e.Round, e.ID)
 
 	if err != nil {
 		return fmt.Errorf("failed to update encounter: %w", err)
@@ -260,12 +328,12 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
 	e.Party = &Party{}
 
 	err := db.QueryRow(`
-       SELECT e.id, e.name, e.user_id, e.party_id, e.turn, e.round, u.name AS user_name, p.name AS party_name
+       SELECT e.id, e.name, e.user_id, e.party_id, e.turn_association_id, e.turn_is_monster, e.round, u.name AS user_name, p.name AS party_name, e.status, e.started_at, e.finished_at
        FROM encounters e
        JOIN users u ON e.user_id = u.id
        JOIN parties p ON e.party_id = p.id
        WHERE e.user_id = $1 AND e.id = $2
-   `, 1, encounterId).Scan(&e.ID, &e.Name, &e.User.ID, &e.Party.ID, &e.Turn, &e.Round, &e.User.Name, &e.Party.Name)
+   `, 1, encounterId).Scan(&e.ID, &e.Name, &e.User.ID, &e.Party.ID, &e.TurnAssociationID, &e.TurnIsMonster, &e.Round, &e.User.Name, &e.Party.Name, &e.Status, &e.StartedAt, &e.FinishedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -275,7 +343,7 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
 	}
 
 	rows, err := db.Query(`
-        SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.enumeration
+        SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.temp_hp, em.enumeration, em.delay_state, em.initiative_order, em.actions_remaining, em.reaction_used, em.attacks_made, em.initiative_statistic, em.initiative_die, em.initiative_group, em.minion_kind, em.owner_association_id, em.owner_is_monster
         FROM monsters m
         JOIN encounter_monsters em ON m.id = em.monster_id
         WHERE em.encounter_id = $1
@@ -292,7 +360,7 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
 	for rows.Next() {
 		var m Monster
 		var jsonData []byte
-		var currentHp int
+		var currentHp, tempHp int
 		err := rows.Scan(
 			&m.ID,
 			&jsonData,
@@ -300,7 +368,19 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
 			&m.AssociationID,
 			&m.Initiative,
 			&currentHp,
+			&tempHp,
 			&m.Enumeration,
+			&m.DelayState,
+			&m.InitiativeOrder,
+			&m.ActionsRemaining,
+			&m.ReactionUsed,
+			&m.AttacksMade,
+			&m.InitiativeStatistic,
+			&m.InitiativeDie,
+			&m.InitiativeGroup,
+			&m.Minion.Kind,
+			&m.Minion.OwnerAssociationID,
+			&m.Minion.OwnerIsMonster,
 		)
 		if err != nil {
 			return e, fmt.Errorf("error scanning monster row: %v", err)
@@ -310,6 +390,7 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
 			return e, fmt.Errorf("error unmarshaling monster data: %v", err)
 		}
 		m.Data.System.Attributes.Hp.Value = currentHp // Set the current HP
+		m.Data.System.Attributes.Hp.Temp = tempHp
 		e.Monsters = append(e.Monsters, &m)
 	}
 
@@ -330,7 +411,16 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
         p.will,
         ep.initiative,
         ep.id as association_id,
-        ep.hp as current_hp
+        ep.hp as current_hp,
+        ep.temp_hp,
+        ep.delay_state,
+        ep.initiative_order,
+        ep.actions_remaining,
+        ep.reaction_used,
+        ep.attacks_made,
+        ep.initiative_statistic,
+        ep.initiative_die,
+        p.skills
     FROM players p
     JOIN encounter_players ep ON p.id = ep.player_id
     WHERE ep.encounter_id = $1
@@ -346,24 +436,36 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
 
 	for playerRows.Next() {
 		var player Player
-		var currentHp int
+		var skills []byte
 		err := playerRows.Scan(
 			&player.ID,
 			&player.Name,
 			&player.Level,
-			&player.Hp,
+			&player.MaxHp,
 			&player.Ac,
 			&player.Fort,
 			&player.Ref,
 			&player.Will,
 			&player.Initiative,
 			&player.AssociationID,
-			&currentHp,
+			&player.Hp,
+			&player.TempHp,
+			&player.DelayState,
+			&player.InitiativeOrder,
+			&player.ActionsRemaining,
+			&player.ReactionUsed,
+			&player.AttacksMade,
+			&player.InitiativeStatistic,
+			&player.InitiativeDie,
+			&skills,
 		)
 		if err != nil {
 			return e, fmt.Errorf("error scanning player row: %v", err)
 		}
-		player.Hp = currentHp // Set the current HP
+		if player.Skills, err = unmarshalSkills(skills); err != nil {
+			return e, err
+		}
+		player.EncounterID = encounterId
 		e.Players = append(e.Players, &player)
 	}
 
@@ -371,6 +473,16 @@ func GetEncounter(db database.Service, encounterId int) (Encounter, error) {
 		return e, fmt.Errorf("error iterating player rows: %v", err)
 	}
 
+	e.Companions, err = getEncounterCompanions(db, encounterId)
+	if err != nil {
+		return e, err
+	}
+
+	e.Customs, err = getEncounterCustomCombatants(db, encounterId)
+	if err != nil {
+		return e, err
+	}
+
 	// Load full party data for party level calculation
 	party, err := GetParty(db, e.Party.ID)
 	if err != nil {
@@ -393,18 +505,32 @@ func GetEncounterWithCombatants(db database.Service, encounterId int) (Encounter
 	players := encounter.Players
 	monsters := encounter.Monsters
 
-	combatants := make([]Combatant, 0, len(players)+len(monsters))
+	combatants := make([]Combatant, 0, len(players)+len(monsters)+len(encounter.Companions)+len(encounter.Customs))
 
 	// Add players to combatants
 	for i := range players {
 		combatants = append(combatants, players[i])
 	}
 
-	// Add monsters to combatants
+	// Add monsters to combatants, simple hazards only trigger and don't
+	// take turns
 	for _, monster := range monsters {
+		if monster.IsSimpleHazard() {
+			continue
+		}
 		combatants = append(combatants, monster)
 	}
 
+	// Add companions to combatants
+	for _, companion := range encounter.Companions {
+		combatants = append(combatants, companion)
+	}
+
+	// Add custom combatants to combatants
+	for _, custom := range encounter.Customs {
+		combatants = append(combatants, custom)
+	}
+
 	// Add combatants to the encounter
 	encounter.Combatants = combatants
 
@@ -416,8 +542,22 @@ func GetEncounterWithCombatants(db database.Service, encounterId int) (Encounter
 			return Encounter{}, fmt.Errorf("error fetching conditions for combatant: %w", err)
 		}
 		encounter.Combatants[i].SetConditions(conditions)
+
+		persistentDamage, err := GetCombatantPersistentDamage(db, encounterId, encounter.Combatants[i].GetAssociationID(), isMonster)
+		if err != nil {
+			return Encounter{}, fmt.Errorf("error fetching persistent damage for combatant: %w", err)
+		}
+		encounter.Combatants[i].SetPersistentDamage(persistentDamage)
+
+		effects, err := GetCombatantEffects(db, encounterId, encounter.Combatants[i].GetAssociationID(), isMonster)
+		if err != nil {
+			return Encounter{}, fmt.Errorf("error fetching effects for combatant: %w", err)
+		}
+		encounter.Combatants[i].SetEffects(effects)
 	}
 
+	nameEffectAnchors(encounter.Combatants)
+
 	return encounter, nil
 }
 
@@ -433,7 +573,9 @@ func GetAllEncounters(db database.Service) ([]Encounter, error) {
             e.user_id,
             e.party_id,
             u.name AS user_name,
-            p.name AS party_name
+            p.name AS party_name,
+            e.round,
+            e.status
         FROM encounters e
         JOIN users u ON e.user_id = u.id
         JOIN parties p ON e.party_id = p.id
@@ -462,6 +604,8 @@ func GetAllEncounters(db database.Service) ([]Encounter, error) {
 			&e.PartyID,
 			&e.User.Name,
 			&e.Party.Name,
+			&e.Round,
+			&e.Status,
 		)
 
 		if err != nil {
@@ -477,7 +621,22 @@ func GetAllEncounters(db database.Service) ([]Encounter, error) {
 	return encounters, nil
 }
 
-func AddMonsterToEncounter(db database.Service, encounterId int, monsterID int, levelAdjustment int, initiative int) (Encounter, error) {
+func AddMonsterToEncounter(db database.Service, encounterId int, monsterID int, levelAdjustment int, initiative InitiativeRoll) (Encounter, error) {
+	return AddMonstersToEncounter(db, encounterId, monsterID, levelAdjustment, []InitiativeRoll{initiative}, false)
+}
+
+const maxMonsterCopies = 20
+
+// AddMonstersToEncounter adds one copy of the monster per initiative roll,
+// numbered after the copies already in the encounter. Grouped copies share an
+// initiative group so their turns can be taken as one step.
+func AddMonstersToEncounter(db database.Service, encounterId int, monsterID int, levelAdjustment int, initiatives []InitiativeRoll, grouped bool) (Encounter, error) {
+	return addMonsters(db, encounterId, monsterID, levelAdjustment, initiatives, grouped, MinionLink{})
+}
+
+// addMonsters adds the copies of the monster, linked to an owner when they
+// were summoned
+func addMonsters(db database.Service, encounterId int, monsterID int, levelAdjustment int, initiatives []InitiativeRoll, grouped bool, link MinionLink) (Encounter, error) {
 	// Use a transaction to ensure atomicity
 	tx, err := db.Begin()
 	if err != nil {
@@ -486,19 +645,40 @@ func AddMonsterToEncounter(db database.Service, encounterId int, monsterID int,
 	//nolint:errcheck
 	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
 
+	if err = insertMonsters(tx, encounterId, monsterID, levelAdjustment, initiatives, grouped, link); err != nil {
+		return Encounter{}, err
+	}
+
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return Encounter{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	encounter, _ := GetEncounter(db, encounterId)
+
+	return encounter, nil
+}
+
+// insertMonsters adds one copy of the monster per initiative roll, numbered
+// after the copies already in the encounter
+func insertMonsters(tx *sql.Tx, encounterId int, monsterID int, levelAdjustment int, initiatives []InitiativeRoll, grouped bool, link MinionLink) error {
+	if err := ValidateCopies(len(initiatives)); err != nil {
+		return err
+	}
+
 	// Get the monster's initial HP and name from the data JSON field
 	var monsterData []byte
-	err = db.QueryRow(`
+	err := tx.QueryRow(`
         SELECT data FROM monsters WHERE id = $1
     `, monsterID).Scan(&monsterData)
 	if err != nil {
-		return Encounter{}, fmt.Errorf("failed to get monster's data: %v", err)
+		return fmt.Errorf("failed to get monster's data: %v", err)
 	}
 
 	var monster Monster
 	err = json.Unmarshal(monsterData, &monster.Data)
 	if err != nil {
-		return Encounter{}, fmt.Errorf("failed to unmarshal monster data: %v", err)
+		return fmt.Errorf("failed to unmarshal monster data: %v", err)
 	}
 
 	monsterHP := monster.Data.System.Attributes.Hp.Value
@@ -506,7 +686,7 @@ func AddMonsterToEncounter(db database.Service, encounterId int, monsterID int,
 
 	// Find the highest enumeration value for monsters of the same type in this encounter
 	var maxEnumeration int
-	err = db.QueryRow(`
+	err = tx.QueryRow(`
 		SELECT COALESCE(MAX(em.enumeration), 0)
 		FROM encounter_monsters em
 		JOIN monsters m ON em.monster_id = m.id
@@ -514,29 +694,48 @@ func AddMonsterToEncounter(db database.Service, encounterId int, monsterID int,
 		AND m.data->>'name' = $2
 	`, encounterId, monsterName).Scan(&maxEnumeration)
 	if err != nil {
-		return Encounter{}, fmt.Errorf("failed to get max enumeration: %v", err)
+		return fmt.Errorf("failed to get max enumeration: %v", err)
 	}
 
-	// Set the new enumeration to be one more than the highest existing enumeration
-	newEnumeration := maxEnumeration + 1
+	group := 0
+	if grouped {
+		if group, err = nextInitiativeGroup(tx, encounterId); err != nil {
+			return err
+		}
+	}
 
-	_, err = db.Exec(`
-        INSERT INTO encounter_monsters (encounter_id, monster_id, level_adjustment, initiative, hp, enumeration)
-        VALUES ($1, $2, $3, $4, $5, $6)
-    `, encounterId, monsterID, levelAdjustment, initiative, monsterHP, newEnumeration)
+	for i, initiative := range initiatives {
+		// Number each copy after the highest existing enumeration
+		_, err = tx.Exec(`
+            INSERT INTO encounter_monsters (encounter_id, monster_id, level_adjustment, initiative, initiative_statistic, initiative_die, initiative_group, hp, enumeration, minion_kind, owner_association_id, owner_is_monster)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        `, encounterId, monsterID, levelAdjustment, initiative.Total, initiative.Statistic, initiative.Die, group, monsterHP, maxEnumeration+i+1, link.Kind, link.OwnerAssociationID, link.OwnerIsMonster)
 
-	if err != nil {
-		return Encounter{}, fmt.Errorf("failed to add monster to encounter: %v", err)
+		if err != nil {
+			return fmt.Errorf("failed to add monster to encounter: %v", err)
+		}
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return Encounter{}, fmt.Errorf("error committing transaction: %v", err)
-	}
+	return nil
+}
 
-	encounter, _ := GetEncounter(db, encounterId)
+// nextInitiativeGroup returns an initiative group not yet used by the
+// encounter's monsters or custom combatants
+func nextInitiativeGroup(tx *sql.Tx, encounterId int) (int, error) {
+	var group int
+	err := tx.QueryRow(`
+		SELECT COALESCE(MAX(initiative_group), 0) + 1
+		FROM (
+			SELECT initiative_group FROM encounter_monsters WHERE encounter_id = $1
+			UNION ALL
+			SELECT initiative_group FROM encounter_custom_combatants WHERE encounter_id = $1
+		) groups
+	`, encounterId).Scan(&group)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get initiative group: %v", err)
+	}
 
-	return encounter, nil
+	return group, nil
 }
 
 func RemoveMonsterFromEncounter(db database.Service, encounterId int, associationID int) error {
@@ -595,73 +794,24 @@ func (e Encounter) GetPartyName() string {
 	return e.Party.Name
 }
 
+// GetDifficulty returns the encounter's threat tier, from ThreatTrivial to
+// ThreatExtreme, or -1 when it has no players
 func (e Encounter) GetDifficulty() int {
-	levels := 0
-	for _, player := range e.Players {
-		levels += player.Level
-	}
-
-	partyLevel := int(float64(levels) / float64(len(e.Players)))
-	monsterXpPool := 0.0
-
-	for _, combatant := range e.Combatants {
-		if combatant.GetType() == "monster" {
-			monsterLevel := combatant.GetLevel()
-			difference := monsterLevel - partyLevel
-
-			switch {
-			case difference <= -4:
-				monsterXpPool += 10.0
-			case difference == -3:
-				monsterXpPool += 15.0
-			case difference == -2:
-				monsterXpPool += 20.0
-			case difference == -1:
-				monsterXpPool += 30.0
-			case difference == 0:
-				monsterXpPool += 40.0
-			case difference == 1:
-				monsterXpPool += 60.0
-			case difference == 2:
-				monsterXpPool += 80.0
-			case difference == 3:
-				monsterXpPool += 120.0
-			case difference >= 4:
-				monsterXpPool += 160.0
-			}
-		}
-	}
-
-	threshold := float64(len(e.Players)) / 4.0
-
-	switch {
-	case monsterXpPool <= 40.0*threshold:
-		return 0
-	case monsterXpPool <= 60.0*threshold:
-		return 1
-	case monsterXpPool <= 80.0*threshold:
-		return 2
-	case monsterXpPool <= 120.0*threshold:
-		return 3
-	case monsterXpPool > 120.0*threshold:
-		return 4
-	}
-
-	return 0
+	return e.GetXpBudget().Threat()
 }
 
 func GetCombatantConditions(db database.Service, encounterID int, associationID int, isMonster bool) ([]Condition, error) {
 	var query string
 	if isMonster {
 		query = `
-            SELECT c.id, c.data, cc.condition_value
+            SELECT c.id, c.data, cc.condition_value, COALESCE(cc.granted_by, 0)
             FROM combatant_conditions cc
             JOIN conditions c ON cc.condition_id = c.id
-            WHERE cc.encounter_id = $1 AND cc.encounter_monster_id = $2
+            WHERE cc.encounter_id = $1 AND COALESCE(cc.encounter_monster_id, cc.encounter_custom_combatant_id) = $2
         `
 	} else {
 		query = `
-            SELECT c.id, c.data, cc.condition_value
+            SELECT c.id, c.data, cc.condition_value, COALESCE(cc.granted_by, 0)
             FROM combatant_conditions cc
             JOIN conditions c ON cc.condition_id = c.id
             WHERE cc.encounter_id = $1 AND cc.encounter_player_id = $2
@@ -683,7 +833,7 @@ func GetCombatantConditions(db database.Service, encounterID int, associationID
 		var c Condition
 		var jsonData []byte
 		var conditionValue int
-		err := rows.Scan(&c.ID, &jsonData, &conditionValue)
+		err := rows.Scan(&c.ID, &jsonData, &conditionValue, &c.GrantedBy)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning condition row: %v", err)
 		}
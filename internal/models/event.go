@@ -0,0 +1,188 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+const (
+	EventHp         = "hp"
+	EventCondition  = "condition"
+	EventInitiative = "initiative"
+	EventTurn       = "turn"
+	EventRule       = "rule"
+	EventCombatant  = "combatant"
+	EventHistory    = "history"
+	EventLifecycle  = "lifecycle"
+)
+
+// EncounterEvent is an entry in an encounter's combat log. Amount is the
+// damage taken for HP events, negative for healing.
+type EncounterEvent struct {
+	ID                int       `json:"id"`
+	EncounterID       int       `json:"encounter_id"`
+	Kind              string    `json:"kind"`
+	CombatantName     string    `json:"combatant_name"`
+	Amount            int       `json:"amount"`
+	DamageType        string    `json:"damage_type"`
+	Source            string    `json:"source"`
+	Description       string    `json:"description"`
+	Round             int       `json:"round"`
+	TurnCombatantName string    `json:"turn_combatant_name"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// NewEvent creates an event about the combatant, c may be nil for events
+// about the whole encounter
+func NewEvent(kind string, c Combatant, description string) EncounterEvent {
+	event := EncounterEvent{Kind: kind, Description: description}
+	if c != nil {
+		event.CombatantName = c.GetName()
+	}
+
+	return event
+}
+
+// NewHpEvent creates an event for damage taken by the combatant, or healing
+// when the amount is negative
+func NewHpEvent(c Combatant, amount int, damageType string, source string) EncounterEvent {
+	event := NewEvent(EventHp, c, "")
+	event.Amount = amount
+	event.DamageType = damageType
+	event.Source = source
+
+	return event
+}
+
+// NewConditionEvent creates an event for a condition the combatant gained or
+// whose value changed, or lost when removed is set
+func NewConditionEvent(c Combatant, condition Condition, removed bool) EncounterEvent {
+	switch {
+	case removed:
+		return NewEvent(EventCondition, c, fmt.Sprintf("%s is no longer %s", c.GetName(), condition.GetSlug()))
+	case condition.IsValued():
+		return NewEvent(EventCondition, c, fmt.Sprintf("%s is now %s %d", c.GetName(), condition.GetSlug(), condition.GetValue()))
+	default:
+		return NewEvent(EventCondition, c, fmt.Sprintf("%s is now %s", c.GetName(), condition.GetSlug()))
+	}
+}
+
+// NewInitiativeEvent creates an event for the combatant's current initiative
+func NewInitiativeEvent(c Combatant) EncounterEvent {
+	roll := c.GetInitiativeRoll()
+	if roll.Statistic == "" {
+		return NewEvent(EventInitiative, c, fmt.Sprintf("%s's initiative is now %d", c.GetName(), roll.Total))
+	}
+
+	return NewEvent(EventInitiative, c, fmt.Sprintf("%s's initiative is now %d (%s)", c.GetName(), roll.Total, roll))
+}
+
+// NewTurnEvent creates an event for the encounter's current turn and round
+func NewTurnEvent(e *Encounter) EncounterEvent {
+	if e.Turn < 0 || e.Turn >= len(e.Combatants) {
+		return NewEvent(EventTurn, nil, fmt.Sprintf("Round %d", e.Round))
+	}
+
+	c := e.Combatants[e.Turn]
+	return NewEvent(EventTurn, c, fmt.Sprintf("Round %d: %s's turn", e.Round, c.GetName()))
+}
+
+// NewRuleEvents creates an event for each change the rules made on their own,
+// such as conditions running out at the end of a turn
+func NewRuleEvents(changes []string) []EncounterEvent {
+	events := make([]EncounterEvent, len(changes))
+	for i, change := range changes {
+		events[i] = NewEvent(EventRule, nil, change)
+	}
+
+	return events
+}
+
+// String describes the event for the timeline, e.g. "Goblin Warrior 1 takes
+// 8 fire damage from Ezren"
+func (e EncounterEvent) String() string {
+	if e.Kind != EventHp {
+		return e.Description
+	}
+
+	description := e.Description
+	switch {
+	case description != "":
+	case e.Amount < 0:
+		description = fmt.Sprintf("%s heals %d HP", e.CombatantName, -e.Amount)
+	case e.DamageType != "":
+		description = fmt.Sprintf("%s takes %d %s damage", e.CombatantName, e.Amount, e.DamageType)
+	default:
+		description = fmt.Sprintf("%s takes %d damage", e.CombatantName, e.Amount)
+	}
+
+	if e.Source != "" {
+		description += " from " + e.Source
+	}
+
+	return description
+}
+
+// LogEvents appends the events to the encounter's combat log, stamped with the
+// current round and whose turn it is
+func LogEvents(db database.Service, e *Encounter, events ...EncounterEvent) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	turn := ""
+	for _, c := range e.Combatants {
+		if e.isTurnOf(c) {
+			turn = c.GetName()
+		}
+	}
+
+	for _, event := range events {
+		_, err := db.Exec(`
+            INSERT INTO encounter_events (encounter_id, kind, combatant_name, amount, damage_type, source, description, round, turn_combatant_name)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        `, e.ID, event.Kind, event.CombatantName, event.Amount, event.DamageType, event.Source, event.Description, e.Round, turn)
+
+		if err != nil {
+			return fmt.Errorf("error logging encounter event: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetEncounterEvents returns the encounter's combat log, oldest first
+func GetEncounterEvents(db database.Service, encounterID int) ([]EncounterEvent, error) {
+	rows, err := db.Query(`
+        SELECT id, encounter_id, kind, combatant_name, amount, damage_type, source, description, round, turn_combatant_name, created_at
+        FROM encounter_events
+        WHERE encounter_id = $1
+        ORDER BY id
+    `, encounterID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying encounter events: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var events []EncounterEvent
+	for rows.Next() {
+		var e EncounterEvent
+		if err := rows.Scan(&e.ID, &e.EncounterID, &e.Kind, &e.CombatantName, &e.Amount, &e.DamageType, &e.Source, &e.Description, &e.Round, &e.TurnCombatantName, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning encounter event row: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating encounter event rows: %v", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,377 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+// Encounter patterns from the Gamemastery Guide the generator can build
+const (
+	PatternSolo  = "solo"
+	PatternBoss  = "boss"
+	PatternHorde = "horde"
+)
+
+var patternNames = map[string]string{
+	PatternSolo:  "solo boss",
+	PatternBoss:  "boss and minions",
+	PatternHorde: "horde",
+}
+
+const (
+	maxCandidates = 200
+	minHordeSize  = 4
+	maxHordeSize  = 12
+	// The boss takes at most 3/4 of the budget, leaving the rest to minions
+	minionXpShare = 4
+)
+
+// GeneratorOptions describes the encounter to generate for a party
+type GeneratorOptions struct {
+	PartyLevel       int                  `json:"party_level"`
+	PartySize        int                  `json:"party_size"`
+	Threat           int                  `json:"threat"`
+	Pattern          string               `json:"pattern"`
+	Filters          MonsterSearchFilters `json:"filters"`
+	AllowAdjustments bool                 `json:"allow_adjustments"`
+}
+
+// GeneratedCreature is a creature of a generated encounter, with its elite or
+// weak adjustment and the number of copies
+type GeneratedCreature struct {
+	Monster  Monster `json:"monster"`
+	Quantity int     `json:"quantity"`
+	Xp       int     `json:"xp"`
+}
+
+// GeneratedEncounter is the preview of a generated encounter
+type GeneratedEncounter struct {
+	Creatures  []GeneratedCreature `json:"creatures"`
+	ThreatName string              `json:"threat_name"`
+	Budget     int                 `json:"budget"`
+	Xp         int                 `json:"xp"`
+}
+
+// ParseTraits splits a comma-separated list of traits such as
+// "undead, aquatic" into the lowercase traits of the bestiary data
+func ParseTraits(traits string) []string {
+	var parsed []string
+	for _, trait := range strings.Split(traits, ",") {
+		if trait = strings.ToLower(strings.TrimSpace(trait)); trait != "" {
+			parsed = append(parsed, trait)
+		}
+	}
+
+	return parsed
+}
+
+// GenerateEncounter picks creatures from the bestiary that match the filters
+// and fill the XP budget of the threat for the party
+func GenerateEncounter(db database.Service, options GeneratorOptions) (GeneratedEncounter, error) {
+	candidates, err := getGeneratorCandidates(db, options)
+	if err != nil {
+		return GeneratedEncounter{}, err
+	}
+
+	//nolint:gosec
+	return PlanEncounter(candidates, options, rand.New(rand.NewSource(rand.Int63())))
+}
+
+// getGeneratorCandidates returns a random selection of creatures whose level
+// can be worth XP to the party, one level wider on each side when they can be
+// made elite or weak
+func getGeneratorCandidates(db database.Service, options GeneratorOptions) ([]Monster, error) {
+	if db == nil {
+		return nil, errors.New("database service is nil")
+	}
+
+	spread := 4
+	if options.AllowAdjustments {
+		spread = 5
+	}
+
+	filters := options.Filters
+	filters.Kind = "creature"
+	minLevel := options.PartyLevel - spread
+	if filters.MinLevel != nil {
+		minLevel = max(minLevel, *filters.MinLevel)
+	}
+	maxLevel := options.PartyLevel + spread
+	if filters.MaxLevel != nil {
+		maxLevel = min(maxLevel, *filters.MaxLevel)
+	}
+	filters.MinLevel = &minLevel
+	filters.MaxLevel = &maxLevel
+
+	whereConditions, queryArgs := monsterFilterConditions(filters, nil)
+	queryArgs = append(queryArgs, maxCandidates)
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT id, data
+		FROM monsters
+		WHERE %s
+		ORDER BY random()
+		LIMIT $%d
+	`, strings.Join(whereConditions, " AND "), len(queryArgs)), queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting creatures: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var monsters []Monster
+	for rows.Next() {
+		var m Monster
+		var jsonData []byte
+		if err := rows.Scan(&m.ID, &jsonData); err != nil {
+			return nil, fmt.Errorf("error scanning creature: %v", err)
+		}
+		if err := json.Unmarshal(jsonData, &m.Data); err != nil {
+			return nil, fmt.Errorf("error unmarshaling creature: %v", err)
+		}
+		monsters = append(monsters, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over creatures: %v", err)
+	}
+
+	return monsters, nil
+}
+
+// PlanEncounter builds an encounter of the pattern from the candidates. Each
+// slot is filled with a creature of the right level, or an elite or weak one
+// when there is none and adjustments are allowed. XP left over is then spent
+// on making creatures elite.
+func PlanEncounter(candidates []Monster, options GeneratorOptions, rng *rand.Rand) (GeneratedEncounter, error) {
+	if options.PartySize < 1 {
+		return GeneratedEncounter{}, errors.New("the party has no players")
+	}
+	if options.Threat < ThreatTrivial || options.Threat > ThreatExtreme {
+		return GeneratedEncounter{}, fmt.Errorf("unknown threat %d", options.Threat)
+	}
+
+	planner := encounterPlanner{
+		options:    options,
+		rng:        rng,
+		candidates: map[int][]Monster{},
+		adjusted:   map[int][]Monster{},
+	}
+	planner.sort(candidates)
+
+	generated := GeneratedEncounter{
+		ThreatName: threatTiers[options.Threat].name,
+		Budget:     tierBudget(options.Threat, options.PartySize),
+	}
+
+	var ok bool
+	switch options.Pattern {
+	case PatternSolo:
+		generated.Creatures, ok = planner.solo(generated.Budget)
+	case PatternBoss:
+		generated.Creatures, ok = planner.bossAndMinions(generated.Budget)
+	case PatternHorde:
+		generated.Creatures, ok = planner.horde(generated.Budget)
+	default:
+		return GeneratedEncounter{}, fmt.Errorf("unknown encounter pattern %q", options.Pattern)
+	}
+	if !ok {
+		return GeneratedEncounter{}, fmt.Errorf("no creatures matching the filters make a %s %s encounter", strings.ToLower(generated.ThreatName), patternNames[options.Pattern])
+	}
+
+	for _, creature := range generated.Creatures {
+		generated.Xp += creature.Xp
+	}
+	if options.AllowAdjustments {
+		generated.Xp = planner.spendLeftover(generated.Creatures, generated.Budget-generated.Xp, generated.Xp)
+	}
+
+	return generated, nil
+}
+
+// encounterPlanner holds the candidates by the difference between their
+// level and the party's
+type encounterPlanner struct {
+	options    GeneratorOptions
+	rng        *rand.Rand
+	candidates map[int][]Monster
+	adjusted   map[int][]Monster
+}
+
+func (p *encounterPlanner) sort(candidates []Monster) {
+	adjustments := []int{0}
+	if p.options.AllowAdjustments {
+		adjustments = []int{0, 1, -1}
+	}
+
+	for _, candidate := range candidates {
+		for _, adjustment := range adjustments {
+			candidate.LevelAdjustment = adjustment
+			difference := candidate.GetLevel() - p.options.PartyLevel
+			if difference < -4 || difference > 4 {
+				continue
+			}
+
+			if adjustment == 0 {
+				p.candidates[difference] = append(p.candidates[difference], candidate)
+			} else {
+				p.adjusted[difference] = append(p.adjusted[difference], candidate)
+			}
+		}
+	}
+}
+
+// pick returns a random creature of the level difference, preferring one
+// that doesn't need an adjustment and isn't already in the encounter
+func (p *encounterPlanner) pick(difference int, quantity int, taken []GeneratedCreature) (GeneratedCreature, bool) {
+	for _, pool := range [][]Monster{p.candidates[difference], p.adjusted[difference]} {
+		var free []Monster
+		for _, monster := range pool {
+			if !isGenerated(monster, taken) {
+				free = append(free, monster)
+			}
+		}
+		if len(free) == 0 {
+			continue
+		}
+
+		monster := free[p.rng.Intn(len(free))]
+		return GeneratedCreature{Monster: monster, Quantity: quantity, Xp: quantity * creatureXp(difference)}, true
+	}
+
+	return GeneratedCreature{}, false
+}
+
+// solo picks the strongest single creature the budget affords
+func (p *encounterPlanner) solo(budget int) ([]GeneratedCreature, bool) {
+	for difference := 4; difference >= -4; difference-- {
+		if creatureXp(difference) > budget {
+			continue
+		}
+		if boss, ok := p.pick(difference, 1, nil); ok {
+			return []GeneratedCreature{boss}, true
+		}
+	}
+
+	return nil, false
+}
+
+// bossAndMinions picks a boss above the party's level and spends the rest of
+// the budget on at least two weaker minions
+func (p *encounterPlanner) bossAndMinions(budget int) ([]GeneratedCreature, bool) {
+	for bossDifference := 4; bossDifference >= 0; bossDifference-- {
+		bossXp := creatureXp(bossDifference)
+		if bossXp*minionXpShare > budget*(minionXpShare-1) {
+			continue
+		}
+
+		boss, ok := p.pick(bossDifference, 1, nil)
+		if !ok {
+			continue
+		}
+
+		for minionDifference := -1; minionDifference >= -4; minionDifference-- {
+			quantity := (budget - bossXp) / creatureXp(minionDifference)
+			if quantity < 2 || quantity > maxHordeSize {
+				continue
+			}
+			if minions, ok := p.pick(minionDifference, quantity, []GeneratedCreature{boss}); ok {
+				return []GeneratedCreature{boss, minions}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// horde picks many copies of the strongest creature below the party's level
+// that fits between four and twelve times in the budget
+func (p *encounterPlanner) horde(budget int) ([]GeneratedCreature, bool) {
+	for difference := -1; difference >= -4; difference-- {
+		quantity := min(budget/creatureXp(difference), maxHordeSize)
+		if quantity < minHordeSize {
+			continue
+		}
+		if horde, ok := p.pick(difference, quantity, nil); ok {
+			return []GeneratedCreature{horde}, true
+		}
+	}
+
+	return nil, false
+}
+
+// spendLeftover makes creatures elite, or no longer weak, while the XP left
+// in the budget pays for it and returns the new total
+func (p *encounterPlanner) spendLeftover(creatures []GeneratedCreature, leftover int, xp int) int {
+	for i := range creatures {
+		creature := &creatures[i]
+		if creature.Monster.LevelAdjustment > 0 {
+			continue
+		}
+
+		stronger := creature.Monster
+		stronger.LevelAdjustment++
+		strongerXp := creature.Quantity * creatureXp(stronger.GetLevel()-p.options.PartyLevel)
+		if cost := strongerXp - creature.Xp; cost > 0 && cost <= leftover {
+			creature.Monster = stronger
+			creature.Xp = strongerXp
+			leftover -= cost
+			xp += cost
+		}
+	}
+
+	return xp
+}
+
+func isGenerated(monster Monster, creatures []GeneratedCreature) bool {
+	for _, creature := range creatures {
+		if creature.Monster.ID == monster.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SaveGeneratedEncounter creates an encounter for the party with the
+// generated creatures and their initiative rolls. Nothing is saved unless
+// every creature could be added.
+func SaveGeneratedEncounter(db database.Service, name string, partyID int, creatures []GeneratedCreature, initiatives [][]InitiativeRoll) (Encounter, error) {
+	if db == nil {
+		return Encounter{}, errors.New("database service is nil")
+	}
+	if len(creatures) == 0 || len(creatures) != len(initiatives) {
+		return Encounter{}, errors.New("the generated encounter has no creatures")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return Encounter{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	encounter, err := insertEncounter(tx, name, partyID)
+	if err != nil {
+		return Encounter{}, err
+	}
+
+	for i, creature := range creatures {
+		if err := insertMonsters(tx, encounter.ID, creature.Monster.ID, creature.Monster.LevelAdjustment, initiatives[i], false, MinionLink{}); err != nil {
+			return Encounter{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Encounter{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return encounter, nil
+}
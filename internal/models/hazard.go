@@ -0,0 +1,115 @@
+package models
+
+import (
+	"strings"
+
+	"pf2.encounterbrew.com/internal/utils"
+)
+
+// HazardType is the actor type of traps, haunts and other hazards in the
+// bestiary data
+const HazardType = "hazard"
+
+// IsHazard reports whether the monster is a hazard rather than a creature
+func (m Monster) IsHazard() bool {
+	return m.Data.Type == HazardType
+}
+
+// IsComplexHazard reports whether the monster is a complex hazard, which
+// rolls initiative and acts on its own turns
+func (m Monster) IsComplexHazard() bool {
+	return m.IsHazard() && m.Data.System.Details.IsComplex
+}
+
+// IsSimpleHazard reports whether the monster is a simple hazard, which only
+// triggers and stays out of the initiative order
+func (m Monster) IsSimpleHazard() bool {
+	return m.IsHazard() && !m.Data.System.Details.IsComplex
+}
+
+// HasHealth reports whether the monster can be damaged, hazards without
+// hit points can only be disabled
+func (m Monster) HasHealth() bool {
+	return !m.IsHazard() || m.Data.System.Attributes.HasHealth
+}
+
+func (m Monster) GetStealthModifier() int {
+	return m.Data.System.Attributes.Stealth.Value + m.AdjustMonster()["mod"]
+}
+
+// GetStealthDC returns the DC to notice the hazard
+func (m Monster) GetStealthDC() int {
+	return 10 + m.GetStealthModifier()
+}
+
+// GetStealthDetails returns the proficiency needed to notice the hazard and
+// any notes on it, e.g. "(expert)"
+func (m Monster) GetStealthDetails() string {
+	return hazardText(m.Data.System.Attributes.Stealth.Details)
+}
+
+func (m Monster) GetHardness() int {
+	return m.Data.System.Attributes.Hardness
+}
+
+func (m Monster) GetHpDetails() string {
+	return hazardText(m.Data.System.Attributes.Hp.Details)
+}
+
+func (m Monster) GetHazardDescription() string {
+	return hazardText(m.Data.System.Details.Description)
+}
+
+func (m Monster) GetDisable() string {
+	return hazardText(m.Data.System.Details.Disable)
+}
+
+func (m Monster) GetReset() string {
+	return hazardText(m.Data.System.Details.Reset)
+}
+
+func (m Monster) GetRoutine() string {
+	return hazardText(m.Data.System.Details.Routine)
+}
+
+// GetHazardActions returns the hazard's reactions and other abilities, which
+// don't come with a statblock category like a creature's do
+func (m Monster) GetHazardActions() []map[string]string {
+	var actions []map[string]string
+	for _, category := range []string{"", "interaction", "defensive", "offensive"} {
+		actions = append(actions, m.GetActions(category)...)
+	}
+
+	return actions
+}
+
+// hazardText turns inline rolls and links in the hazard's text into plain
+// text, keeping the HTML formatting
+func hazardText(text string) string {
+	return strings.TrimSpace(utils.RemoveHTML(utils.NewReplacer().ProcessText(text)))
+}
+
+// hazardXp returns the XP a hazard is worth for a party of the given level.
+// Complex hazards are worth as much as a creature of the same level, simple
+// hazards a fifth of that.
+func hazardXp(hazard *Monster, partyLevel int) int {
+	xp := creatureXp(hazard.GetLevel() - partyLevel)
+	if hazard.IsSimpleHazard() {
+		return xp / 5
+	}
+
+	return xp
+}
+
+// SimpleHazards returns the encounter's simple hazards, which aren't part of
+// the initiative order
+func (e Encounter) SimpleHazards() []*Monster {
+	var hazards []*Monster
+	for _, m := range e.Monsters {
+		if m.IsSimpleHazard() {
+			hazards = append(hazards, m)
+		}
+	}
+
+	return hazards
+}
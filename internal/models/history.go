@@ -0,0 +1,358 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"pf2.encounterbrew.com/internal/database"
+)
+
+const (
+	historyUndo = "undo"
+	historyRedo = "redo"
+
+	// HistoryLimit is the number of changes that can be undone
+	HistoryLimit = 20
+)
+
+// historyTables hold an encounter's combat state, tables referenced by
+// others first
+var historyTables = []string{
+	"encounter_monsters",
+	"encounter_players",
+	"encounter_custom_combatants",
+	"combatant_conditions",
+	"persistent_damage",
+	"combatant_effects",
+}
+
+// encounterState is a snapshot of an encounter's combat state: the turn and
+// round, the XP award it holds and the rows of each history table as JSON
+type encounterState struct {
+	TurnAssociationID int                        `json:"turn_association_id"`
+	TurnIsMonster     bool                       `json:"turn_is_monster"`
+	Round             int                        `json:"round"`
+	Status            string                     `json:"status"`
+	XpAwardID         int                        `json:"xp_award_id,omitempty"`
+	Tables            map[string]json.RawMessage `json:"tables"`
+}
+
+// UndoSnapshot is the encounter's state before a change, saved to the undo
+// history only once the change succeeded
+type UndoSnapshot struct {
+	EncounterID int
+	Label       string
+	state       []byte
+}
+
+// RecordUndo saves the encounter's current state before a change so the change
+// can be undone
+func RecordUndo(db database.Service, encounterID int, label string) error {
+	snapshot, err := SnapshotUndo(db, encounterID, label)
+	if err != nil {
+		return err
+	}
+
+	return snapshot.Save(db)
+}
+
+// SnapshotUndo takes the encounter's state before a change without adding it
+// to the undo history yet
+func SnapshotUndo(db database.Service, encounterID int, label string) (UndoSnapshot, error) {
+	if db == nil {
+		return UndoSnapshot{}, errors.New("database service is nil")
+	}
+
+	state, err := getEncounterState(db, encounterID)
+	if err != nil {
+		return UndoSnapshot{}, err
+	}
+
+	return UndoSnapshot{EncounterID: encounterID, Label: label, state: state}, nil
+}
+
+// Save adds the snapshot to the undo history. A new change can't be redone
+// past, so it clears the redo stack, and only the last HistoryLimit changes
+// are kept.
+func (s UndoSnapshot) Save(db database.Service) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	if err := pushHistory(tx, s.EncounterID, historyUndo, s.Label, s.state); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+        DELETE FROM encounter_history
+        WHERE encounter_id = $1 AND stack = $2
+    `, s.EncounterID, historyRedo)
+	if err != nil {
+		return fmt.Errorf("error clearing redo history: %v", err)
+	}
+
+	_, err = tx.Exec(`
+        DELETE FROM encounter_history
+        WHERE encounter_id = $1 AND stack = $2 AND id NOT IN (
+            SELECT id FROM encounter_history
+            WHERE encounter_id = $1 AND stack = $2
+            ORDER BY id DESC
+            LIMIT $3
+        )
+    `, s.EncounterID, historyUndo, HistoryLimit)
+	if err != nil {
+		return fmt.Errorf("error trimming undo history: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Undo restores the state from before the last change and returns its label
+func Undo(db database.Service, encounterID int) (string, error) {
+	return travelHistory(db, encounterID, historyUndo, historyRedo)
+}
+
+// Redo reapplies the last undone change and returns its label
+func Redo(db database.Service, encounterID int) (string, error) {
+	return travelHistory(db, encounterID, historyRedo, historyUndo)
+}
+
+// travelHistory restores the latest state on one stack and saves the current
+// state on the other one, so the step can be reversed again
+func travelHistory(db database.Service, encounterID int, from string, to string) (string, error) {
+	if db == nil {
+		return "", errors.New("database service is nil")
+	}
+
+	var id int
+	var label string
+	var state []byte
+	err := db.QueryRow(`
+        SELECT id, label, state
+        FROM encounter_history
+        WHERE encounter_id = $1 AND stack = $2
+        ORDER BY id DESC
+        LIMIT 1
+    `, encounterID, from).Scan(&id, &label, &state)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("nothing to %s", from)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting %s history: %v", from, err)
+	}
+
+	current, err := getEncounterState(db, encounterID)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	if _, err := tx.Exec(`DELETE FROM encounter_history WHERE id = $1`, id); err != nil {
+		return "", fmt.Errorf("error removing %s history: %v", from, err)
+	}
+
+	if err := pushHistory(tx, encounterID, to, label, current); err != nil {
+		return "", err
+	}
+
+	if err := restoreEncounterState(tx, encounterID, state); err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return label, nil
+}
+
+func pushHistory(tx *sql.Tx, encounterID int, stack string, label string, state []byte) error {
+	_, err := tx.Exec(`
+        INSERT INTO encounter_history (encounter_id, stack, label, state)
+        VALUES ($1, $2, $3, $4)
+    `, encounterID, stack, label, state)
+
+	if err != nil {
+		return fmt.Errorf("error saving %s history: %v", stack, err)
+	}
+
+	return nil
+}
+
+// getEncounterState takes a snapshot of the encounter's combat state
+func getEncounterState(db database.Service, encounterID int) ([]byte, error) {
+	state := encounterState{Tables: map[string]json.RawMessage{}}
+
+	err := db.QueryRow(`
+        SELECT turn_association_id, turn_is_monster, round, status,
+            COALESCE((SELECT id FROM party_xp_awards WHERE encounter_id = encounters.id), 0)
+        FROM encounters
+        WHERE id = $1
+    `, encounterID).Scan(&state.TurnAssociationID, &state.TurnIsMonster, &state.Round, &state.Status, &state.XpAwardID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting encounter state: %v", err)
+	}
+
+	for _, table := range historyTables {
+		var rows []byte
+		err := db.QueryRow(`
+            SELECT COALESCE(json_agg(t), '[]')
+            FROM `+table+` t
+            WHERE t.encounter_id = $1
+        `, encounterID).Scan(&rows)
+		if err != nil {
+			return nil, fmt.Errorf("error getting %s state: %v", table, err)
+		}
+		state.Tables[table] = rows
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding encounter state: %v", err)
+	}
+
+	return data, nil
+}
+
+// restoreEncounterState replaces the encounter's combat state with the
+// snapshot, bringing back removed combatants with their original ids
+func restoreEncounterState(tx *sql.Tx, encounterID int, data []byte) error {
+	var state encounterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error decoding encounter state: %v", err)
+	}
+
+	for i := len(historyTables) - 1; i >= 0; i-- {
+		if _, err := tx.Exec(`DELETE FROM `+historyTables[i]+` WHERE encounter_id = $1`, encounterID); err != nil {
+			return fmt.Errorf("error clearing %s: %v", historyTables[i], err)
+		}
+	}
+
+	for _, table := range historyTables {
+		rows, ok := state.Tables[table]
+		if !ok {
+			continue
+		}
+
+		if err := restoreRows(tx, table, rows); err != nil {
+			return err
+		}
+	}
+
+	// Snapshots from before encounters had a status keep the current one
+	_, err := tx.Exec(`
+        UPDATE encounters
+        SET turn_association_id = $1, turn_is_monster = $2, round = $3, status = COALESCE(NULLIF($4, ''), status)
+        WHERE id = $5
+    `, state.TurnAssociationID, state.TurnIsMonster, state.Round, state.Status, encounterID)
+	if err != nil {
+		return fmt.Errorf("error restoring turn and round: %v", err)
+	}
+
+	// Undoing a reset reattaches the award it detached, unless the encounter
+	// has earned XP again since
+	if state.XpAwardID != 0 {
+		_, err = tx.Exec(`
+            UPDATE party_xp_awards
+            SET encounter_id = $1
+            WHERE id = $2 AND encounter_id IS NULL AND NOT EXISTS (
+                SELECT 1 FROM party_xp_awards WHERE encounter_id = $1
+            )
+        `, encounterID, state.XpAwardID)
+		if err != nil {
+			return fmt.Errorf("error restoring XP award: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// restoreRows inserts the snapshot's rows into the table. Only the columns
+// both the snapshot and the table have are restored, so columns added by
+// later migrations get their defaults and dropped ones are left out.
+func restoreRows(tx *sql.Tx, table string, rows json.RawMessage) error {
+	var records []map[string]json.RawMessage
+	if err := json.Unmarshal(rows, &records); err != nil {
+		return fmt.Errorf("error decoding %s state: %v", table, err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	columns, err := tableColumns(tx, table)
+	if err != nil {
+		return err
+	}
+
+	var restored []string
+	for column := range records[0] {
+		if columns[column] {
+			restored = append(restored, pq.QuoteIdentifier(column))
+		}
+	}
+	sort.Strings(restored)
+	list := strings.Join(restored, ", ")
+
+	_, err = tx.Exec(`
+        INSERT INTO `+table+` (`+list+`)
+        SELECT `+list+` FROM json_populate_recordset(NULL::`+table+`, $1)
+    `, string(rows))
+	if err != nil {
+		return fmt.Errorf("error restoring %s: %v", table, err)
+	}
+
+	return nil
+}
+
+// tableColumns returns the names of the table's current columns
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query(`
+        SELECT column_name
+        FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = $1
+    `, table)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s columns: %v", table, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("error scanning %s column: %v", table, err)
+		}
+		columns[column] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over %s columns: %v", table, err)
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,245 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+	"pf2.encounterbrew.com/internal/utils"
+)
+
+// SortCombatants sorts the combatants by initiative and points Turn at the
+// combatant whose turn it is, wherever they ended up in the order
+func (e *Encounter) SortCombatants() {
+	SortCombatantsByInitiative(e.Combatants)
+
+	e.Turn = 0
+	for i, c := range e.Combatants {
+		if e.isTurnOf(c) {
+			e.Turn = i
+			return
+		}
+	}
+}
+
+// isTurnOf reports whether it is the combatant's turn
+func (e *Encounter) isTurnOf(c Combatant) bool {
+	return c.GetAssociationID() == e.TurnAssociationID && c.IsMonster() == e.TurnIsMonster
+}
+
+// setTurnOf makes it the combatant's turn without persisting it
+func (e *Encounter) setTurnOf(c Combatant) {
+	e.TurnAssociationID, e.TurnIsMonster = c.GetAssociationID(), c.IsMonster()
+}
+
+// tiedWith returns the combatants sharing the combatant's initiative, in
+// their current order
+func tiedWith(combatants []Combatant, c Combatant) []Combatant {
+	var tied []Combatant
+	for _, combatant := range combatants {
+		if combatant.GetInitiative() == c.GetInitiative() {
+			tied = append(tied, combatant)
+		}
+	}
+
+	return tied
+}
+
+// storeTieOrder saves the order of tied combatants so they keep it the next
+// time the encounter is loaded
+func storeTieOrder(db database.Service, tied []Combatant) error {
+	for i, c := range tied {
+		if c.GetInitiativeOrder() == i+1 {
+			continue
+		}
+		if err := c.SetInitiativeOrder(db, i+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MoveInTie swaps the combatant at the index with the one before or after
+// it and returns who it swapped with. The GM can only reorder combatants that
+// are tied on initiative. Minions move along with their owner.
+func MoveInTie(db database.Service, e *Encounter, index int, up bool) (Combatant, error) {
+	if index < 0 || index >= len(e.Combatants) {
+		return nil, errors.New("invalid combatant index")
+	}
+
+	c := e.Combatants[index]
+	if owner, ok := OwnerOf(e.Combatants, c); ok {
+		return nil, fmt.Errorf("%s acts on %s's turn", c.GetName(), owner.GetName())
+	}
+
+	// Only combatants taking their own turns are ordered among each other
+	var order []Combatant
+	position := 0
+	for _, combatant := range e.Combatants {
+		if HasOwner(e.Combatants, combatant) {
+			continue
+		}
+		if combatant == c {
+			position = len(order)
+		}
+		order = append(order, combatant)
+	}
+
+	other := position + 1
+	if up {
+		other = position - 1
+	}
+
+	if other < 0 || other >= len(order) || order[other].GetInitiative() != c.GetInitiative() {
+		return nil, fmt.Errorf("%s is not tied on initiative with anyone in that direction", c.GetName())
+	}
+
+	e.setTurnOf(e.Combatants[e.Turn])
+	order[position], order[other] = order[other], order[position]
+
+	if err := storeTieOrder(db, tiedWith(order, c)); err != nil {
+		return nil, fmt.Errorf("error reordering tied combatants: %v", err)
+	}
+
+	e.SortCombatants()
+
+	return order[position], nil
+}
+
+// PassTurnFrom hands the turn to the next combatant if it is currently the
+// given combatant's turn, so removing them doesn't reset the turn to the top
+// of the initiative order. Minions are skipped as on any other turn change
+// and the next combatant's turn starts; it returns what that changed.
+func PassTurnFrom(db database.Service, e *Encounter, c Combatant) ([]string, error) {
+	if len(e.Combatants) < 2 || e.Combatants[e.Turn] != c {
+		return nil, nil
+	}
+
+	if err := advanceTurn(db, e); err != nil {
+		return nil, err
+	}
+
+	return startTurn(db, e)
+}
+
+const InitiativePerception = "perception"
+
+// InitiativeRoll is a combatant's initiative check: the statistic they
+// rolled with, the d20 result and the total
+type InitiativeRoll struct {
+	Statistic string `json:"statistic"`
+	Die       int    `json:"die"`
+	Total     int    `json:"total"`
+}
+
+// String describes the roll, e.g. "Stealth: 12 + 9 = 21"
+func (r InitiativeRoll) String() string {
+	if r.Statistic == "" {
+		return fmt.Sprintf("Initiative %d", r.Total)
+	}
+
+	return fmt.Sprintf("%s: %d %s %d = %d", utils.CapitalizeFirst(r.Statistic), r.Die, sign(r.Total-r.Die), abs(r.Total-r.Die), r.Total)
+}
+
+// InitiativeStatistics lists the statistics initiative can be rolled with:
+// Perception and every skill
+func InitiativeStatistics() []string {
+	statistics := []string{InitiativePerception}
+	for skill := range skillAttributes {
+		statistics = append(statistics, skill)
+	}
+	sort.Strings(statistics[1:])
+
+	return statistics
+}
+
+func isInitiativeStatistic(statistic string) bool {
+	_, isSkill := skillAttributes[statistic]
+	return statistic == InitiativePerception || isSkill
+}
+
+// RollInitiative rolls initiative for the combatant with the statistic
+func RollInitiative(c Combatant, statistic string) (InitiativeRoll, error) {
+	statistic = strings.ToLower(strings.TrimSpace(statistic))
+	if !isInitiativeStatistic(statistic) {
+		return InitiativeRoll{}, fmt.Errorf("can't roll initiative with %q", statistic)
+	}
+
+	die := RollDie(20)
+
+	return InitiativeRoll{Statistic: statistic, Die: die, Total: die + c.GetInitiativeModifier(statistic)}, nil
+}
+
+// ValidateCopies checks the number of copies of a creature added at once
+func ValidateCopies(quantity int) error {
+	if quantity < 1 || quantity > maxMonsterCopies {
+		return fmt.Errorf("can add between 1 and %d copies at once", maxMonsterCopies)
+	}
+
+	return nil
+}
+
+// RollCopies rolls initiative for the copies of the combatant with the
+// statistic, once for the whole group or once per copy
+func RollCopies(c Combatant, statistic string, quantity int, grouped bool) ([]InitiativeRoll, error) {
+	if err := ValidateCopies(quantity); err != nil {
+		return nil, err
+	}
+
+	initiatives := make([]InitiativeRoll, quantity)
+	for i := range initiatives {
+		if grouped && i > 0 {
+			initiatives[i] = initiatives[0]
+			continue
+		}
+
+		roll, err := RollInitiative(c, statistic)
+		if err != nil {
+			return nil, err
+		}
+		initiatives[i] = roll
+	}
+
+	return initiatives, nil
+}
+
+// RecordInitiative stores an initiative total the player rolled themselves
+// along with the statistic they used, working out the die result from it
+func RecordInitiative(db database.Service, c Combatant, statistic string, total int) error {
+	statistic = strings.ToLower(strings.TrimSpace(statistic))
+	if !isInitiativeStatistic(statistic) {
+		return fmt.Errorf("can't roll initiative with %q", statistic)
+	}
+
+	return c.SetInitiativeRoll(db, InitiativeRoll{Statistic: statistic, Die: total - c.GetInitiativeModifier(statistic), Total: total})
+}
+
+// untrainedSkillModifier returns the modifier for an untrained skill: the skill's
+// attribute modifier and any condition modifiers
+func untrainedSkillModifier(c Combatant, skill string) int {
+	attributes := map[string]int{
+		"str": c.GetStr(), "dex": c.GetDex(), "con": c.GetCon(),
+		"int": c.GetInt(), "wis": c.GetWis(), "cha": c.GetCha(),
+	}
+
+	return attributes[skillAttributes[skill]] + ConditionModifier(c.GetConditions(), c.GetEffects(), SkillSelectors(skill))
+}
+
+func sign(value int) string {
+	if value < 0 {
+		return "-"
+	}
+
+	return "+"
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
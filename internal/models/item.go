@@ -32,6 +32,9 @@ type Item struct {
 		AttackEffects struct {
 			Value []any `json:"value"`
 		} `json:"attackEffects"`
+		Ability struct {
+			Value string `json:"value"`
+		} `json:"ability"`
 		Area struct {
 			Type  string `json:"type"`
 			Value int    `json:"value"`
@@ -97,6 +100,9 @@ type Item struct {
 		Time struct {
 			Value string `json:"value"`
 		} `json:"time"`
+		Tradition struct {
+			Value string `json:"value"`
+		} `json:"tradition"`
 		Traits struct {
 			Value      []string `json:"value"`
 			Traditions []string `json:"traditions"`
@@ -263,6 +269,20 @@ func (i Item) GetDamageEffect() string {
 	}
 }
 
+// GetSpellcastingAttribute returns the key attribute of a spellcasting
+// entry, falling back to the usual one for its tradition
+func (i Item) GetSpellcastingAttribute() string {
+	if i.System.Ability.Value != "" {
+		return i.System.Ability.Value
+	}
+
+	if attribute, ok := traditionAttributes[i.System.Tradition.Value]; ok {
+		return attribute
+	}
+
+	return "cha"
+}
+
 func (i Item) GetSpellDC(modifier int) int {
 	return i.System.Spelldc.Dc + modifier
 }
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+// An encounter is prepared, then run from the start of combat until it ends
+// and stays frozen until it is reset
+const (
+	EncounterPreparing = "preparing"
+	EncounterRunning   = "running"
+	EncounterFinished  = "finished"
+)
+
+// resetCombatState clears everything a combatant picks up during combat
+const resetCombatState = `
+	temp_hp = 0,
+	initiative = 0,
+	initiative_statistic = '',
+	initiative_die = 0,
+	initiative_order = 0,
+	delay_state = '',
+	actions_remaining = 3,
+	reaction_used = FALSE,
+	attacks_made = 0`
+
+func (e Encounter) IsPreparing() bool {
+	return e.Status == EncounterPreparing || e.Status == ""
+}
+
+func (e Encounter) IsRunning() bool {
+	return e.Status == EncounterRunning
+}
+
+func (e Encounter) IsFinished() bool {
+	return e.Status == EncounterFinished
+}
+
+// GetEncounterStatus returns the encounter's place in its lifecycle
+func GetEncounterStatus(db database.Service, encounterID int) (string, error) {
+	if db == nil {
+		return "", errors.New("database service is nil")
+	}
+
+	var status string
+	err := db.QueryRow(`
+		SELECT status FROM encounters
+		WHERE id = $1
+	`, encounterID).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("error getting encounter status: %v", err)
+	}
+
+	return status, nil
+}
+
+// StartEncounter rolls initiative for the monsters that haven't rolled yet,
+// then starts round 1 with the turn of the first combatant. Players roll
+// their own dice, so those without an initiative are only pointed out. It
+// returns a description of everything that changed.
+func StartEncounter(db database.Service, e *Encounter) ([]string, error) {
+	if !e.IsPreparing() {
+		return nil, errors.New("the encounter has already started")
+	}
+
+	var changes []string
+	for _, c := range e.Combatants {
+		// Minions follow their owner's initiative, and rolled or entered
+		// initiatives are kept
+		if roll := c.GetInitiativeRoll(); HasOwner(e.Combatants, c) || roll.Statistic != "" || roll.Total != 0 {
+			continue
+		}
+
+		if !c.IsMonster() {
+			changes = append(changes, fmt.Sprintf("%s has no initiative yet", c.GetName()))
+			continue
+		}
+
+		roll, err := RollInitiative(c, c.GetInitiativeStatistic())
+		if err != nil {
+			return changes, err
+		}
+		if err := c.SetInitiativeRoll(db, roll); err != nil {
+			return changes, fmt.Errorf("error rolling initiative: %v", err)
+		}
+		changes = append(changes, fmt.Sprintf("%s rolled initiative (%s)", c.GetName(), roll))
+	}
+
+	if err := SyncMinionInitiative(db, e); err != nil {
+		return changes, fmt.Errorf("error updating minion initiative: %v", err)
+	}
+
+	e.Turn, e.Round = 0, 0
+	if err := UpdateTurnAndRound(db, e); err != nil {
+		return changes, fmt.Errorf("error updating turn and round: %v", err)
+	}
+
+	err := db.QueryRow(`
+		UPDATE encounters
+		SET status = $1, started_at = CURRENT_TIMESTAMP, finished_at = NULL
+		WHERE id = $2
+		RETURNING started_at
+	`, EncounterRunning, e.ID).Scan(&e.StartedAt)
+	if err != nil {
+		return changes, fmt.Errorf("error starting encounter: %v", err)
+	}
+	e.Status, e.FinishedAt = EncounterRunning, nil
+
+	if len(e.Combatants) == 0 {
+		return changes, nil
+	}
+
+	started, err := startTurn(db, e)
+	return append(changes, started...), err
+}
+
+// EndEncounter ends the combat and records when it ended. The encounter is
+// frozen from then on.
+func EndEncounter(db database.Service, e *Encounter) error {
+	if !e.IsRunning() {
+		return errors.New("the encounter isn't running")
+	}
+
+	err := db.QueryRow(`
+		UPDATE encounters
+		SET status = $1, finished_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING finished_at
+	`, EncounterFinished, e.ID).Scan(&e.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("error ending encounter: %v", err)
+	}
+	e.Status = EncounterFinished
+
+	return nil
+}
+
+// ResetEncounter prepares the encounter to be run again: every combatant is
+// back at full HP without conditions, persistent damage, effects or
+// initiative, and the turn and round start over. XP already awarded stays in
+// the party's history.
+func ResetEncounter(db database.Service, encounterID int) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	// Monsters keep their HP in the bestiary data, players in their party
+	// and companions and custom combatants in the encounter
+	resets := []struct {
+		table string
+		query string
+	}{
+		{"encounter_monsters", `
+			UPDATE encounter_monsters em
+			SET hp = (m.data->'system'->'attributes'->'hp'->>'value')::int,` + resetCombatState + `
+			FROM monsters m
+			WHERE em.monster_id = m.id AND em.encounter_id = $1
+		`},
+		{"encounter_players", `
+			UPDATE encounter_players ep
+			SET hp = COALESCE((SELECT p.hp FROM players p WHERE p.id = ep.player_id), ep.max_hp),` + resetCombatState + `
+			WHERE ep.encounter_id = $1
+		`},
+		{"encounter_custom_combatants", `
+			UPDATE encounter_custom_combatants
+			SET hp = max_hp,` + resetCombatState + `
+			WHERE encounter_id = $1
+		`},
+		{"combatant_conditions", `DELETE FROM combatant_conditions WHERE encounter_id = $1`},
+		{"persistent_damage", `DELETE FROM persistent_damage WHERE encounter_id = $1`},
+		{"combatant_effects", `DELETE FROM combatant_effects WHERE encounter_id = $1`},
+		{"party_xp_awards", `UPDATE party_xp_awards SET encounter_id = NULL WHERE encounter_id = $1`},
+		{"encounters", `
+			UPDATE encounters
+			SET status = 'preparing', started_at = NULL, finished_at = NULL, round = 0, turn_association_id = 0, turn_is_monster = FALSE
+			WHERE id = $1
+		`},
+	}
+
+	for _, reset := range resets {
+		if _, err := tx.Exec(reset.query, encounterID); err != nil {
+			return fmt.Errorf("error resetting %s: %v", reset.table, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
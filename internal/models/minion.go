@@ -0,0 +1,264 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+const (
+	MinionCompanion = "companion"
+	MinionFamiliar  = "familiar"
+	MinionEidolon   = "eidolon"
+	MinionSummon    = "summon"
+)
+
+// CompanionKinds lists the minions entered with their own statistics, summons
+// come from the bestiary instead
+func CompanionKinds() []string {
+	return []string{MinionCompanion, MinionFamiliar, MinionEidolon}
+}
+
+func isCompanionKind(kind string) bool {
+	for _, k := range CompanionKinds() {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MinionLink ties a companion or summon to the combatant that owns it. The
+// owner is identified by its association id like the encounter's turn.
+type MinionLink struct {
+	Kind               string `json:"minion_kind"`
+	OwnerAssociationID int    `json:"owner_association_id"`
+	OwnerIsMonster     bool   `json:"owner_is_monster"`
+}
+
+// MinionLinkTo creates a link of the given kind to the owner
+func MinionLinkTo(kind string, owner Combatant) MinionLink {
+	return MinionLink{Kind: kind, OwnerAssociationID: owner.GetAssociationID(), OwnerIsMonster: owner.IsMonster()}
+}
+
+// IsMinion reports whether the combatant is a companion or summon
+func IsMinion(c Combatant) bool {
+	return c.GetMinionLink().Kind != ""
+}
+
+// IsMinionOf reports whether the combatant belongs to the owner
+func IsMinionOf(c Combatant, owner Combatant) bool {
+	link := c.GetMinionLink()
+	return link.Kind != "" && link.OwnerAssociationID == owner.GetAssociationID() && link.OwnerIsMonster == owner.IsMonster()
+}
+
+// OwnerOf returns the combatant the minion belongs to, if it is still in the
+// encounter
+func OwnerOf(combatants []Combatant, c Combatant) (Combatant, bool) {
+	if !IsMinion(c) {
+		return nil, false
+	}
+
+	for _, owner := range combatants {
+		if IsMinionOf(c, owner) {
+			return owner, true
+		}
+	}
+
+	return nil, false
+}
+
+// HasOwner reports whether the combatant acts on the turn of an owner in the
+// encounter rather than on its own
+func HasOwner(combatants []Combatant, c Combatant) bool {
+	_, ok := OwnerOf(combatants, c)
+	return ok
+}
+
+// MinionsOf returns the owner's minions, in their current order
+func MinionsOf(combatants []Combatant, owner Combatant) []Combatant {
+	var minions []Combatant
+	for _, c := range combatants {
+		if IsMinionOf(c, owner) {
+			minions = append(minions, c)
+		}
+	}
+
+	return minions
+}
+
+// placeMinions moves each minion right behind its owner, so they share the
+// owner's slot in the initiative order. Minions whose owner left the
+// encounter keep their own place.
+func placeMinions(combatants []Combatant) {
+	ordered := make([]Combatant, 0, len(combatants))
+	for _, c := range combatants {
+		if !HasOwner(combatants, c) {
+			ordered = appendWithMinions(ordered, combatants, c)
+		}
+	}
+
+	// Minions owning each other have no owner to follow, leave them be
+	if len(ordered) == len(combatants) {
+		copy(combatants, ordered)
+	}
+}
+
+// appendWithMinions appends the combatant followed by its minions, and
+// theirs in turn
+func appendWithMinions(ordered []Combatant, combatants []Combatant, c Combatant) []Combatant {
+	ordered = append(ordered, c)
+	for _, minion := range MinionsOf(combatants, c) {
+		if minion != c {
+			ordered = appendWithMinions(ordered, combatants, minion)
+		}
+	}
+
+	return ordered
+}
+
+// getEncounterCompanions loads the encounter's companions, which have no
+// player and keep their own statistics
+func getEncounterCompanions(db database.Service, encounterID int) ([]*Player, error) {
+	rows, err := db.Query(`
+        SELECT ep.id, ep.name, ep.level, ep.max_hp, ep.hp, ep.temp_hp, ep.ac, ep.fort, ep.ref, ep.will, ep.perception, ep.initiative, ep.initiative_statistic, ep.initiative_die, ep.delay_state, ep.initiative_order, ep.actions_remaining, ep.reaction_used, ep.attacks_made, ep.minion_kind, ep.owner_association_id, ep.owner_is_monster
+        FROM encounter_players ep
+        WHERE ep.encounter_id = $1 AND ep.player_id IS NULL
+    `, encounterID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying companions: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var companions []*Player
+	for rows.Next() {
+		companion := Player{EncounterID: encounterID}
+		err := rows.Scan(
+			&companion.AssociationID,
+			&companion.Name,
+			&companion.Level,
+			&companion.MaxHp,
+			&companion.Hp,
+			&companion.TempHp,
+			&companion.Ac,
+			&companion.Fort,
+			&companion.Ref,
+			&companion.Will,
+			&companion.Perception,
+			&companion.Initiative,
+			&companion.InitiativeStatistic,
+			&companion.InitiativeDie,
+			&companion.DelayState,
+			&companion.InitiativeOrder,
+			&companion.ActionsRemaining,
+			&companion.ReactionUsed,
+			&companion.AttacksMade,
+			&companion.Minion.Kind,
+			&companion.Minion.OwnerAssociationID,
+			&companion.Minion.OwnerIsMonster,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning companion row: %v", err)
+		}
+		companions = append(companions, &companion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating companion rows: %v", err)
+	}
+
+	return companions, nil
+}
+
+// AddCompanionToEncounter adds a companion, familiar or eidolon with the
+// statistics entered by the GM. It acts on the owner's turn with the owner's
+// initiative.
+func AddCompanionToEncounter(db database.Service, encounterID int, companion *Player, owner Combatant) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	companion.Name = strings.TrimSpace(companion.Name)
+	if companion.Name == "" {
+		return errors.New("the companion needs a name")
+	}
+	if companion.MaxHp < 1 {
+		return errors.New("the companion needs at least 1 HP")
+	}
+	if !isCompanionKind(companion.Minion.Kind) {
+		return fmt.Errorf("unknown companion kind %q", companion.Minion.Kind)
+	}
+
+	companion.Hp = companion.MaxHp
+	companion.EncounterID = encounterID
+	companion.Minion = MinionLinkTo(companion.Minion.Kind, owner)
+
+	roll := owner.GetInitiativeRoll()
+	companion.Initiative = roll.Total
+	companion.InitiativeStatistic = roll.Statistic
+	companion.InitiativeDie = roll.Die
+
+	err := db.QueryRow(`
+        INSERT INTO encounter_players (encounter_id, name, level, max_hp, hp, ac, fort, ref, will, perception, initiative, initiative_statistic, initiative_die, minion_kind, owner_association_id, owner_is_monster)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+        RETURNING id
+    `, encounterID, companion.Name, companion.Level, companion.MaxHp, companion.Hp, companion.Ac, companion.Fort, companion.Ref, companion.Will, companion.Perception,
+		companion.Initiative, companion.InitiativeStatistic, companion.InitiativeDie,
+		companion.Minion.Kind, companion.Minion.OwnerAssociationID, companion.Minion.OwnerIsMonster).Scan(&companion.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error adding companion to encounter: %v", err)
+	}
+
+	return nil
+}
+
+// AddSummonToEncounter adds a bestiary monster summoned by the owner. It acts
+// on the owner's turn with the owner's initiative.
+func AddSummonToEncounter(db database.Service, encounterID int, monsterID int, levelAdjustment int, owner Combatant) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	_, err := addMonsters(db, encounterID, monsterID, levelAdjustment, []InitiativeRoll{owner.GetInitiativeRoll()}, false, MinionLinkTo(MinionSummon, owner))
+	return err
+}
+
+// SyncMinionInitiative gives every minion its owner's initiative again after
+// the owner's changed, so they stay in the owner's slot
+func SyncMinionInitiative(db database.Service, e *Encounter) error {
+	for _, c := range e.Combatants {
+		owner, ok := OwnerOf(e.Combatants, c)
+		if !ok || c.GetInitiativeRoll() == owner.GetInitiativeRoll() {
+			continue
+		}
+
+		if err := c.SetInitiativeRoll(db, owner.GetInitiativeRoll()); err != nil {
+			return err
+		}
+	}
+
+	e.SortCombatants()
+
+	return nil
+}
+
+// ReleaseMinions unlinks the owner's minions when the owner leaves the
+// encounter without them, so they take their own turns from now on
+func ReleaseMinions(db database.Service, combatants []Combatant, owner Combatant) error {
+	for _, minion := range MinionsOf(combatants, owner) {
+		link := minion.GetMinionLink()
+		if err := minion.SetMinionLink(db, MinionLink{Kind: link.Kind}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
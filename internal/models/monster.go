@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"strconv"
 	"strings"
 
@@ -16,13 +15,24 @@ import (
 )
 
 type Monster struct {
-	ID              int         `json:"id"`
-	AssociationID   int         `json:"association_id"`
-	LevelAdjustment int         `json:"level_adjustment"`
-	Enumeration     int         `json:"enumeration"`
-	Initiative      int         `json:"initiative"`
-	Conditions      []Condition `json:"conditions"`
-	Data            struct {
+	ID                  int                `json:"id"`
+	AssociationID       int                `json:"association_id"`
+	LevelAdjustment     int                `json:"level_adjustment"`
+	Enumeration         int                `json:"enumeration"`
+	Initiative          int                `json:"initiative"`
+	InitiativeOrder     int                `json:"initiative_order"`
+	InitiativeStatistic string             `json:"initiative_statistic"`
+	InitiativeDie       int                `json:"initiative_die"`
+	InitiativeGroup     int                `json:"initiative_group"`
+	Minion              MinionLink         `json:"minion"`
+	Conditions          []Condition        `json:"conditions"`
+	PersistentDamage    []PersistentDamage `json:"persistent_damage"`
+	Effects             []Effect           `json:"effects"`
+	DelayState          string             `json:"delay_state"`
+	ActionsRemaining    int                `json:"actions_remaining"`
+	ReactionUsed        bool               `json:"reaction_used"`
+	AttacksMade         int                `json:"attacks_made"`
+	Data                struct {
 		ID     string `json:"_id"`
 		Img    string `json:"img"`
 		Items  []Item `json:"items"`
@@ -56,7 +66,9 @@ type Monster struct {
 				AllSaves struct {
 					Value string `json:"value"`
 				} `json:"allSaves"`
-				Hp struct {
+				Hardness  int  `json:"hardness"`
+				HasHealth bool `json:"hasHealth"`
+				Hp        struct {
 					Details string `json:"details"`
 					Max     int    `json:"max"`
 					Temp    int    `json:"temp"`
@@ -66,8 +78,10 @@ type Monster struct {
 					Type string `json:"type"`
 				} `json:"immunities"`
 				Resistances []struct {
-					Type  string `json:"type"`
-					Value int    `json:"value"`
+					Type       string   `json:"type"`
+					Value      int      `json:"value"`
+					Exceptions []string `json:"exceptions"`
+					DoubleVs   []string `json:"doubleVs"`
 				} `json:"resistances"`
 				Speed struct {
 					OtherSpeeds []struct {
@@ -76,14 +90,22 @@ type Monster struct {
 					} `json:"otherSpeeds"`
 					Value int `json:"value"`
 				} `json:"speed"`
+				Stealth struct {
+					Details string `json:"details"`
+					Value   int    `json:"value"`
+				} `json:"stealth"`
 				Weaknesses []struct {
-					Type  string `json:"type"`
-					Value int    `json:"value"`
+					Type       string   `json:"type"`
+					Value      int      `json:"value"`
+					Exceptions []string `json:"exceptions"`
 				} `json:"weaknesses"`
 			} `json:"attributes"`
 			Details struct {
-				Blurb     string `json:"blurb"`
-				Languages struct {
+				Blurb       string `json:"blurb"`
+				Description string `json:"description"`
+				Disable     string `json:"disable"`
+				IsComplex   bool   `json:"isComplex"`
+				Languages   struct {
 					Details string   `json:"details"`
 					Value   []string `json:"value"`
 				} `json:"languages"`
@@ -97,6 +119,8 @@ type Monster struct {
 					Remaster bool   `json:"remaster"`
 					Title    string `json:"title"`
 				} `json:"publication"`
+				Reset   string `json:"reset"`
+				Routine string `json:"routine"`
 			} `json:"details"`
 			Initiative struct {
 				Statistic string `json:"statistic"`
@@ -188,15 +212,10 @@ func (m Monster) AdjustMonster() map[string]int {
 	return adjustments
 }
 
+// AdjustConditions returns the modifiers the monster's conditions and effects
+// apply to its AC, saves and perception
 func (m Monster) AdjustConditions() map[string]int {
-	conditions := map[string]int{}
-	conditions["ac"] = 0
-
-	if m.IsOffGuard() {
-		conditions["ac"] = -2
-	}
-
-	return conditions
+	return adjustConditions(m.Conditions, m.Effects)
 }
 
 // Implement the Combatant interface
@@ -220,6 +239,10 @@ func (m Monster) GetName() string {
 }
 
 func (m Monster) GetType() string {
+	if m.IsHazard() {
+		return HazardType
+	}
+
 	return "monster"
 }
 
@@ -245,11 +268,133 @@ func (m *Monster) SetInitiative(db database.Service, i int) error {
 	return nil
 }
 
+func (m Monster) GetInitiativeOrder() int {
+	return m.InitiativeOrder
+}
+
+func (m *Monster) SetInitiativeOrder(db database.Service, order int) error {
+	m.InitiativeOrder = order
+
+	_, err := db.Exec(`
+        UPDATE encounter_monsters
+        SET initiative_order = $1
+        WHERE id = $2
+    `, order, m.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating monster initiative order in database: %v", err)
+	}
+
+	return nil
+}
+
+// GetInitiativeGroup returns the group of monsters that were added together
+// with one initiative roll, 0 when the monster rolled on its own
+func (m Monster) GetInitiativeGroup() int {
+	return m.InitiativeGroup
+}
+
+// GetMinionLink returns the owner of a summoned monster
+func (m Monster) GetMinionLink() MinionLink {
+	return m.Minion
+}
+
+func (m *Monster) SetMinionLink(db database.Service, link MinionLink) error {
+	m.Minion = link
+
+	_, err := db.Exec(`
+        UPDATE encounter_monsters
+        SET minion_kind = $1, owner_association_id = $2, owner_is_monster = $3
+        WHERE id = $4
+    `, link.Kind, link.OwnerAssociationID, link.OwnerIsMonster, m.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating monster owner in database: %v", err)
+	}
+
+	return nil
+}
+
+func (m Monster) GetDelayState() string {
+	return m.DelayState
+}
+
+func (m *Monster) SetDelayState(db database.Service, state string) error {
+	m.DelayState = state
+
+	_, err := db.Exec(`
+        UPDATE encounter_monsters
+        SET delay_state = $1
+        WHERE id = $2
+    `, state, m.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating monster delay state in database: %v", err)
+	}
+
+	return nil
+}
+
+func (m Monster) GetActionsRemaining() int {
+	return m.ActionsRemaining
+}
+
+func (m Monster) IsReactionUsed() bool {
+	return m.ReactionUsed
+}
+
+func (m *Monster) SetActions(db database.Service, actions int, reactionUsed bool) error {
+	m.ActionsRemaining = actions
+	m.ReactionUsed = reactionUsed
+
+	_, err := db.Exec(`
+        UPDATE encounter_monsters
+        SET actions_remaining = $1, reaction_used = $2
+        WHERE id = $3
+    `, actions, reactionUsed, m.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating monster actions in database: %v", err)
+	}
+
+	return nil
+}
+
+func (m Monster) GetAttacksMade() int {
+	return m.AttacksMade
+}
+
+func (m *Monster) SetAttacksMade(db database.Service, attacks int) error {
+	m.AttacksMade = attacks
+
+	_, err := db.Exec(`
+        UPDATE encounter_monsters
+        SET attacks_made = $1
+        WHERE id = $2
+    `, attacks, m.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating monster attacks in database: %v", err)
+	}
+
+	return nil
+}
+
 func (m Monster) GetHp() int {
 	return m.Data.System.Attributes.Hp.Value + m.AdjustMonster()["hp"]
 }
 
 func (m *Monster) SetHp(db database.Service, i int) error {
+	// Temporary hit points are lost first
+	if i > 0 && m.GetTempHp() > 0 {
+		absorbed := min(m.GetTempHp(), i)
+		i -= absorbed
+
+		if err := m.SetTempHp(db, m.GetTempHp()-absorbed); err != nil {
+			return err
+		}
+	}
+
 	m.Data.System.Attributes.Hp.Value -= i
 
 	// Update the hp in the encounter_monsters table
@@ -266,6 +411,40 @@ func (m *Monster) SetHp(db database.Service, i int) error {
 	return nil
 }
 
+// SetHpCritical applies damage from a critical hit, monsters don't track dying
+func (m *Monster) SetHpCritical(db database.Service, i int) error {
+	return m.SetHp(db, i)
+}
+
+func (m Monster) GetTempHp() int {
+	return m.Data.System.Attributes.Hp.Temp
+}
+
+func (m *Monster) GainTempHp(db database.Service, i int) error {
+	// Temporary hit points don't stack, the higher value is kept
+	if i <= m.GetTempHp() {
+		return nil
+	}
+
+	return m.SetTempHp(db, i)
+}
+
+func (m *Monster) SetTempHp(db database.Service, i int) error {
+	m.Data.System.Attributes.Hp.Temp = max(0, i)
+
+	_, err := db.Exec(`
+        UPDATE encounter_monsters
+        SET temp_hp = $1
+        WHERE id = $2
+    `, m.Data.System.Attributes.Hp.Temp, m.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating monster temp hp in database: %v", err)
+	}
+
+	return nil
+}
+
 func (m Monster) GetMaxHp() int {
 	return m.Data.System.Attributes.Hp.Max + m.AdjustMonster()["hp"]
 }
@@ -299,7 +478,7 @@ func (m Monster) GetTraits() []string {
 }
 
 func (m Monster) GetPerceptionMod() int {
-	return m.Data.System.Perception.Mod + m.AdjustMonster()["mod"]
+	return m.Data.System.Perception.Mod + m.AdjustMonster()["mod"] + m.AdjustConditions()["perception"]
 }
 
 func (m Monster) GetPerceptionSenses() string {
@@ -334,7 +513,8 @@ func (m Monster) GetSkills() string {
 	var skills string
 
 	for key, value := range m.Data.System.Skills {
-		skills += fmt.Sprintf("%s +%d, ", utils.CapitalizeFirst(key), value.Base+m.AdjustMonster()["mod"])
+		modifier := value.Base + m.AdjustMonster()["mod"] + ConditionModifier(m.Conditions, m.Effects, SkillSelectors(key))
+		skills += fmt.Sprintf("%s %s, ", utils.CapitalizeFirst(key), utils.PositiveOrNegative(modifier))
 	}
 
 	return utils.RemoveTrailingComma(skills)
@@ -345,7 +525,8 @@ func (m Monster) GetLores() string {
 
 	for _, i := range m.Data.Items {
 		if i.Type == "lore" {
-			lores += fmt.Sprintf(", %s +%d", utils.CapitalizeFirst(i.Name), i.System.Mod.Value)
+			modifier := i.System.Mod.Value + ConditionModifier(m.Conditions, m.Effects, SkillSelectors("lore"))
+			lores += fmt.Sprintf(", %s %s", utils.CapitalizeFirst(i.Name), utils.PositiveOrNegative(modifier))
 		}
 	}
 
@@ -377,15 +558,15 @@ func (m Monster) GetCha() int {
 }
 
 func (m Monster) GetFort() int {
-	return m.Data.System.Saves.Fortitude.Value + m.AdjustMonster()["mod"]
+	return m.Data.System.Saves.Fortitude.Value + m.AdjustMonster()["mod"] + m.AdjustConditions()["fort"]
 }
 
 func (m Monster) GetRef() int {
-	return m.Data.System.Saves.Reflex.Value + m.AdjustMonster()["mod"]
+	return m.Data.System.Saves.Reflex.Value + m.AdjustMonster()["mod"] + m.AdjustConditions()["ref"]
 }
 
 func (m Monster) GetWill() int {
-	return m.Data.System.Saves.Will.Value + m.AdjustMonster()["mod"]
+	return m.Data.System.Saves.Will.Value + m.AdjustMonster()["mod"] + m.AdjustConditions()["will"]
 }
 
 func (m Monster) GetImmunities() string {
@@ -424,6 +605,33 @@ func (m Monster) GetWeaknesses() string {
 	return utils.RemoveTrailingComma(weaknesses)
 }
 
+func (m Monster) GetDamageDefenses() DamageDefenses {
+	defenses := DamageDefenses{Hardness: m.GetHardness()}
+
+	for _, immunity := range m.Data.System.Attributes.Immunities {
+		defenses.Immunities = append(defenses.Immunities, immunity.Type)
+	}
+
+	for _, resistance := range m.Data.System.Attributes.Resistances {
+		defenses.Resistances = append(defenses.Resistances, DamageModifier{
+			Type:       resistance.Type,
+			Value:      resistance.Value,
+			Exceptions: resistance.Exceptions,
+			DoubleVs:   resistance.DoubleVs,
+		})
+	}
+
+	for _, weakness := range m.Data.System.Attributes.Weaknesses {
+		defenses.Weaknesses = append(defenses.Weaknesses, DamageModifier{
+			Type:       weakness.Type,
+			Value:      weakness.Value,
+			Exceptions: weakness.Exceptions,
+		})
+	}
+
+	return defenses
+}
+
 func (m Monster) GetSpeed() string {
 	return fmt.Sprintf("%d feet", m.Data.System.Attributes.Speed.Value)
 }
@@ -554,6 +762,22 @@ func (m *Monster) SetConditions(conditions []Condition) {
 	m.Conditions = conditions
 }
 
+func (m Monster) GetPersistentDamage() []PersistentDamage {
+	return m.PersistentDamage
+}
+
+func (m *Monster) SetPersistentDamage(persistentDamage []PersistentDamage) {
+	m.PersistentDamage = persistentDamage
+}
+
+func (m Monster) GetEffects() []Effect {
+	return m.Effects
+}
+
+func (m *Monster) SetEffects(effects []Effect) {
+	m.Effects = effects
+}
+
 func (m *Monster) SetCondition(db database.Service, encounterID int, conditionID int, conditionValue int) error {
 	// Initialize the Conditions slice if it's nil
 	if m.Conditions == nil {
@@ -606,7 +830,7 @@ func (m *Monster) SetCondition(db database.Service, encounterID int, conditionID
 		return fmt.Errorf("error inserting condition into combatant_conditions: %v", err)
 	}
 
-	return nil
+	return linkConditions(db, encounterID, m, condition)
 }
 
 func (m *Monster) RemoveCondition(db database.Service, encounterID int, conditionID int) error {
@@ -629,7 +853,7 @@ func (m *Monster) RemoveCondition(db database.Service, encounterID int, conditio
 		return fmt.Errorf("error removing condition from combatant_conditions: %v", err)
 	}
 
-	return nil
+	return unlinkConditions(db, encounterID, m, conditionID)
 }
 
 func (m *Monster) HasCondition(conditionID int) bool {
@@ -676,18 +900,68 @@ func (m Monster) IsMonster() bool {
 }
 
 func (m Monster) IsOffGuard() bool {
-	for _, c := range m.Conditions {
-		if c.GetName() == "Off-Guard" {
-			return true
-		}
+	_, ok := findCondition(&m, "off-guard")
+	return ok
+}
+
+func (m Monster) GenerateInitiative() int {
+	roll, _ := RollInitiative(&m, m.GetInitiativeStatistic())
+	return roll.Total
+}
+
+// GetInitiativeStatistic returns the statistic the monster rolled initiative
+// with, or the one from its data when it hasn't rolled yet
+func (m Monster) GetInitiativeStatistic() string {
+	if m.InitiativeStatistic != "" {
+		return m.InitiativeStatistic
+	}
+	// Complex hazards usually roll initiative with their Stealth
+	if m.IsHazard() {
+		return "stealth"
+	}
+	if isInitiativeStatistic(m.Data.System.Initiative.Statistic) {
+		return m.Data.System.Initiative.Statistic
 	}
 
-	return false
+	return InitiativePerception
 }
 
-func (m Monster) GenerateInitiative() int {
-	//nolint:gosec
-	return rand.Intn(20) + 1 + m.GetPerceptionMod()
+func (m Monster) GetInitiativeModifier(statistic string) int {
+	if statistic == InitiativePerception {
+		return m.GetPerceptionMod()
+	}
+
+	if statistic == "stealth" && m.IsHazard() {
+		return m.GetStealthModifier() + ConditionModifier(m.Conditions, m.Effects, SkillSelectors(statistic))
+	}
+
+	if skill, ok := m.Data.System.Skills[statistic]; ok {
+		return skill.Base + m.AdjustMonster()["mod"] + ConditionModifier(m.Conditions, m.Effects, SkillSelectors(statistic))
+	}
+
+	return untrainedSkillModifier(&m, statistic)
+}
+
+func (m Monster) GetInitiativeRoll() InitiativeRoll {
+	return InitiativeRoll{Statistic: m.InitiativeStatistic, Die: m.InitiativeDie, Total: m.Initiative}
+}
+
+func (m *Monster) SetInitiativeRoll(db database.Service, roll InitiativeRoll) error {
+	m.Initiative = roll.Total
+	m.InitiativeStatistic = roll.Statistic
+	m.InitiativeDie = roll.Die
+
+	_, err := db.Exec(`
+        UPDATE encounter_monsters
+        SET initiative = $1, initiative_statistic = $2, initiative_die = $3
+        WHERE id = $4
+    `, roll.Total, roll.Statistic, roll.Die, m.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating monster initiative in database: %v", err)
+	}
+
+	return nil
 }
 
 func (m *Monster) GetAssociationID() int {
@@ -726,23 +1000,28 @@ func GetAllMonsters(db database.Service) ([]Monster, error) {
 }
 
 type MonsterSearchFilters struct {
+	Kind            string   `json:"kind"`
 	MinLevel        *int     `json:"min_level"`
 	MaxLevel        *int     `json:"max_level"`
 	ExcludedSources []string `json:"excluded_sources"`
 	ExcludedSizes   []string `json:"excluded_sizes"`
+	Traits          []string `json:"traits"`
 }
 
-func SearchMonsters(db database.Service, search string) ([]Monster, error) {
-	return SearchMonstersWithFilters(db, search, MonsterSearchFilters{})
-}
-
-func SearchMonstersWithFilters(db database.Service, search string, filters MonsterSearchFilters) ([]Monster, error) {
-	// Build dynamic query with filters
-	queryArgs := []interface{}{search}
-	argCounter := 1
-
+// monsterFilterConditions builds the WHERE conditions for the search filters,
+// numbering their arguments after the ones already in queryArgs
+func monsterFilterConditions(filters MonsterSearchFilters, queryArgs []interface{}) ([]string, []interface{}) {
+	argCounter := len(queryArgs)
 	whereConditions := []string{}
 
+	// Creatures or hazards only
+	switch filters.Kind {
+	case HazardType:
+		whereConditions = append(whereConditions, "data->>'type' = 'hazard'")
+	case "creature":
+		whereConditions = append(whereConditions, "data->>'type' <> 'hazard'")
+	}
+
 	// Level filter conditions
 	if filters.MinLevel != nil {
 		argCounter++
@@ -769,8 +1048,10 @@ func SearchMonstersWithFilters(db database.Service, search string, filters Monst
 
 	// If "Other" is checked, handle it specially
 	if hasOther {
-		// Remove "Other" from the excluded sources
-		filters.ExcludedSources = append(filters.ExcludedSources[:otherIndex], filters.ExcludedSources[otherIndex+1:]...)
+		// Remove "Other" from the excluded sources, without touching the
+		// caller's slice
+		excluded := append([]string{}, filters.ExcludedSources[:otherIndex]...)
+		filters.ExcludedSources = append(excluded, filters.ExcludedSources[otherIndex+1:]...)
 
 		// Include ONLY the core books (excluding all other sources)
 		coreBooks := []string{
@@ -798,6 +1079,23 @@ func SearchMonstersWithFilters(db database.Service, search string, filters Monst
 		queryArgs = append(queryArgs, pq.Array(filters.ExcludedSizes))
 	}
 
+	// Trait filter, the creature must have every trait
+	if len(filters.Traits) > 0 {
+		argCounter++
+		whereConditions = append(whereConditions, fmt.Sprintf("data->'system'->'traits'->'value' ?& $%d", argCounter))
+		queryArgs = append(queryArgs, pq.Array(filters.Traits))
+	}
+
+	return whereConditions, queryArgs
+}
+
+func SearchMonsters(db database.Service, search string) ([]Monster, error) {
+	return SearchMonstersWithFilters(db, search, MonsterSearchFilters{})
+}
+
+func SearchMonstersWithFilters(db database.Service, search string, filters MonsterSearchFilters) ([]Monster, error) {
+	whereConditions, queryArgs := monsterFilterConditions(filters, []interface{}{search})
+
 	// Build WHERE clause for filters
 	filterClause := ""
 	if len(whereConditions) > 0 {
@@ -11,11 +11,13 @@ import (
 )
 
 type Party struct {
-	ID      int      `json:"id"`
-	Name    string   `json:"name"`
-	UserID  int      `json:"user_id"`
-	User    *User    `json:"user,omitempty"`
-	Players []Player `json:"players,omitempty"`
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	UserID   int       `json:"user_id"`
+	User     *User     `json:"user,omitempty"`
+	Players  []Player  `json:"players,omitempty"`
+	Xp       int       `json:"xp"`
+	XpAwards []XpAward `json:"xp_awards,omitempty"`
 }
 
 func (p *Party) GetLevel() float64 {
@@ -152,7 +154,7 @@ func GetParty(db database.Service, partyID int) (Party, error) {
 
 	// Updated query to include perception
 	rows, err := db.Query(`
-        SELECT id, name, level, hp, ac, fort, ref, will, perception
+        SELECT id, name, level, hp, ac, fort, ref, will, perception, skills
         FROM players
         WHERE party_id = $1
     `, partyID)
@@ -168,6 +170,7 @@ func GetParty(db database.Service, partyID int) (Party, error) {
 	var players []Player
 	for rows.Next() {
 		var player Player
+		var skills []byte
 		err := rows.Scan(
 			&player.ID,
 			&player.Name,
@@ -178,10 +181,14 @@ func GetParty(db database.Service, partyID int) (Party, error) {
 			&player.Ref,
 			&player.Will,
 			&player.Perception, // Added perception
+			&skills,
 		)
 		if err != nil {
 			return Party{}, fmt.Errorf("error scanning player row: %v", err)
 		}
+		if player.Skills, err = unmarshalSkills(skills); err != nil {
+			return Party{}, err
+		}
 		player.PartyID = partyID
 		players = append(players, player)
 	}
@@ -278,14 +285,19 @@ func (p *Party) UpdateWithPlayers(db database.Service, playersToDelete []int) er
 
 	// Update or insert players
 	for _, player := range p.Players {
+		skills, err := marshalSkills(player.Skills)
+		if err != nil {
+			return err
+		}
+
 		if player.ID == 0 {
 			// Insert new player
 			err := tx.QueryRow(`
-                INSERT INTO players (name, level, ac, hp, fort, ref, will, perception, party_id)
-                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+                INSERT INTO players (name, level, ac, hp, fort, ref, will, perception, skills, party_id)
+                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
                 RETURNING id`,
 				player.Name, player.Level, player.Ac, player.Hp,
-				player.Fort, player.Ref, player.Will, player.Perception,
+				player.Fort, player.Ref, player.Will, player.Perception, skills,
 				p.ID).Scan(&player.ID)
 			if err != nil {
 				return fmt.Errorf("error inserting player: %v", err)
@@ -295,10 +307,10 @@ func (p *Party) UpdateWithPlayers(db database.Service, playersToDelete []int) er
 			result, err := tx.Exec(`
                 UPDATE players
                 SET name = $1, level = $2, ac = $3, hp = $4,
-                    fort = $5, ref = $6, will = $7, perception = $8
-                WHERE id = $9 AND party_id = $10`,
+                    fort = $5, ref = $6, will = $7, perception = $8, skills = $9
+                WHERE id = $10 AND party_id = $11`,
 				player.Name, player.Level, player.Ac, player.Hp,
-				player.Fort, player.Ref, player.Will, player.Perception,
+				player.Fort, player.Ref, player.Will, player.Perception, skills,
 				player.ID, p.ID)
 
 			if err != nil {
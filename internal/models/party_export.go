@@ -23,14 +23,15 @@ type PartyExportData struct {
 
 // PlayerExportData represents a player in the export format
 type PlayerExportData struct {
-	Name       string `json:"name"`
-	Level      int    `json:"level"`
-	Hp         int    `json:"hp"`
-	Ac         int    `json:"ac"`
-	Fort       int    `json:"for"`
-	Ref        int    `json:"ref"`
-	Will       int    `json:"wil"`
-	Perception int    `json:"perception"`
+	Name       string         `json:"name"`
+	Level      int            `json:"level"`
+	Hp         int            `json:"hp"`
+	Ac         int            `json:"ac"`
+	Fort       int            `json:"for"`
+	Ref        int            `json:"ref"`
+	Will       int            `json:"wil"`
+	Perception int            `json:"perception"`
+	Skills     map[string]int `json:"skills,omitempty"`
 }
 
 // ExportAllParties exports all parties for a user in the seeder format
@@ -69,7 +70,7 @@ func ExportAllParties(db database.Service, userID int) (*PartiesExportData, erro
 
 		// Get players for this party
 		playerRows, err := db.Query(`
-			SELECT name, level, hp, ac, fort, ref, will, perception
+			SELECT name, level, hp, ac, fort, ref, will, perception, skills
 			FROM players
 			WHERE party_id = $1
 			ORDER BY id
@@ -90,13 +91,17 @@ func ExportAllParties(db database.Service, userID int) (*PartiesExportData, erro
 
 		for playerRows.Next() {
 			var player PlayerExportData
+			var skills []byte
 			err := playerRows.Scan(
 				&player.Name, &player.Level, &player.Hp, &player.Ac,
-				&player.Fort, &player.Ref, &player.Will, &player.Perception,
+				&player.Fort, &player.Ref, &player.Will, &player.Perception, &skills,
 			)
 			if err != nil {
 				return nil, fmt.Errorf("error scanning player row: %v", err)
 			}
+			if player.Skills, err = unmarshalSkills(skills); err != nil {
+				return nil, err
+			}
 			partyExport.Players = append(partyExport.Players, player)
 		}
 
@@ -188,13 +193,18 @@ func ImportParties(db database.Service, userID int, importData *PartiesExportDat
 				continue // Skip players with empty names
 			}
 
-			_, err := tx.Exec(`
+			skills, err := marshalSkills(playerData.Skills)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`
 				INSERT INTO players (
-					name, level, hp, ac, fort, ref, will, perception, party_id
-				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+					name, level, hp, ac, fort, ref, will, perception, skills, party_id
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			`, trimmedPlayerName, playerData.Level, playerData.Hp, playerData.Ac,
 				playerData.Fort, playerData.Ref, playerData.Will, playerData.Perception,
-				partyID)
+				skills, partyID)
 			if err != nil {
 				return fmt.Errorf("error inserting player '%s': %v", trimmedPlayerName, err)
 			}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+const (
+	persistentDamageDC         = 15
+	assistedPersistentDamageDC = 10
+)
+
+// PersistentDamage is an ongoing source of damage such as "2d6 persistent
+// fire" that's dealt at the end of the combatant's turn until a flat check
+// ends it
+type PersistentDamage struct {
+	ID         int    `json:"id"`
+	Formula    string `json:"formula"`
+	DamageType string `json:"damage_type"`
+	Assisted   bool   `json:"assisted"`
+}
+
+func (p PersistentDamage) String() string {
+	return fmt.Sprintf("%s persistent %s", p.Formula, p.DamageType)
+}
+
+// FlatCheckDC returns the DC of the flat check to end the persistent damage,
+// which is lower when someone helps the combatant recover
+func (p PersistentDamage) FlatCheckDC() int {
+	if p.Assisted {
+		return assistedPersistentDamageDC
+	}
+
+	return persistentDamageDC
+}
+
+// associationColumn returns the column referencing the combatant's row in
+// encounter_monsters, encounter_custom_combatants or encounter_players
+func associationColumn(c Combatant) string {
+	if _, ok := c.(*CustomCombatant); ok {
+		return "encounter_custom_combatant_id"
+	}
+	if c.IsMonster() {
+		return "encounter_monster_id"
+	}
+
+	return "encounter_player_id"
+}
+
+// associationKey returns the column expression holding the association ID of
+// a monster or a player. Custom combatants share the ids of monsters, so
+// either of their columns matches.
+func associationKey(isMonster bool) string {
+	if isMonster {
+		return "COALESCE(encounter_monster_id, encounter_custom_combatant_id)"
+	}
+
+	return "encounter_player_id"
+}
+
+func AddPersistentDamage(db database.Service, encounterID int, c Combatant, formula string, damageType string) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	formula = strings.ReplaceAll(strings.ToLower(formula), " ", "")
+	damageType = strings.ToLower(strings.TrimSpace(damageType))
+
+	if err := ValidateFormula(formula); err != nil {
+		return err
+	}
+	if damageType == "" {
+		return errors.New("persistent damage needs a damage type")
+	}
+
+	p := PersistentDamage{Formula: formula, DamageType: damageType}
+
+	err := db.QueryRow(`
+        INSERT INTO persistent_damage (encounter_id, `+associationColumn(c)+`, formula, damage_type)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `, encounterID, c.GetAssociationID(), formula, damageType).Scan(&p.ID)
+
+	if err != nil {
+		return fmt.Errorf("error inserting persistent damage: %v", err)
+	}
+
+	c.SetPersistentDamage(append(c.GetPersistentDamage(), p))
+
+	return nil
+}
+
+func RemovePersistentDamage(db database.Service, encounterID int, c Combatant, persistentDamageID int) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	_, err := db.Exec(`
+        DELETE FROM persistent_damage
+        WHERE id = $1 AND encounter_id = $2 AND `+associationColumn(c)+` = $3
+    `, persistentDamageID, encounterID, c.GetAssociationID())
+
+	if err != nil {
+		return fmt.Errorf("error removing persistent damage: %v", err)
+	}
+
+	var remaining []PersistentDamage
+	for _, p := range c.GetPersistentDamage() {
+		if p.ID != persistentDamageID {
+			remaining = append(remaining, p)
+		}
+	}
+	c.SetPersistentDamage(remaining)
+
+	return nil
+}
+
+// SetPersistentDamageAssisted marks whether the next flat check against the
+// persistent damage is made with assisted recovery
+func SetPersistentDamageAssisted(db database.Service, encounterID int, c Combatant, persistentDamageID int, assisted bool) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	_, err := db.Exec(`
+        UPDATE persistent_damage
+        SET assisted = $1
+        WHERE id = $2 AND encounter_id = $3 AND `+associationColumn(c)+` = $4
+    `, assisted, persistentDamageID, encounterID, c.GetAssociationID())
+
+	if err != nil {
+		return fmt.Errorf("error updating persistent damage: %v", err)
+	}
+
+	persistentDamage := c.GetPersistentDamage()
+	for i := range persistentDamage {
+		if persistentDamage[i].ID == persistentDamageID {
+			persistentDamage[i].Assisted = assisted
+		}
+	}
+
+	return nil
+}
+
+func GetCombatantPersistentDamage(db database.Service, encounterID int, associationID int, isMonster bool) ([]PersistentDamage, error) {
+	rows, err := db.Query(`
+        SELECT id, formula, damage_type, assisted
+        FROM persistent_damage
+        WHERE encounter_id = $1 AND `+associationKey(isMonster)+` = $2
+        ORDER BY id
+    `, encounterID, associationID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying persistent damage: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var persistentDamage []PersistentDamage
+	for rows.Next() {
+		var p PersistentDamage
+		if err := rows.Scan(&p.ID, &p.Formula, &p.DamageType, &p.Assisted); err != nil {
+			return nil, fmt.Errorf("error scanning persistent damage row: %v", err)
+		}
+		persistentDamage = append(persistentDamage, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating persistent damage rows: %v", err)
+	}
+
+	return persistentDamage, nil
+}
+
+// processPersistentDamage deals each persistent damage at the end of the
+// combatant's turn and then attempts the flat check to end it
+func processPersistentDamage(db database.Service, encounterID int, c Combatant) ([]string, error) {
+	var changes []string
+
+	// Work on a copy, ending persistent damage modifies the combatant's slice
+	persistentDamage := append([]PersistentDamage{}, c.GetPersistentDamage()...)
+
+	for _, p := range persistentDamage {
+		amount, err := RollFormula(p.Formula)
+		if err != nil {
+			return changes, err
+		}
+
+		instance := DamageInstance{Amount: amount, Type: p.DamageType, Tags: []string{p.DamageType, "persistent-damage"}}
+		result := ApplyDamageDefenses([]DamageInstance{instance}, c.GetDamageDefenses())
+
+		if err := c.SetHp(db, result.Total); err != nil {
+			return changes, fmt.Errorf("error applying persistent damage: %v", err)
+		}
+		changes = append(changes, fmt.Sprintf("%s (rolled %d for %s)", result.Summary(c.GetName()), amount, p))
+
+		roll := RollDie(20)
+		if roll >= p.FlatCheckDC() {
+			if err := RemovePersistentDamage(db, encounterID, c, p.ID); err != nil {
+				return changes, err
+			}
+			changes = append(changes, fmt.Sprintf("%s ends %s (flat check %d vs DC %d)", c.GetName(), p, roll, p.FlatCheckDC()))
+			continue
+		}
+
+		// Assisted recovery only helps with a single flat check
+		if p.Assisted {
+			if err := SetPersistentDamageAssisted(db, encounterID, c, p.ID, false); err != nil {
+				return changes, err
+			}
+		}
+		changes = append(changes, fmt.Sprintf("%s still has %s (flat check %d vs DC %d)", c.GetName(), p, roll, p.FlatCheckDC()))
+	}
+
+	return changes, nil
+}
@@ -3,27 +3,40 @@ package models
 import (
 	"errors"
 	"fmt"
-	"math/rand"
 
 	"pf2.encounterbrew.com/internal/database"
 )
 
 type Player struct {
-	ID            int         `json:"id"`
-	AssociationID int         `json:"association_id"`
-	Name          string      `json:"name"`
-	Level         int         `json:"level"`
-	Hp            int         `json:"hp"`
-	Ac            int         `json:"ac"`
-	Fort          int         `json:"for"`
-	Ref           int         `json:"ref"`
-	Will          int         `json:"wil"`
-	Perception    int         `json:"perception"`
-	PartyID       int         `json:"party_id"`
-	Party         *Party      `json:"party,omitempty"`
-	Initiative    int         `json:"initiative"`
-	Conditions    []Condition `json:"conditions"`
-	Enumeration   int         `json:"enumeration"`
+	ID                  int                `json:"id"`
+	AssociationID       int                `json:"association_id"`
+	EncounterID         int                `json:"encounter_id"`
+	Name                string             `json:"name"`
+	Level               int                `json:"level"`
+	Hp                  int                `json:"hp"`
+	MaxHp               int                `json:"max_hp"`
+	TempHp              int                `json:"temp_hp"`
+	Ac                  int                `json:"ac"`
+	Fort                int                `json:"for"`
+	Ref                 int                `json:"ref"`
+	Will                int                `json:"wil"`
+	Perception          int                `json:"perception"`
+	Skills              map[string]int     `json:"skills"`
+	PartyID             int                `json:"party_id"`
+	Party               *Party             `json:"party,omitempty"`
+	Initiative          int                `json:"initiative"`
+	InitiativeOrder     int                `json:"initiative_order"`
+	InitiativeStatistic string             `json:"initiative_statistic"`
+	InitiativeDie       int                `json:"initiative_die"`
+	Conditions          []Condition        `json:"conditions"`
+	PersistentDamage    []PersistentDamage `json:"persistent_damage"`
+	Effects             []Effect           `json:"effects"`
+	DelayState          string             `json:"delay_state"`
+	ActionsRemaining    int                `json:"actions_remaining"`
+	ReactionUsed        bool               `json:"reaction_used"`
+	AttacksMade         int                `json:"attacks_made"`
+	Enumeration         int                `json:"enumeration"`
+	Minion              MinionLink         `json:"minion"`
 }
 
 // Implement the Combatant interface
@@ -58,29 +71,210 @@ func (p *Player) SetInitiative(db database.Service, i int) error {
 	return nil
 }
 
+func (p Player) GetInitiativeOrder() int {
+	return p.InitiativeOrder
+}
+
+func (p *Player) SetInitiativeOrder(db database.Service, order int) error {
+	p.InitiativeOrder = order
+
+	_, err := db.Exec(`
+        UPDATE encounter_players
+        SET initiative_order = $1
+        WHERE id = $2
+    `, order, p.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating player initiative order in database: %v", err)
+	}
+
+	return nil
+}
+
+// GetInitiativeGroup returns 0, players always roll their own initiative
+func (p Player) GetInitiativeGroup() int {
+	return 0
+}
+
+// GetMinionLink returns the owner of a companion, players aren't minions
+func (p Player) GetMinionLink() MinionLink {
+	return p.Minion
+}
+
+func (p *Player) SetMinionLink(db database.Service, link MinionLink) error {
+	p.Minion = link
+
+	_, err := db.Exec(`
+        UPDATE encounter_players
+        SET minion_kind = $1, owner_association_id = $2, owner_is_monster = $3
+        WHERE id = $4
+    `, link.Kind, link.OwnerAssociationID, link.OwnerIsMonster, p.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating player owner in database: %v", err)
+	}
+
+	return nil
+}
+
+func (p Player) GetDelayState() string {
+	return p.DelayState
+}
+
+func (p *Player) SetDelayState(db database.Service, state string) error {
+	p.DelayState = state
+
+	_, err := db.Exec(`
+        UPDATE encounter_players
+        SET delay_state = $1
+        WHERE id = $2
+    `, state, p.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating player delay state in database: %v", err)
+	}
+
+	return nil
+}
+
+func (p Player) GetActionsRemaining() int {
+	return p.ActionsRemaining
+}
+
+func (p Player) IsReactionUsed() bool {
+	return p.ReactionUsed
+}
+
+func (p *Player) SetActions(db database.Service, actions int, reactionUsed bool) error {
+	p.ActionsRemaining = actions
+	p.ReactionUsed = reactionUsed
+
+	_, err := db.Exec(`
+        UPDATE encounter_players
+        SET actions_remaining = $1, reaction_used = $2
+        WHERE id = $3
+    `, actions, reactionUsed, p.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating player actions in database: %v", err)
+	}
+
+	return nil
+}
+
+func (p Player) GetAttacksMade() int {
+	return p.AttacksMade
+}
+
+func (p *Player) SetAttacksMade(db database.Service, attacks int) error {
+	p.AttacksMade = attacks
+
+	_, err := db.Exec(`
+        UPDATE encounter_players
+        SET attacks_made = $1
+        WHERE id = $2
+    `, attacks, p.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating player attacks in database: %v", err)
+	}
+
+	return nil
+}
+
 func (p Player) GetHp() int {
 	return p.Hp
 }
 
 func (p *Player) SetHp(db database.Service, i int) error {
-	p.Hp -= i
+	return p.changeHp(db, i, false)
+}
+
+// SetHpCritical applies damage from a critical hit, which pushes a player
+// further towards death when it drops them to 0 HP
+func (p *Player) SetHpCritical(db database.Service, i int) error {
+	return p.changeHp(db, i, true)
+}
+
+func (p *Player) changeHp(db database.Service, i int, critical bool) error {
+	// Temporary hit points are lost first
+	if i > 0 && p.TempHp > 0 {
+		absorbed := min(p.TempHp, i)
+		i -= absorbed
+
+		if err := p.SetTempHp(db, p.TempHp-absorbed); err != nil {
+			return err
+		}
+	}
+
+	// Healing can't raise a player above their maximum HP
+	before := p.Hp
+	wasDown := before <= 0
+	p.Hp = max(0, p.Hp-i)
+	if i < 0 && p.MaxHp > 0 {
+		p.Hp = min(p.Hp, max(p.MaxHp, before))
+	}
 
 	// Update the hp in the encounter_players table
 	_, err := db.Exec(`
         UPDATE encounter_players
         SET hp = $1
-        WHERE player_id = $2 AND encounter_id = $3
-    `, p.Hp, p.ID, p.AssociationID)
+        WHERE id = $2
+    `, p.Hp, p.AssociationID)
 
 	if err != nil {
 		return fmt.Errorf("error updating player hp in database: %v", err)
 	}
 
+	// Players aren't killed outright at 0 HP, they start dying instead
+	switch {
+	case i > 0 && p.Hp == 0:
+		return applyDamageWhileDown(db, p.EncounterID, p, critical)
+	case i < 0 && wasDown && p.Hp > 0:
+		_, err := recoverFromDying(db, p.EncounterID, p)
+		return err
+	}
+
 	return nil
 }
 
+func (p Player) GetTempHp() int {
+	return p.TempHp
+}
+
+func (p *Player) GainTempHp(db database.Service, i int) error {
+	// Temporary hit points don't stack, the higher value is kept
+	if i <= p.TempHp {
+		return nil
+	}
+
+	return p.SetTempHp(db, i)
+}
+
+func (p *Player) SetTempHp(db database.Service, i int) error {
+	p.TempHp = max(0, i)
+
+	_, err := db.Exec(`
+        UPDATE encounter_players
+        SET temp_hp = $1
+        WHERE id = $2
+    `, p.TempHp, p.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating player temp hp in database: %v", err)
+	}
+
+	return nil
+}
+
+// GetMaxHp returns the player's maximum HP. Outside of an encounter only the
+// maximum is known, so it is the player's HP.
 func (p Player) GetMaxHp() int {
-	return p.Hp
+	if p.MaxHp == 0 {
+		return p.Hp
+	}
+
+	return p.MaxHp
 }
 
 func (p Player) GetAc() int {
@@ -104,7 +298,7 @@ func (p Player) GetTraits() []string {
 }
 
 func (p Player) GetPerceptionMod() int {
-	return p.Perception
+	return p.Perception + p.AdjustConditions()["perception"]
 }
 
 func (p Player) GetPerceptionSenses() string {
@@ -116,7 +310,7 @@ func (p Player) GetLanguages() string {
 }
 
 func (p Player) GetSkills() string {
-	return ""
+	return FormatSkills(p.Skills)
 }
 
 func (p Player) GetLores() string {
@@ -148,15 +342,15 @@ func (p Player) GetCha() int {
 }
 
 func (p Player) GetFort() int {
-	return p.Fort
+	return p.Fort + p.AdjustConditions()["fort"]
 }
 
 func (p Player) GetRef() int {
-	return p.Ref
+	return p.Ref + p.AdjustConditions()["ref"]
 }
 
 func (p Player) GetWill() int {
-	return p.Will
+	return p.Will + p.AdjustConditions()["will"]
 }
 
 func (p Player) GetImmunities() string {
@@ -171,6 +365,10 @@ func (p Player) GetWeaknesses() string {
 	return ""
 }
 
+func (p Player) GetDamageDefenses() DamageDefenses {
+	return DamageDefenses{}
+}
+
 func (p Player) GetSpeed() string {
 	return ""
 }
@@ -215,6 +413,22 @@ func (p *Player) SetConditions(conditions []Condition) {
 	p.Conditions = conditions
 }
 
+func (p Player) GetPersistentDamage() []PersistentDamage {
+	return p.PersistentDamage
+}
+
+func (p *Player) SetPersistentDamage(persistentDamage []PersistentDamage) {
+	p.PersistentDamage = persistentDamage
+}
+
+func (p Player) GetEffects() []Effect {
+	return p.Effects
+}
+
+func (p *Player) SetEffects(effects []Effect) {
+	p.Effects = effects
+}
+
 func (p *Player) SetCondition(db database.Service, encounterID int, conditionID int, conditionValue int) error {
 	// Initialize the Conditions slice if it's nil
 	if p.Conditions == nil {
@@ -267,7 +481,7 @@ func (p *Player) SetCondition(db database.Service, encounterID int, conditionID
 		return fmt.Errorf("error inserting condition into combatant_conditions: %v", err)
 	}
 
-	return nil
+	return linkConditions(db, encounterID, p, condition)
 }
 
 func (p *Player) RemoveCondition(db database.Service, encounterID int, conditionID int) error {
@@ -290,7 +504,7 @@ func (p *Player) RemoveCondition(db database.Service, encounterID int, condition
 		return fmt.Errorf("error removing condition from combatant_conditions: %v", err)
 	}
 
-	return nil
+	return unlinkConditions(db, encounterID, p, conditionID)
 }
 
 func (p *Player) HasCondition(conditionID int) bool {
@@ -337,29 +551,63 @@ func (p Player) IsMonster() bool {
 }
 
 func (p Player) IsOffGuard() bool {
-	for _, c := range p.Conditions {
-		if c.GetName() == "Off-Guard" {
-			return true
-		}
+	_, ok := findCondition(&p, "off-guard")
+	return ok
+}
+
+// AdjustConditions returns the modifiers the player's conditions and effects
+// apply to their AC, saves and perception
+func (p Player) AdjustConditions() map[string]int {
+	return adjustConditions(p.Conditions, p.Effects)
+}
+
+func (p Player) GenerateInitiative() int {
+	roll, _ := RollInitiative(&p, p.GetInitiativeStatistic())
+	return roll.Total
+}
+
+// GetInitiativeStatistic returns the statistic the player rolled initiative
+// with, Perception unless they said otherwise
+func (p Player) GetInitiativeStatistic() string {
+	if p.InitiativeStatistic != "" {
+		return p.InitiativeStatistic
 	}
 
-	return false
+	return InitiativePerception
 }
 
-func (p Player) AdjustConditions() map[string]int {
-	conditions := map[string]int{}
-	conditions["ac"] = 0
+func (p Player) GetInitiativeModifier(statistic string) int {
+	if statistic == InitiativePerception {
+		return p.GetPerceptionMod()
+	}
 
-	if p.IsOffGuard() {
-		conditions["ac"] = -2
+	if modifier, ok := p.Skills[statistic]; ok {
+		return modifier + ConditionModifier(p.Conditions, p.Effects, SkillSelectors(statistic))
 	}
 
-	return conditions
+	return untrainedSkillModifier(&p, statistic)
 }
 
-func (p Player) GenerateInitiative() int {
-	//nolint:gosec
-	return rand.Intn(20) + 1 + p.GetPerceptionMod()
+func (p Player) GetInitiativeRoll() InitiativeRoll {
+	return InitiativeRoll{Statistic: p.InitiativeStatistic, Die: p.InitiativeDie, Total: p.Initiative}
+}
+
+func (p *Player) SetInitiativeRoll(db database.Service, roll InitiativeRoll) error {
+	p.Initiative = roll.Total
+	p.InitiativeStatistic = roll.Statistic
+	p.InitiativeDie = roll.Die
+
+	_, err := db.Exec(`
+        UPDATE encounter_players
+        SET initiative = $1, initiative_statistic = $2, initiative_die = $3
+        WHERE id = $4
+    `, roll.Total, roll.Statistic, roll.Die, p.AssociationID)
+
+	if err != nil {
+		return fmt.Errorf("error updating player initiative in database: %v", err)
+	}
+
+	return nil
 }
 
 func (p *Player) GetAssociationID() int {
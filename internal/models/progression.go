@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"pf2.encounterbrew.com/internal/database"
+)
+
+// A party levels up every 1000 XP, up to level 20
+const (
+	XpPerLevel = 1000
+	MaxLevel   = 20
+)
+
+// XpAward is XP a party earned in an encounter. The encounter's name is kept
+// so the award stays in the history when the encounter is deleted.
+type XpAward struct {
+	ID            int       `json:"id"`
+	PartyID       int       `json:"party_id"`
+	EncounterID   *int      `json:"encounter_id,omitempty"`
+	EncounterName string    `json:"encounter_name"`
+	Xp            int       `json:"xp"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CanLevelUp reports whether the party has the XP for the next level
+func (p Party) CanLevelUp() bool {
+	return p.Xp >= XpPerLevel
+}
+
+// AwardXp adds the XP to the party's total and its history. XP can only be
+// awarded once the encounter has finished, and once until it is reset.
+func AwardXp(db database.Service, e *Encounter, xp int) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+	if !e.IsFinished() {
+		return errors.New("XP is awarded once the encounter has finished")
+	}
+	if e.XpAward != nil {
+		return fmt.Errorf("the party already earned %d XP for this encounter", e.XpAward.Xp)
+	}
+	if xp < 0 {
+		return errors.New("XP can't be negative")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	award := XpAward{PartyID: e.PartyID, EncounterID: &e.ID, EncounterName: e.Name, Xp: xp}
+	err = tx.QueryRow(`
+		INSERT INTO party_xp_awards (party_id, encounter_id, encounter_name, xp)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, award.PartyID, e.ID, award.EncounterName, award.Xp).Scan(&award.ID, &award.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return errors.New("the party already earned XP for this encounter")
+	}
+	if err != nil {
+		return fmt.Errorf("error awarding XP: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE parties
+		SET xp = xp + $1
+		WHERE id = $2
+	`, xp, e.PartyID)
+	if err != nil {
+		return fmt.Errorf("error updating party XP: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	e.XpAward = &award
+
+	return nil
+}
+
+// LoadXpAward looks up the XP the party earned in the encounter. Resetting
+// the encounter detaches its award, so it can earn XP again.
+func LoadXpAward(db database.Service, e *Encounter) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	e.XpAward = nil
+	if !e.IsFinished() {
+		return nil
+	}
+
+	var award XpAward
+	err := db.QueryRow(`
+		SELECT id, party_id, encounter_id, encounter_name, xp, created_at
+		FROM party_xp_awards
+		WHERE encounter_id = $1
+	`, e.ID).Scan(&award.ID, &award.PartyID, &award.EncounterID, &award.EncounterName, &award.Xp, &award.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting XP award: %v", err)
+	}
+	e.XpAward = &award
+
+	return nil
+}
+
+// LoadPartyProgress loads the party's XP total and the history of its
+// awards, newest first
+func LoadPartyProgress(db database.Service, p *Party) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	err := db.QueryRow(`
+		SELECT xp FROM parties
+		WHERE id = $1
+	`, p.ID).Scan(&p.Xp)
+	if err != nil {
+		return fmt.Errorf("error getting party XP: %v", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT id, party_id, encounter_id, encounter_name, xp, created_at
+		FROM party_xp_awards
+		WHERE party_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, p.ID)
+	if err != nil {
+		return fmt.Errorf("error getting XP awards: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	p.XpAwards = nil
+	for rows.Next() {
+		var award XpAward
+		if err := rows.Scan(&award.ID, &award.PartyID, &award.EncounterID, &award.EncounterName, &award.Xp, &award.CreatedAt); err != nil {
+			return fmt.Errorf("error scanning XP award: %v", err)
+		}
+		p.XpAwards = append(p.XpAwards, award)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over XP awards: %v", err)
+	}
+
+	return nil
+}
+
+// LevelUpParty spends 1000 of the party's XP to raise every player by a
+// level. Players already at level 20 stay there.
+func LevelUpParty(db database.Service, partyID int) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	result, err := tx.Exec(`
+		UPDATE parties
+		SET xp = xp - $1
+		WHERE id = $2 AND xp >= $1
+	`, XpPerLevel, partyID)
+	if err != nil {
+		return fmt.Errorf("error updating party XP: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("the party needs %d XP to level up", XpPerLevel)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE players
+		SET level = level + 1
+		WHERE party_id = $1 AND level < $2
+	`, partyID, MaxLevel)
+	if err != nil {
+		return fmt.Errorf("error leveling up players: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FlatModifier is a bonus or penalty from a FlatModifier rule element in a
+// condition's rules data
+type FlatModifier struct {
+	Slug      string
+	Type      string
+	Selectors []string
+	Value     int
+}
+
+// statisticSelectors lists the rule selectors that apply to each statistic
+var statisticSelectors = map[string][]string{
+	"ac":         {"all", "ac", "dex-based"},
+	"fort":       {"all", "saving-throw", "fortitude", "con-based"},
+	"ref":        {"all", "saving-throw", "reflex", "dex-based"},
+	"will":       {"all", "saving-throw", "will", "wis-based"},
+	"perception": {"all", "perception", "wis-based"},
+}
+
+var skillAttributes = map[string]string{
+	"acrobatics":   "dex",
+	"arcana":       "int",
+	"athletics":    "str",
+	"crafting":     "int",
+	"deception":    "cha",
+	"diplomacy":    "cha",
+	"intimidation": "cha",
+	"medicine":     "wis",
+	"nature":       "wis",
+	"occultism":    "int",
+	"performance":  "cha",
+	"religion":     "wis",
+	"society":      "int",
+	"stealth":      "dex",
+	"survival":     "wis",
+	"thievery":     "dex",
+}
+
+// traditionAttributes is used for spellcasting entries that don't name
+// their key attribute
+var traditionAttributes = map[string]string{
+	"arcane": "int",
+	"divine": "wis",
+	"occult": "cha",
+	"primal": "wis",
+}
+
+// GetFlatModifiers returns the condition's FlatModifier rules with their
+// values worked out. Rules with predicates, which only apply in situations
+// we can't know about, are skipped.
+func (c Condition) GetFlatModifiers() []FlatModifier {
+	var modifiers []FlatModifier
+
+	for _, r := range c.Data.System.Rules {
+		rule, ok := r.(map[string]any)
+		if !ok || rule["key"] != "FlatModifier" || rule["predicate"] != nil {
+			continue
+		}
+
+		value, ok := evaluateRuleValue(rule["value"], c.GetValue())
+		if !ok {
+			continue
+		}
+
+		modifier := FlatModifier{Value: value, Type: "untyped"}
+		if slug, ok := rule["slug"].(string); ok {
+			modifier.Slug = slug
+		}
+		if modifierType, ok := rule["type"].(string); ok {
+			modifier.Type = modifierType
+		}
+
+		switch selector := rule["selector"].(type) {
+		case string:
+			modifier.Selectors = []string{selector}
+		case []any:
+			for _, s := range selector {
+				if s, ok := s.(string); ok {
+					modifier.Selectors = append(modifier.Selectors, s)
+				}
+			}
+		}
+
+		modifiers = append(modifiers, modifier)
+	}
+
+	return modifiers
+}
+
+// evaluateRuleValue works out rule values such as -2, "-@item.badge.value"
+// or "-1 * @item.badge.value". Anything else, like formulas referring to
+// the actor, is reported as not evaluable.
+func evaluateRuleValue(value any, badge int) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case string:
+		expression := strings.ReplaceAll(v, " ", "")
+		expression = strings.ReplaceAll(expression, "@item.badge.value", strconv.Itoa(badge))
+
+		result := 1
+		for _, factor := range strings.Split(expression, "*") {
+			n, err := strconv.Atoi(factor)
+			if err != nil {
+				return 0, false
+			}
+			result *= n
+		}
+
+		return result, true
+	}
+
+	return 0, false
+}
+
+// ConditionModifier adds up the modifiers from the conditions and effects
+// that apply to any of the selectors. Only the highest bonus and the worst
+// penalty of each type count, untyped modifiers all stack. Overridden
+// conditions are ignored.
+func ConditionModifier(conditions []Condition, effects []Effect, selectors []string) int {
+	bonuses := map[string]int{}
+	penalties := map[string]int{}
+	untyped := 0
+
+	var modifiers []FlatModifier
+	for _, condition := range activeConditions(conditions) {
+		modifiers = append(modifiers, condition.GetFlatModifiers()...)
+	}
+	for _, effect := range effects {
+		modifiers = append(modifiers, effect.GetFlatModifiers()...)
+	}
+
+	for _, modifier := range modifiers {
+		if !modifier.appliesTo(selectors) {
+			continue
+		}
+
+		switch {
+		case modifier.Type == "untyped":
+			untyped += modifier.Value
+		case modifier.Value > 0:
+			bonuses[modifier.Type] = max(bonuses[modifier.Type], modifier.Value)
+		default:
+			penalties[modifier.Type] = min(penalties[modifier.Type], modifier.Value)
+		}
+	}
+
+	total := untyped
+	for _, value := range bonuses {
+		total += value
+	}
+	for _, value := range penalties {
+		total += value
+	}
+
+	return total
+}
+
+func (f FlatModifier) appliesTo(selectors []string) bool {
+	for _, selector := range f.Selectors {
+		for _, s := range selectors {
+			if selector == s {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// adjustConditions returns the condition and effect modifiers for AC, saves
+// and perception
+func adjustConditions(conditions []Condition, effects []Effect) map[string]int {
+	adjustments := map[string]int{}
+
+	for statistic, selectors := range statisticSelectors {
+		adjustments[statistic] = ConditionModifier(conditions, effects, selectors)
+	}
+
+	return adjustments
+}
+
+// SkillSelectors returns the rule selectors for a skill check, lores use
+// Intelligence
+func SkillSelectors(skill string) []string {
+	skill = strings.ToLower(skill)
+
+	attribute, ok := skillAttributes[skill]
+	if !ok {
+		attribute = "int"
+	}
+
+	return []string{"all", "skill-check", skill, attribute + "-based"}
+}
+
+// AttackSelectors returns the rule selectors for a strike. Ranged strikes
+// use Dexterity, melee strikes Strength.
+func AttackSelectors(attack Item) []string {
+	attribute := "str"
+	if attack.System.Range != nil {
+		attribute = "dex"
+	}
+
+	return []string{"all", "attack", "attack-roll", "strike-attack-roll", attribute + "-based"}
+}
+
+// SpellAttackSelectors returns the rule selectors for spell attack rolls
+// from the spellcasting entry
+func SpellAttackSelectors(entry Item) []string {
+	return []string{"all", "attack", "attack-roll", "spell-attack-roll", entry.GetSpellcastingAttribute() + "-based"}
+}
+
+// SpellDCSelectors returns the rule selectors for spell DCs from the
+// spellcasting entry
+func SpellDCSelectors(entry Item) []string {
+	return []string{"all", "spell-dc", entry.GetSpellcastingAttribute() + "-based"}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/utils"
+)
+
+// ParseSkills reads skill modifiers written like a statblock, e.g.
+// "Stealth +9, Deception +7"
+func ParseSkills(input string) (map[string]int, error) {
+	skills := map[string]int{}
+
+	for _, entry := range strings.Split(input, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(entry, " ")
+		if separator < 0 {
+			return nil, fmt.Errorf("skill %q needs a modifier", entry)
+		}
+
+		name := strings.ToLower(strings.TrimSpace(entry[:separator]))
+		if _, ok := skillAttributes[name]; !ok {
+			return nil, fmt.Errorf("unknown skill %q", name)
+		}
+
+		modifier, err := strconv.Atoi(strings.TrimPrefix(entry[separator+1:], "+"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid modifier for %s: %q", name, entry[separator+1:])
+		}
+
+		skills[name] = modifier
+	}
+
+	return skills, nil
+}
+
+// FormatSkills writes skill modifiers the way ParseSkills reads them, sorted
+// by name
+func FormatSkills(skills map[string]int) string {
+	names := make([]string, 0, len(skills))
+	for name := range skills {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]string, len(names))
+	for i, name := range names {
+		entries[i] = fmt.Sprintf("%s %s", utils.CapitalizeFirst(name), utils.PositiveOrNegative(skills[name]))
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+// marshalSkills encodes skill modifiers for the players.skills column
+func marshalSkills(skills map[string]int) ([]byte, error) {
+	if skills == nil {
+		skills = map[string]int{}
+	}
+
+	data, err := json.Marshal(skills)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding skills: %v", err)
+	}
+
+	return data, nil
+}
+
+// unmarshalSkills decodes the players.skills column
+func unmarshalSkills(data []byte) (map[string]int, error) {
+	skills := map[string]int{}
+	if len(data) == 0 {
+		return skills, nil
+	}
+
+	if err := json.Unmarshal(data, &skills); err != nil {
+		return nil, fmt.Errorf("error decoding skills: %v", err)
+	}
+
+	return skills, nil
+}
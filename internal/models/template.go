@@ -0,0 +1,313 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+// EncounterTemplate is a party-agnostic blueprint of an encounter's creatures
+type EncounterTemplate struct {
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	Creatures []TemplateCreature `json:"creatures,omitempty"`
+}
+
+// TemplateCreature is a creature of a template with the number of copies
+type TemplateCreature struct {
+	Name            string `json:"name"`
+	LevelAdjustment int    `json:"level_adjustment"`
+	Quantity        int    `json:"quantity"`
+}
+
+func (c TemplateCreature) String() string {
+	name := c.Name
+	if c.LevelAdjustment > 0 {
+		name = "Elite " + name
+	} else if c.LevelAdjustment < 0 {
+		name = "Weak " + name
+	}
+
+	if c.Quantity > 1 {
+		return fmt.Sprintf("%d × %s", c.Quantity, name)
+	}
+
+	return name
+}
+
+// Fresh copies of the creatures start at full HP without initiative,
+// conditions or effects. Companions and summons belong to combatants of the
+// original encounter and aren't copied.
+const (
+	copyEncounterMonsters = `
+		INSERT INTO encounter_monsters (encounter_id, monster_id, level_adjustment, hp, enumeration)
+		SELECT $1, em.monster_id, em.level_adjustment, (m.data->'system'->'attributes'->'hp'->>'value')::int, em.enumeration
+		FROM encounter_monsters em
+		JOIN monsters m ON em.monster_id = m.id
+		WHERE em.encounter_id = $2 AND em.minion_kind = ''
+		ORDER BY em.id
+	`
+	copyEncounterCustoms = `
+		INSERT INTO encounter_custom_combatants (encounter_id, name, level, max_hp, hp, ac, fort, ref, will, perception, attacks, enumeration)
+		SELECT $1, name, level, max_hp, max_hp, ac, fort, ref, will, perception, attacks, enumeration
+		FROM encounter_custom_combatants
+		WHERE encounter_id = $2 AND minion_kind = ''
+		ORDER BY id
+	`
+	copyTemplateMonsters = `
+		INSERT INTO encounter_monsters (encounter_id, monster_id, level_adjustment, hp, enumeration)
+		SELECT $1, tm.monster_id, tm.level_adjustment, (m.data->'system'->'attributes'->'hp'->>'value')::int, tm.enumeration
+		FROM encounter_template_monsters tm
+		JOIN monsters m ON tm.monster_id = m.id
+		WHERE tm.template_id = $2
+		ORDER BY tm.id
+	`
+	copyTemplateCustoms = `
+		INSERT INTO encounter_custom_combatants (encounter_id, name, level, max_hp, hp, ac, fort, ref, will, perception, attacks, enumeration)
+		SELECT $1, name, level, max_hp, max_hp, ac, fort, ref, will, perception, attacks, enumeration
+		FROM encounter_template_customs
+		WHERE template_id = $2
+		ORDER BY id
+	`
+)
+
+// DuplicateEncounter copies the encounter's monsters, with their level
+// adjustments, and custom combatants into a new encounter for the same party
+// at round 0. Without a name the copy is named after the original.
+func DuplicateEncounter(db database.Service, encounterID int, name string) (Encounter, error) {
+	if db == nil {
+		return Encounter{}, errors.New("database service is nil")
+	}
+
+	var original string
+	var partyID int
+	err := db.QueryRow(`
+		SELECT name, party_id FROM encounters
+		WHERE user_id = $1 AND id = $2
+	`, 1, encounterID).Scan(&original, &partyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Encounter{}, fmt.Errorf("no encounter found with ID %d", encounterID)
+		}
+		return Encounter{}, fmt.Errorf("error getting encounter: %v", err)
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = original + " (copy)"
+	}
+
+	return createEncounterFrom(db, name, partyID, encounterID, copyEncounterMonsters, copyEncounterCustoms)
+}
+
+// SaveEncounterTemplate saves the encounter's monsters and custom combatants
+// as a template that can be used for any party
+func SaveEncounterTemplate(db database.Service, encounterID int, name string) (EncounterTemplate, error) {
+	if db == nil {
+		return EncounterTemplate{}, errors.New("database service is nil")
+	}
+
+	template := EncounterTemplate{Name: strings.TrimSpace(name)}
+	if template.Name == "" {
+		return EncounterTemplate{}, errors.New("a template needs a name")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return EncounterTemplate{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	err = tx.QueryRow(`
+		INSERT INTO encounter_templates (name, user_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`, template.Name, 1).Scan(&template.ID)
+	if err != nil {
+		return EncounterTemplate{}, fmt.Errorf("error creating template: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO encounter_template_monsters (template_id, monster_id, level_adjustment, enumeration)
+		SELECT $1, monster_id, level_adjustment, enumeration
+		FROM encounter_monsters
+		WHERE encounter_id = $2 AND minion_kind = ''
+		ORDER BY id
+	`, template.ID, encounterID)
+	if err != nil {
+		return EncounterTemplate{}, fmt.Errorf("error adding monsters to template: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO encounter_template_customs (template_id, name, level, max_hp, ac, fort, ref, will, perception, attacks, enumeration)
+		SELECT $1, name, level, max_hp, ac, fort, ref, will, perception, attacks, enumeration
+		FROM encounter_custom_combatants
+		WHERE encounter_id = $2 AND minion_kind = ''
+		ORDER BY id
+	`, template.ID, encounterID)
+	if err != nil {
+		return EncounterTemplate{}, fmt.Errorf("error adding custom combatants to template: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return EncounterTemplate{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return template, nil
+}
+
+// GetAllEncounterTemplates returns the templates with their creatures
+func GetAllEncounterTemplates(db database.Service) ([]EncounterTemplate, error) {
+	if db == nil {
+		return nil, errors.New("database service is nil")
+	}
+
+	rows, err := db.Query(`
+		SELECT id, name FROM encounter_templates
+		WHERE user_id = $1
+		ORDER BY name, id
+	`, 1) // hard-coded User-ID for now
+	if err != nil {
+		return nil, fmt.Errorf("error getting templates: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var templates []EncounterTemplate
+	for rows.Next() {
+		var t EncounterTemplate
+		if err := rows.Scan(&t.ID, &t.Name); err != nil {
+			return nil, fmt.Errorf("error scanning template: %v", err)
+		}
+		templates = append(templates, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over templates: %v", err)
+	}
+
+	for i := range templates {
+		if templates[i].Creatures, err = getTemplateCreatures(db, templates[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+// getTemplateCreatures counts the copies of each creature in the template
+func getTemplateCreatures(db database.Service, templateID int) ([]TemplateCreature, error) {
+	rows, err := db.Query(`
+		SELECT name, level_adjustment, COUNT(*)
+		FROM (
+			SELECT tm.id, m.data->>'name' AS name, tm.level_adjustment
+			FROM encounter_template_monsters tm
+			JOIN monsters m ON tm.monster_id = m.id
+			WHERE tm.template_id = $1
+			UNION ALL
+			SELECT id, name, 0
+			FROM encounter_template_customs
+			WHERE template_id = $1
+		) creatures
+		GROUP BY name, level_adjustment
+		ORDER BY MIN(id)
+	`, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting template creatures: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("error closing rows: %v\n", err)
+		}
+	}()
+
+	var creatures []TemplateCreature
+	for rows.Next() {
+		var c TemplateCreature
+		if err := rows.Scan(&c.Name, &c.LevelAdjustment, &c.Quantity); err != nil {
+			return nil, fmt.Errorf("error scanning template creature: %v", err)
+		}
+		creatures = append(creatures, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over template creatures: %v", err)
+	}
+
+	return creatures, nil
+}
+
+// CreateEncounterFromTemplate creates an encounter for the party with fresh
+// copies of the template's creatures
+func CreateEncounterFromTemplate(db database.Service, templateID int, name string, partyID int) (Encounter, error) {
+	if db == nil {
+		return Encounter{}, errors.New("database service is nil")
+	}
+
+	var template string
+	err := db.QueryRow(`
+		SELECT name FROM encounter_templates
+		WHERE user_id = $1 AND id = $2
+	`, 1, templateID).Scan(&template)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Encounter{}, fmt.Errorf("no template found with ID %d", templateID)
+		}
+		return Encounter{}, fmt.Errorf("error getting template: %v", err)
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = template
+	}
+
+	return createEncounterFrom(db, name, partyID, templateID, copyTemplateMonsters, copyTemplateCustoms)
+}
+
+func DeleteEncounterTemplate(db database.Service, templateID int) error {
+	if db == nil {
+		return errors.New("database service is nil")
+	}
+
+	_, err := db.Exec(`
+		DELETE FROM encounter_templates
+		WHERE user_id = $1 AND id = $2
+	`, 1, templateID)
+	if err != nil {
+		return fmt.Errorf("error deleting template: %v", err)
+	}
+
+	return nil
+}
+
+// createEncounterFrom creates an encounter for the party and fills it with
+// the creatures the copy queries select from the source encounter or template
+func createEncounterFrom(db database.Service, name string, partyID int, sourceID int, copyMonsters string, copyCustoms string) (Encounter, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return Encounter{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	//nolint:errcheck
+	defer tx.Rollback() // Rollback the transaction if it hasn't been committed
+
+	encounter, err := insertEncounter(tx, name, partyID)
+	if err != nil {
+		return Encounter{}, err
+	}
+
+	if _, err = tx.Exec(copyMonsters, encounter.ID, sourceID); err != nil {
+		return Encounter{}, fmt.Errorf("error copying monsters: %v", err)
+	}
+	if _, err = tx.Exec(copyCustoms, encounter.ID, sourceID); err != nil {
+		return Encounter{}, fmt.Errorf("error copying custom combatants: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Encounter{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return encounter, nil
+}
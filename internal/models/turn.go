@@ -0,0 +1,287 @@
+package models
+
+import (
+	"fmt"
+
+	"pf2.encounterbrew.com/internal/database"
+)
+
+// turnEndReductions lists the valued conditions that drop automatically
+// at the end of the affected creature's turn, and by how much
+var turnEndReductions = map[string]int{
+	"frightened": 1,
+}
+
+// findCondition returns the combatant's condition with the given slug
+func findCondition(c Combatant, slug string) (Condition, bool) {
+	for _, condition := range c.GetConditions() {
+		if condition.GetSlug() == slug {
+			return condition, true
+		}
+	}
+
+	return Condition{}, false
+}
+
+// reduceCondition lowers a valued condition and removes it once it reaches 0
+func reduceCondition(db database.Service, encounterID int, c Combatant, condition Condition, amount int) (string, error) {
+	newValue := condition.GetValue() - amount
+
+	if newValue <= 0 {
+		if err := c.RemoveCondition(db, encounterID, condition.ID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s is no longer %s", c.GetName(), condition.GetSlug()), nil
+	}
+
+	if err := c.SetCondition(db, encounterID, condition.ID, -amount); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s: %s %d → %d", c.GetName(), condition.GetSlug(), condition.GetValue(), newValue), nil
+}
+
+// ProcessTurnStart applies the start of turn rules for the combatant and
+// returns a description of everything that changed
+func ProcessTurnStart(db database.Service, encounterID int, c Combatant) ([]string, error) {
+	var changes []string
+
+	if IsDying(c) {
+		changes = append(changes, fmt.Sprintf("%s must attempt a recovery check (DC %d)", c.GetName(), RecoveryCheckDC(c)))
+	}
+
+	actions := ActionsPerTurn(c)
+
+	// Stunned overrides slowed: actions lost to stunned count towards the slowed ones
+	lostToStunned := 0
+	if stunned, ok := findCondition(c, "stunned"); ok && stunned.GetValue() > 0 {
+		lostToStunned = min(stunned.GetValue(), actions)
+
+		change, err := reduceCondition(db, encounterID, c, stunned, lostToStunned)
+		if err != nil {
+			return changes, fmt.Errorf("error reducing stunned: %v", err)
+		}
+		changes = append(changes, fmt.Sprintf("%s loses %d %s to stunned", c.GetName(), lostToStunned, pluralizeActions(lostToStunned)), change)
+	}
+
+	lost := lostToStunned
+	if slowed, ok := findCondition(c, "slowed"); ok && slowed.GetValue() > lostToStunned {
+		lost = min(slowed.GetValue(), actions)
+		changes = append(changes, fmt.Sprintf("%s loses %d %s to slowed %d", c.GetName(), lost-lostToStunned, pluralizeActions(lost-lostToStunned), slowed.GetValue()))
+	}
+
+	remaining := actions - lost
+	if lost > 0 {
+		changes = append(changes, fmt.Sprintf("%s has %d %s this turn", c.GetName(), remaining, pluralizeActions(remaining)))
+	}
+
+	// A new turn brings fresh actions and the reaction back
+	if err := c.SetActions(db, remaining, false); err != nil {
+		return changes, fmt.Errorf("error resetting actions: %v", err)
+	}
+
+	return changes, nil
+}
+
+// ProcessTurnEnd applies the end of turn rules for the combatant and
+// returns a description of everything that changed
+func ProcessTurnEnd(db database.Service, encounterID int, c Combatant) ([]string, error) {
+	// The multiple attack penalty only lasts for the turn
+	if c.GetAttacksMade() > 0 {
+		if err := c.SetAttacksMade(db, 0); err != nil {
+			return nil, fmt.Errorf("error resetting attacks: %v", err)
+		}
+	}
+
+	changes, err := processPersistentDamage(db, encounterID, c)
+	if err != nil {
+		return changes, fmt.Errorf("error processing persistent damage: %v", err)
+	}
+
+	// Work on a copy, removing a condition modifies the combatant's slice
+	conditions := append([]Condition{}, c.GetConditions()...)
+
+	for _, condition := range conditions {
+		amount, ok := turnEndReductions[condition.GetSlug()]
+		if !ok || !condition.IsValued() {
+			continue
+		}
+
+		change, err := reduceCondition(db, encounterID, c, condition, amount)
+		if err != nil {
+			return changes, fmt.Errorf("error reducing %s: %v", condition.GetSlug(), err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func pluralizeActions(count int) string {
+	if count == 1 {
+		return "action"
+	}
+
+	return "actions"
+}
+
+// NextTurn ends the active combatant's turn, moves on to the next combatant
+// and starts their turn. It returns a description of everything that changed.
+func NextTurn(db database.Service, e *Encounter) ([]string, error) {
+	if len(e.Combatants) == 0 {
+		return nil, nil
+	}
+
+	changes, err := endTurn(db, e)
+	if err != nil {
+		return changes, err
+	}
+
+	if err := advanceTurn(db, e); err != nil {
+		return changes, err
+	}
+
+	started, err := startTurn(db, e)
+	return append(changes, started...), err
+}
+
+// NextGroupTurn takes the turns of the active combatant and the rest of their
+// initiative group as one step, running each member's start and end of turn
+// rules on the way. Combatants outside of a group only take their own turn.
+func NextGroupTurn(db database.Service, e *Encounter) ([]string, error) {
+	if len(e.Combatants) == 0 {
+		return nil, nil
+	}
+
+	group := e.Combatants[e.Turn].GetInitiativeGroup()
+
+	var changes []string
+	for {
+		round := e.Round
+
+		step, err := NextTurn(db, e)
+		changes = append(changes, step...)
+		if err != nil {
+			return changes, err
+		}
+
+		if group == 0 || e.Round != round || e.Combatants[e.Turn].GetInitiativeGroup() != group {
+			return changes, nil
+		}
+	}
+}
+
+// endTurn runs the end of turn rules for the active combatant and the
+// minions acting on their turn
+func endTurn(db database.Service, e *Encounter) ([]string, error) {
+	var changes []string
+	for _, c := range actingCombatants(e) {
+		ended, err := endTurnOf(db, e, c)
+		changes = append(changes, ended...)
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// endTurnOf runs the end of turn rules for one combatant. A combatant that
+// returned from delaying already went through them when they delayed.
+func endTurnOf(db database.Service, e *Encounter, c Combatant) ([]string, error) {
+	if c.GetDelayState() == DelayStateReturned {
+		return nil, c.SetDelayState(db, "")
+	}
+
+	changes, err := ProcessTurnEnd(db, e.ID, c)
+	if err != nil {
+		return changes, fmt.Errorf("error processing end of turn: %v", err)
+	}
+
+	expired, err := ProcessEffects(db, e.ID, e.Combatants, c, EffectAnchorEnd)
+	if err != nil {
+		return changes, fmt.Errorf("error processing effects: %v", err)
+	}
+
+	return append(changes, expired...), nil
+}
+
+// actingCombatants returns the active combatant followed by the minions that
+// act on their turn, which are always placed right behind them
+func actingCombatants(e *Encounter) []Combatant {
+	acting := []Combatant{e.Combatants[e.Turn]}
+	for _, c := range e.Combatants[e.Turn+1:] {
+		if !HasOwner(e.Combatants, c) {
+			break
+		}
+		acting = append(acting, c)
+	}
+
+	return acting
+}
+
+// advanceTurn moves the turn to the next combatant, starting a new round
+// after the last one and skipping minions
+func advanceTurn(db database.Service, e *Encounter) error {
+	// Minions act on their owner's turn instead of getting their own
+	for range e.Combatants {
+		if e.Turn >= len(e.Combatants)-1 {
+			e.Turn = 0
+			e.Round += 1
+		} else {
+			e.Turn += 1
+		}
+
+		if !HasOwner(e.Combatants, e.Combatants[e.Turn]) {
+			break
+		}
+	}
+
+	if err := UpdateTurnAndRound(db, e); err != nil {
+		return fmt.Errorf("error updating turn and round: %v", err)
+	}
+
+	return nil
+}
+
+// startTurn runs the start of turn rules for the active combatant and the
+// minions acting on their turn
+func startTurn(db database.Service, e *Encounter) ([]string, error) {
+	var changes []string
+	for _, c := range actingCombatants(e) {
+		started, err := startTurnOf(db, e, c)
+		changes = append(changes, started...)
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// startTurnOf runs the start of turn rules for one combatant. A combatant
+// that is still delaying has delayed a whole round, so they lose the delayed
+// turn and act at their original position again.
+func startTurnOf(db database.Service, e *Encounter, c Combatant) ([]string, error) {
+	var changes []string
+
+	if c.GetDelayState() == DelayStateDelaying {
+		if err := c.SetDelayState(db, ""); err != nil {
+			return changes, err
+		}
+		changes = append(changes, fmt.Sprintf("%s delayed a whole round and acts at their original position", c.GetName()))
+	}
+
+	expired, err := ProcessEffects(db, e.ID, e.Combatants, c, EffectAnchorStart)
+	if err != nil {
+		return changes, fmt.Errorf("error processing effects: %v", err)
+	}
+	changes = append(changes, expired...)
+
+	started, err := ProcessTurnStart(db, e.ID, c)
+	if err != nil {
+		return changes, fmt.Errorf("error processing start of turn: %v", err)
+	}
+
+	return append(changes, started...), nil
+}
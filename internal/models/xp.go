@@ -0,0 +1,200 @@
+package models
+
+import (
+	"math"
+)
+
+// Threat tiers of an encounter, from easiest to deadliest
+const (
+	ThreatTrivial = iota
+	ThreatLow
+	ThreatModerate
+	ThreatSevere
+	ThreatExtreme
+)
+
+// threatTiers holds the XP budget of each threat tier for a party of four
+// and the character adjustment for each character more or fewer
+var threatTiers = []struct {
+	name       string
+	budget     int
+	adjustment int
+}{
+	{"Trivial", 40, 10},
+	{"Low", 60, 15},
+	{"Moderate", 80, 20},
+	{"Severe", 120, 30},
+	{"Extreme", 160, 40},
+}
+
+// ThreatTier is the XP budget of a threat tier for the party
+type ThreatTier struct {
+	Name   string `json:"name"`
+	Budget int    `json:"budget"`
+}
+
+// CreatureXp is the XP a creature or hazard of the encounter is worth
+type CreatureXp struct {
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+	Xp    int    `json:"xp"`
+}
+
+// XpBudget is the XP an encounter spends against the budget of its party
+type XpBudget struct {
+	PartyLevel int          `json:"party_level"`
+	PartySize  int          `json:"party_size"`
+	Xp         int          `json:"xp"`
+	Tiers      []ThreatTier `json:"tiers"`
+	Creatures  []CreatureXp `json:"creatures"`
+}
+
+// GetXpBudget works out the XP of the encounter's creatures and hazards for
+// its party. Minions and allies fight alongside someone else and aren't
+// counted.
+func (e Encounter) GetXpBudget() XpBudget {
+	return e.xpBudget(false)
+}
+
+// GetEarnedXp works out the XP the party earned for the creatures they
+// defeated and the hazards they overcame
+func (e Encounter) GetEarnedXp() XpBudget {
+	return e.xpBudget(true)
+}
+
+// xpBudget counts the XP of the creatures and hazards, leaving out the
+// creatures still standing when they have to be defeated
+func (e Encounter) xpBudget(defeated bool) XpBudget {
+	budget := XpBudget{
+		PartyLevel: partyLevel(e.Players),
+		PartySize:  len(e.Players),
+	}
+
+	for threat, tier := range threatTiers {
+		budget.Tiers = append(budget.Tiers, ThreatTier{Name: tier.name, Budget: tierBudget(threat, budget.PartySize)})
+	}
+
+	for _, combatant := range e.Combatants {
+		if IsMinion(combatant) || IsAlly(combatant) {
+			continue
+		}
+
+		// Hazards are overcome rather than defeated, so they always count
+		if defeated && combatant.GetType() != HazardType && combatant.GetHp() > 0 {
+			continue
+		}
+
+		// Complex hazards and custom combatants are worth as much as a
+		// creature of their level
+		switch combatant.GetType() {
+		case "monster", HazardType, CustomType:
+			budget.add(combatant.GetName(), combatant.GetLevel(), creatureXp(combatant.GetLevel()-budget.PartyLevel))
+		}
+	}
+
+	// Simple hazards aren't combatants but still add to the challenge
+	for _, hazard := range e.SimpleHazards() {
+		budget.add(hazard.GetName(), hazard.GetLevel(), hazardXp(hazard, budget.PartyLevel))
+	}
+
+	return budget
+}
+
+// tierBudget returns the XP budget of the threat tier adjusted to the size of
+// the party
+func tierBudget(threat int, partySize int) int {
+	tier := threatTiers[threat]
+	return max(0, tier.budget+(partySize-4)*tier.adjustment)
+}
+
+func (b *XpBudget) add(name string, level int, xp int) {
+	b.Creatures = append(b.Creatures, CreatureXp{Name: name, Level: level, Xp: xp})
+	b.Xp += xp
+}
+
+// Threat returns the highest threat tier whose budget the encounter's XP
+// reaches, or -1 when there is no party to measure it against. A tier's
+// budget is what an encounter of that threat spends, so an encounter only
+// counts as moderate once it spends the whole moderate budget: 70 XP
+// against four players is low, not moderate.
+func (b XpBudget) Threat() int {
+	if b.PartySize == 0 {
+		return -1
+	}
+
+	threat := ThreatTrivial
+	for i, tier := range b.Tiers {
+		if b.Xp >= tier.Budget {
+			threat = i
+		}
+	}
+
+	return threat
+}
+
+// ThreatName returns the name of the encounter's threat tier
+func (b XpBudget) ThreatName() string {
+	if threat := b.Threat(); threat >= 0 {
+		return b.Tiers[threat].Name
+	}
+
+	return "No party"
+}
+
+// partyLevel returns the average level of the players, rounded to the
+// nearest level
+func partyLevel(players []*Player) int {
+	if len(players) == 0 {
+		return 0
+	}
+
+	levels := 0
+	for _, player := range players {
+		levels += player.Level
+	}
+
+	return int(math.Round(float64(levels) / float64(len(players))))
+}
+
+// IsAlly reports whether the creature fights on the party's side, which GMs
+// mark with a friendly or helpful attitude
+func IsAlly(c Combatant) bool {
+	if !c.IsMonster() {
+		return false
+	}
+
+	_, friendly := findCondition(c, "friendly")
+	_, helpful := findCondition(c, "helpful")
+
+	return friendly || helpful
+}
+
+// creatureXp returns the XP a creature is worth by the difference between
+// its level and the party's. The creature XP table starts at 4 levels below
+// the party; creatures even weaker than that pose no threat and are worth
+// nothing rather than 10 XP. Those more than 4 above are off the table and
+// counted as 4 above.
+func creatureXp(difference int) int {
+	switch {
+	case difference < -4:
+		return 0
+	case difference == -4:
+		return 10
+	case difference == -3:
+		return 15
+	case difference == -2:
+		return 20
+	case difference == -1:
+		return 30
+	case difference == 0:
+		return 40
+	case difference == 1:
+		return 60
+	case difference == 2:
+		return 80
+	case difference == 3:
+		return 120
+	default:
+		return 160
+	}
+}
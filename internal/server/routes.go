@@ -39,21 +39,58 @@ func (s *Server) RegisterRoutes() http.Handler {
 	// Encounter routes
 	e.GET("/encounters/new", encounter.EncounterNewHandler(s.db))
 	e.POST("/encounters", encounter.EncounterCreateHandler(s.db))
+	e.GET("/encounters/generate", encounter.EncounterGenerateHandler(s.db))
+	e.POST("/encounters/generate/preview", encounter.EncounterGeneratePreview(s.db))
+	e.POST("/encounters/generate", encounter.EncounterGenerateSave(s.db))
+	e.GET("/encounters/templates", encounter.EncounterTemplateListHandler(s.db))
+	e.POST("/encounters/templates/:template_id", encounter.EncounterTemplateCreateHandler(s.db))
+	e.DELETE("/encounters/templates/:template_id", encounter.EncounterTemplateDeleteHandler(s.db))
+	e.POST("/encounters/:encounter_id/duplicate", encounter.EncounterDuplicateHandler(s.db))
+	e.POST("/encounters/:encounter_id/template", encounter.EncounterSaveTemplateHandler(s.db))
 	e.GET("/encounters/:encounter_id/edit", encounter.EncounterEditHandler(s.db))
 	e.PUT("/encounters/:encounter_id", encounter.EncounterUpdateHandler(s.db))
 	e.DELETE("/encounters/:encounter_id", encounter.EncounterDeleteHandler(s.db))
 	e.GET("/encounters", encounter.EncounterListHandler(s.db))
 	e.GET("/encounters/:encounter_id", encounter.EncounterShowHandler(s.db))
+	e.GET("/encounters/:encounter_id/events", encounter.EncounterEvents(s.db))
+	// Finished encounters are frozen until they are reset
+	frozen := encounter.RejectFinished(s.db)
 	e.POST("/encounters/:encounter_id/search_monsters", encounter.EncounterSearchMonster(s.db))
-	e.POST("/encounters/:encounter_id/add_monster/:monster_id", encounter.EncounterAddMonster(s.db))
-	e.POST("/encounters/:encounter_id/remove_monster/:association_id", encounter.EncounterRemoveMonster(s.db))
-	e.DELETE("/encounters/:encounter_id/remove_combatant/:association_id/:is_monster", encounter.EncounterRemoveCombatant(s.db))
-	e.PATCH("/encounters/:encounter_id/combatant/:index/update", encounter.UpdateCombatant(s.db))
-	e.PATCH("/encounters/:encounter_id/bulk_update_initiative", encounter.BulkUpdateInitiative(s.db))
-	e.POST("/encounters/:encounter_id/combatant/:index/add_condition/:condition_id", encounter.AddCondition(s.db))
-	e.POST("/encounters/:encounter_id/combatant/:index/remove_condition/:condition_id", encounter.RemoveCondition(s.db))
-	e.POST("/encounters/:encounter_id/next_turn", encounter.ChangeTurn(s.db, true))
-	e.POST("/encounters/:encounter_id/prev_turn", encounter.ChangeTurn(s.db, false))
+	e.POST("/encounters/:encounter_id/add_monster/:monster_id", encounter.EncounterAddMonster(s.db), frozen)
+	e.POST("/encounters/:encounter_id/remove_monster/:association_id", encounter.EncounterRemoveMonster(s.db), frozen)
+	e.POST("/encounters/:encounter_id/add_custom", encounter.EncounterAddCustomCombatant(s.db), frozen)
+	e.POST("/encounters/:encounter_id/remove_custom/:association_id", encounter.EncounterRemoveCustomCombatant(s.db), frozen)
+	e.DELETE("/encounters/:encounter_id/remove_combatant/:association_id/:is_monster", encounter.EncounterRemoveCombatant(s.db), frozen)
+	e.PATCH("/encounters/:encounter_id/combatant/:index/update", encounter.UpdateCombatant(s.db), frozen)
+	e.PATCH("/encounters/:encounter_id/bulk_update_initiative", encounter.BulkUpdateInitiative(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/add_condition/:condition_id", encounter.AddCondition(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/remove_condition/:condition_id", encounter.RemoveCondition(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/recovery_check", encounter.RecoveryCheck(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/persistent_damage", encounter.AddPersistentDamage(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/persistent_damage/:persistent_damage_id/remove", encounter.RemovePersistentDamage(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/persistent_damage/:persistent_damage_id/assist", encounter.AssistPersistentDamage(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/effect", encounter.AddEffect(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/effect/:effect_id/remove", encounter.RemoveEffect(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/ready", encounter.ReadyAction(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/return", encounter.ReturnFromDelay(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/actions", encounter.SpendActions(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/attack", encounter.MakeAttack(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/reaction", encounter.UseReaction(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/move_up", encounter.MoveInTie(s.db, true), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/move_down", encounter.MoveInTie(s.db, false), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/companion", encounter.AddCompanion(s.db), frozen)
+	e.POST("/encounters/:encounter_id/combatant/:index/search_summons", encounter.SearchSummons(s.db))
+	e.POST("/encounters/:encounter_id/combatant/:index/summon/:monster_id", encounter.AddSummon(s.db), frozen)
+	e.POST("/encounters/:encounter_id/delay", encounter.DelayTurn(s.db), frozen)
+	e.POST("/encounters/:encounter_id/next_turn", encounter.ChangeTurn(s.db, true), frozen)
+	e.POST("/encounters/:encounter_id/next_group_turn", encounter.NextGroupTurn(s.db), frozen)
+	e.POST("/encounters/:encounter_id/prev_turn", encounter.ChangeTurn(s.db, false), frozen)
+	e.POST("/encounters/:encounter_id/start", encounter.StartEncounter(s.db))
+	e.POST("/encounters/:encounter_id/end", encounter.EndEncounter(s.db))
+	e.POST("/encounters/:encounter_id/reset", encounter.ResetEncounter(s.db))
+	e.POST("/encounters/:encounter_id/award_xp", encounter.AwardXp(s.db))
+	e.POST("/encounters/:encounter_id/undo", encounter.ChangeHistory(s.db, true))
+	e.POST("/encounters/:encounter_id/redo", encounter.ChangeHistory(s.db, false))
 
 	// Party routes
 	e.GET("/parties", party.PartyListHandler(s.db))
@@ -63,6 +100,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 	e.POST("/parties", party.PartyCreateHandler(s.db))
 	e.GET("/parties/:party_id/edit", party.PartyEditHandler(s.db))
 	e.PATCH("/parties/:party_id", party.PartyUpdateHandler(s.db))
+	e.POST("/parties/:party_id/level_up", party.PartyLevelUpHandler(s.db))
 	e.DELETE("/parties/:party_id", party.DeletePartyHandler(s.db))
 
 	// Player routes
@@ -0,0 +1,2 @@
+ALTER TABLE encounter_players DROP COLUMN IF EXISTS temp_hp;
+ALTER TABLE encounter_monsters DROP COLUMN IF EXISTS temp_hp;
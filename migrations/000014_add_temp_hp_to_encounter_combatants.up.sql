@@ -0,0 +1,6 @@
+-- Temporary hit points are tracked per combatant in an encounter
+ALTER TABLE encounter_monsters
+ADD COLUMN temp_hp INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE encounter_players
+ADD COLUMN temp_hp INTEGER NOT NULL DEFAULT 0;
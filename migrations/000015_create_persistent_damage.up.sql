@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS persistent_damage (
+    id SERIAL PRIMARY KEY,
+    encounter_id INTEGER REFERENCES encounters(id) ON DELETE CASCADE,
+    encounter_player_id INTEGER REFERENCES encounter_players(id) ON DELETE CASCADE,
+    encounter_monster_id INTEGER REFERENCES encounter_monsters(id) ON DELETE CASCADE,
+    formula VARCHAR(50) NOT NULL,
+    damage_type VARCHAR(50) NOT NULL,
+    assisted BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT chk_persistent_damage_player_or_monster CHECK (
+        (encounter_player_id IS NOT NULL AND encounter_monster_id IS NULL) OR
+        (encounter_player_id IS NULL AND encounter_monster_id IS NOT NULL)
+    )
+);
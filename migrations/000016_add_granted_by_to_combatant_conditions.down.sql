@@ -0,0 +1,2 @@
+ALTER TABLE combatant_conditions
+DROP COLUMN IF EXISTS granted_by;
@@ -0,0 +1,4 @@
+-- Conditions granted by another condition (e.g. grabbed gives off-guard) are
+-- removed together with it
+ALTER TABLE combatant_conditions
+ADD COLUMN granted_by INTEGER REFERENCES conditions(id);
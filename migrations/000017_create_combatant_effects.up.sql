@@ -0,0 +1,21 @@
+-- Timed effects such as spells, tracked in rounds that count down at the
+-- start or end of the anchor combatant's turn
+CREATE TABLE IF NOT EXISTS combatant_effects (
+    id SERIAL PRIMARY KEY,
+    encounter_id INTEGER REFERENCES encounters(id) ON DELETE CASCADE,
+    encounter_player_id INTEGER REFERENCES encounter_players(id) ON DELETE CASCADE,
+    encounter_monster_id INTEGER REFERENCES encounter_monsters(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    rounds INTEGER NOT NULL CHECK (rounds > 0),
+    anchor VARCHAR(10) NOT NULL CHECK (anchor IN ('start', 'end')),
+    anchor_player_id INTEGER REFERENCES encounter_players(id) ON DELETE CASCADE,
+    anchor_monster_id INTEGER REFERENCES encounter_monsters(id) ON DELETE CASCADE,
+    CONSTRAINT chk_combatant_effects_player_or_monster CHECK (
+        (encounter_player_id IS NOT NULL AND encounter_monster_id IS NULL) OR
+        (encounter_player_id IS NULL AND encounter_monster_id IS NOT NULL)
+    ),
+    CONSTRAINT chk_combatant_effects_anchor_player_or_monster CHECK (
+        (anchor_player_id IS NOT NULL AND anchor_monster_id IS NULL) OR
+        (anchor_player_id IS NULL AND anchor_monster_id IS NOT NULL)
+    )
+);
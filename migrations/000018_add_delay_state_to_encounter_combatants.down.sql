@@ -0,0 +1,5 @@
+ALTER TABLE encounter_monsters
+DROP COLUMN IF EXISTS delay_state;
+
+ALTER TABLE encounter_players
+DROP COLUMN IF EXISTS delay_state;
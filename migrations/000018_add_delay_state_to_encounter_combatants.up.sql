@@ -0,0 +1,6 @@
+-- Whether a combatant is delaying or has just returned from delaying
+ALTER TABLE encounter_monsters
+ADD COLUMN delay_state VARCHAR(10) NOT NULL DEFAULT '';
+
+ALTER TABLE encounter_players
+ADD COLUMN delay_state VARCHAR(10) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+ALTER TABLE encounters
+ADD COLUMN turn INTEGER NOT NULL DEFAULT 0,
+DROP COLUMN IF EXISTS turn_association_id,
+DROP COLUMN IF EXISTS turn_is_monster;
+
+ALTER TABLE encounter_monsters
+DROP COLUMN IF EXISTS initiative_order;
+
+ALTER TABLE encounter_players
+DROP COLUMN IF EXISTS initiative_order;
@@ -0,0 +1,31 @@
+-- Secondary order for combatants tied on initiative, chosen by the GM or set
+-- when a combatant returns from delaying
+ALTER TABLE encounter_monsters
+ADD COLUMN initiative_order INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE encounter_players
+ADD COLUMN initiative_order INTEGER NOT NULL DEFAULT 0;
+
+-- The current turn belongs to a combatant rather than a position in the
+-- initiative order, so adding or removing combatants doesn't shift it
+ALTER TABLE encounters
+ADD COLUMN turn_association_id INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN turn_is_monster BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Running encounters keep their turn: the old turn was a position in the
+-- combatants sorted by initiative, with monsters listed before players
+UPDATE encounters e
+SET turn_association_id = c.id, turn_is_monster = c.is_monster
+FROM (
+    SELECT encounter_id, id, is_monster,
+        ROW_NUMBER() OVER (PARTITION BY encounter_id ORDER BY initiative DESC, is_monster DESC, id) - 1 AS position
+    FROM (
+        SELECT encounter_id, id, initiative, TRUE AS is_monster FROM encounter_monsters
+        UNION ALL
+        SELECT encounter_id, id, initiative, FALSE AS is_monster FROM encounter_players
+    ) combatants
+) c
+WHERE c.encounter_id = e.id AND c.position = e.turn;
+
+ALTER TABLE encounters
+DROP COLUMN IF EXISTS turn;
@@ -0,0 +1,7 @@
+ALTER TABLE encounter_monsters
+DROP COLUMN IF EXISTS actions_remaining,
+DROP COLUMN IF EXISTS reaction_used;
+
+ALTER TABLE encounter_players
+DROP COLUMN IF EXISTS actions_remaining,
+DROP COLUMN IF EXISTS reaction_used;
@@ -0,0 +1,8 @@
+-- Actions left on a combatant's turn and whether they used their reaction
+ALTER TABLE encounter_monsters
+ADD COLUMN actions_remaining INTEGER NOT NULL DEFAULT 3,
+ADD COLUMN reaction_used BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE encounter_players
+ADD COLUMN actions_remaining INTEGER NOT NULL DEFAULT 3,
+ADD COLUMN reaction_used BOOLEAN NOT NULL DEFAULT FALSE;
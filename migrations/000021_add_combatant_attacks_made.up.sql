@@ -0,0 +1,6 @@
+-- Attacks made on the current turn, for the multiple attack penalty
+ALTER TABLE encounter_monsters
+ADD COLUMN attacks_made INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE encounter_players
+ADD COLUMN attacks_made INTEGER NOT NULL DEFAULT 0;
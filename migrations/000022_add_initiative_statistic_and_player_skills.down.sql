@@ -0,0 +1,10 @@
+ALTER TABLE players
+DROP COLUMN IF EXISTS skills;
+
+ALTER TABLE encounter_monsters
+DROP COLUMN IF EXISTS initiative_statistic,
+DROP COLUMN IF EXISTS initiative_die;
+
+ALTER TABLE encounter_players
+DROP COLUMN IF EXISTS initiative_statistic,
+DROP COLUMN IF EXISTS initiative_die;
@@ -0,0 +1,12 @@
+-- Skill modifiers of players, e.g. {"stealth": 9}
+ALTER TABLE players
+ADD COLUMN skills JSONB NOT NULL DEFAULT '{}';
+
+-- The statistic initiative was rolled with and the d20 result
+ALTER TABLE encounter_monsters
+ADD COLUMN initiative_statistic VARCHAR(30) NOT NULL DEFAULT '',
+ADD COLUMN initiative_die INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE encounter_players
+ADD COLUMN initiative_statistic VARCHAR(30) NOT NULL DEFAULT '',
+ADD COLUMN initiative_die INTEGER NOT NULL DEFAULT 0;
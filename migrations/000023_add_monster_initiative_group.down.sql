@@ -0,0 +1,2 @@
+ALTER TABLE encounter_monsters
+DROP COLUMN IF EXISTS initiative_group;
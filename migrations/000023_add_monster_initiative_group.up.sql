@@ -0,0 +1,3 @@
+-- Monsters added together that share one initiative roll and act as a group
+ALTER TABLE encounter_monsters
+ADD COLUMN initiative_group INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- Append-only log of everything that happened during an encounter. Combatants
+-- are referenced by name so their events survive them being removed.
+CREATE TABLE IF NOT EXISTS encounter_events (
+    id SERIAL PRIMARY KEY,
+    encounter_id INTEGER NOT NULL REFERENCES encounters(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    combatant_name VARCHAR(255) NOT NULL DEFAULT '',
+    amount INTEGER NOT NULL DEFAULT 0,
+    damage_type VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    round INTEGER NOT NULL DEFAULT 0,
+    turn_combatant_name VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounter_events_encounter_id ON encounter_events(encounter_id);
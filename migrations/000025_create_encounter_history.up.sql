@@ -0,0 +1,12 @@
+-- Snapshots of an encounter's combat state taken before each change, so
+-- changes can be undone and redone
+CREATE TABLE IF NOT EXISTS encounter_history (
+    id SERIAL PRIMARY KEY,
+    encounter_id INTEGER NOT NULL REFERENCES encounters(id) ON DELETE CASCADE,
+    stack VARCHAR(4) NOT NULL CHECK (stack IN ('undo', 'redo')),
+    label VARCHAR(255) NOT NULL,
+    state JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounter_history_encounter_id ON encounter_history(encounter_id, stack);
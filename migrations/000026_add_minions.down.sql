@@ -0,0 +1,20 @@
+DELETE FROM encounter_players
+WHERE player_id IS NULL;
+
+ALTER TABLE encounter_monsters
+DROP COLUMN IF EXISTS minion_kind,
+DROP COLUMN IF EXISTS owner_association_id,
+DROP COLUMN IF EXISTS owner_is_monster;
+
+ALTER TABLE encounter_players
+DROP COLUMN IF EXISTS minion_kind,
+DROP COLUMN IF EXISTS owner_association_id,
+DROP COLUMN IF EXISTS owner_is_monster,
+DROP COLUMN IF EXISTS name,
+DROP COLUMN IF EXISTS level,
+DROP COLUMN IF EXISTS max_hp,
+DROP COLUMN IF EXISTS ac,
+DROP COLUMN IF EXISTS fort,
+DROP COLUMN IF EXISTS ref,
+DROP COLUMN IF EXISTS will,
+DROP COLUMN IF EXISTS perception;
@@ -0,0 +1,21 @@
+-- Companions, familiars, eidolons and summons act on the turn of the
+-- combatant that owns them, identified like the encounter's turn
+ALTER TABLE encounter_monsters
+ADD COLUMN minion_kind VARCHAR(20) NOT NULL DEFAULT '',
+ADD COLUMN owner_association_id INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN owner_is_monster BOOLEAN NOT NULL DEFAULT false;
+
+-- Companions aren't party members, so they have no player and keep their own
+-- statistics
+ALTER TABLE encounter_players
+ADD COLUMN minion_kind VARCHAR(20) NOT NULL DEFAULT '',
+ADD COLUMN owner_association_id INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN owner_is_monster BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN name VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN level INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN max_hp INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN ac INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN fort INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN ref INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN will INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN perception INTEGER NOT NULL DEFAULT 0;
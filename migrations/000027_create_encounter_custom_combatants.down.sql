@@ -0,0 +1,37 @@
+DROP TRIGGER IF EXISTS encounter_custom_combatants_delete_state ON encounter_custom_combatants;
+DROP TRIGGER IF EXISTS encounter_monsters_delete_state ON encounter_monsters;
+DROP FUNCTION IF EXISTS delete_monster_combatant_state();
+
+DELETE FROM combatant_conditions
+WHERE encounter_monster_id IN (SELECT id FROM encounter_custom_combatants);
+
+DELETE FROM persistent_damage
+WHERE encounter_monster_id IN (SELECT id FROM encounter_custom_combatants);
+
+DELETE FROM combatant_effects
+WHERE encounter_monster_id IN (SELECT id FROM encounter_custom_combatants)
+OR anchor_monster_id IN (SELECT id FROM encounter_custom_combatants);
+
+DROP TABLE IF EXISTS encounter_custom_combatants;
+
+ALTER TABLE combatant_conditions
+ADD CONSTRAINT combatant_conditions_encounter_monster_id_fkey
+FOREIGN KEY (encounter_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE;
+
+ALTER TABLE persistent_damage
+ADD CONSTRAINT persistent_damage_encounter_monster_id_fkey
+FOREIGN KEY (encounter_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE;
+
+ALTER TABLE combatant_effects
+ADD CONSTRAINT combatant_effects_encounter_monster_id_fkey
+FOREIGN KEY (encounter_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT combatant_effects_anchor_monster_id_fkey
+FOREIGN KEY (anchor_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE;
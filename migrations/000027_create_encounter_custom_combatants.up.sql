@@ -0,0 +1,62 @@
+-- Combatants made up on the fly without a bestiary entry. They take their ids
+-- from the encounter_monsters sequence, so they can be told apart from monsters
+-- by their id alone and share the monster columns of conditions and effects.
+CREATE TABLE IF NOT EXISTS encounter_custom_combatants (
+    id INTEGER PRIMARY KEY DEFAULT nextval('encounter_monsters_id_seq'),
+    encounter_id INTEGER NOT NULL REFERENCES encounters(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    level INTEGER NOT NULL DEFAULT 0,
+    max_hp INTEGER NOT NULL DEFAULT 0,
+    hp INTEGER NOT NULL DEFAULT 0,
+    temp_hp INTEGER NOT NULL DEFAULT 0,
+    ac INTEGER NOT NULL DEFAULT 0,
+    fort INTEGER NOT NULL DEFAULT 0,
+    ref INTEGER NOT NULL DEFAULT 0,
+    will INTEGER NOT NULL DEFAULT 0,
+    perception INTEGER NOT NULL DEFAULT 0,
+    attacks TEXT NOT NULL DEFAULT '',
+    enumeration INTEGER NOT NULL DEFAULT 0,
+    initiative INTEGER NOT NULL DEFAULT 0,
+    initiative_statistic VARCHAR(30) NOT NULL DEFAULT '',
+    initiative_die INTEGER NOT NULL DEFAULT 0,
+    initiative_order INTEGER NOT NULL DEFAULT 0,
+    initiative_group INTEGER NOT NULL DEFAULT 0,
+    delay_state VARCHAR(10) NOT NULL DEFAULT '',
+    actions_remaining INTEGER NOT NULL DEFAULT 3,
+    reaction_used BOOLEAN NOT NULL DEFAULT FALSE,
+    attacks_made INTEGER NOT NULL DEFAULT 0,
+    minion_kind VARCHAR(20) NOT NULL DEFAULT '',
+    owner_association_id INTEGER NOT NULL DEFAULT 0,
+    owner_is_monster BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounter_custom_combatants_encounter_id ON encounter_custom_combatants(encounter_id);
+
+-- The monster columns now refer to either table, so the foreign keys make way
+-- for a trigger removing the conditions and effects of deleted combatants
+ALTER TABLE combatant_conditions
+DROP CONSTRAINT IF EXISTS combatant_conditions_encounter_monster_id_fkey;
+
+ALTER TABLE persistent_damage
+DROP CONSTRAINT IF EXISTS persistent_damage_encounter_monster_id_fkey;
+
+ALTER TABLE combatant_effects
+DROP CONSTRAINT IF EXISTS combatant_effects_encounter_monster_id_fkey,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_monster_id_fkey;
+
+CREATE OR REPLACE FUNCTION delete_monster_combatant_state() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM combatant_conditions WHERE encounter_monster_id = OLD.id;
+    DELETE FROM persistent_damage WHERE encounter_monster_id = OLD.id;
+    DELETE FROM combatant_effects WHERE encounter_monster_id = OLD.id OR anchor_monster_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER encounter_monsters_delete_state
+AFTER DELETE ON encounter_monsters
+FOR EACH ROW EXECUTE FUNCTION delete_monster_combatant_state();
+
+CREATE TRIGGER encounter_custom_combatants_delete_state
+AFTER DELETE ON encounter_custom_combatants
+FOR EACH ROW EXECUTE FUNCTION delete_monster_combatant_state();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS encounter_template_customs;
+DROP TABLE IF EXISTS encounter_template_monsters;
+DROP TABLE IF EXISTS encounter_templates;
@@ -0,0 +1,33 @@
+-- Party-agnostic blueprints of encounters, holding one row per creature so
+-- they can be copied into a new encounter for any party
+CREATE TABLE IF NOT EXISTS encounter_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS encounter_template_monsters (
+    id SERIAL PRIMARY KEY,
+    template_id INTEGER NOT NULL REFERENCES encounter_templates(id) ON DELETE CASCADE,
+    monster_id INTEGER NOT NULL REFERENCES monsters(id) ON DELETE CASCADE,
+    level_adjustment INTEGER NOT NULL DEFAULT 0,
+    enumeration INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS encounter_template_customs (
+    id SERIAL PRIMARY KEY,
+    template_id INTEGER NOT NULL REFERENCES encounter_templates(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    level INTEGER NOT NULL DEFAULT 0,
+    max_hp INTEGER NOT NULL DEFAULT 0,
+    ac INTEGER NOT NULL DEFAULT 0,
+    fort INTEGER NOT NULL DEFAULT 0,
+    ref INTEGER NOT NULL DEFAULT 0,
+    will INTEGER NOT NULL DEFAULT 0,
+    perception INTEGER NOT NULL DEFAULT 0,
+    attacks TEXT NOT NULL DEFAULT '',
+    enumeration INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounter_template_monsters_template_id ON encounter_template_monsters(template_id);
+CREATE INDEX IF NOT EXISTS idx_encounter_template_customs_template_id ON encounter_template_customs(template_id);
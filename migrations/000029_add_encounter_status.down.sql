@@ -0,0 +1,4 @@
+ALTER TABLE encounters
+DROP COLUMN IF EXISTS finished_at,
+DROP COLUMN IF EXISTS started_at,
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,8 @@
+-- Encounters are prepared, run and finished. Encounters already past their
+-- first round are running.
+ALTER TABLE encounters
+ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'preparing' CHECK (status IN ('preparing', 'running', 'finished')),
+ADD COLUMN started_at TIMESTAMP,
+ADD COLUMN finished_at TIMESTAMP;
+
+UPDATE encounters SET status = 'running' WHERE round > 0;
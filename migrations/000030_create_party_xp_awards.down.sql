@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS party_xp_awards;
+
+ALTER TABLE parties
+DROP COLUMN IF EXISTS xp;
@@ -0,0 +1,18 @@
+-- Parties collect XP towards their next level, each award is kept for the
+-- party's XP history and survives the encounter it was earned in
+ALTER TABLE parties
+ADD COLUMN xp INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS party_xp_awards (
+    id SERIAL PRIMARY KEY,
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    encounter_id INTEGER REFERENCES encounters(id) ON DELETE SET NULL,
+    encounter_name VARCHAR(255) NOT NULL,
+    xp INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_party_xp_awards_party_id ON party_xp_awards(party_id);
+-- An encounter awards XP once, resetting it detaches the award so it can be
+-- run and awarded again
+CREATE UNIQUE INDEX IF NOT EXISTS idx_party_xp_awards_encounter_id ON party_xp_awards(encounter_id);
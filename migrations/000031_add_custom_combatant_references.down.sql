@@ -0,0 +1,74 @@
+ALTER TABLE combatant_conditions
+DROP CONSTRAINT IF EXISTS chk_encounter_player_or_monster,
+DROP CONSTRAINT IF EXISTS combatant_conditions_encounter_monster_id_fkey;
+
+ALTER TABLE persistent_damage
+DROP CONSTRAINT IF EXISTS chk_persistent_damage_player_or_monster,
+DROP CONSTRAINT IF EXISTS persistent_damage_encounter_monster_id_fkey;
+
+ALTER TABLE combatant_effects
+DROP CONSTRAINT IF EXISTS chk_combatant_effects_player_or_monster,
+DROP CONSTRAINT IF EXISTS chk_combatant_effects_anchor_player_or_monster,
+DROP CONSTRAINT IF EXISTS combatant_effects_encounter_monster_id_fkey,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_monster_id_fkey;
+
+UPDATE combatant_conditions
+SET encounter_monster_id = encounter_custom_combatant_id
+WHERE encounter_custom_combatant_id IS NOT NULL;
+
+UPDATE persistent_damage
+SET encounter_monster_id = encounter_custom_combatant_id
+WHERE encounter_custom_combatant_id IS NOT NULL;
+
+UPDATE combatant_effects
+SET encounter_monster_id = encounter_custom_combatant_id
+WHERE encounter_custom_combatant_id IS NOT NULL;
+
+UPDATE combatant_effects
+SET anchor_monster_id = anchor_custom_combatant_id
+WHERE anchor_custom_combatant_id IS NOT NULL;
+
+DELETE FROM encounter_history;
+
+ALTER TABLE combatant_conditions
+DROP COLUMN IF EXISTS encounter_custom_combatant_id,
+ADD CONSTRAINT chk_encounter_player_or_monster CHECK (
+    (encounter_player_id IS NOT NULL AND encounter_monster_id IS NULL) OR
+    (encounter_player_id IS NULL AND encounter_monster_id IS NOT NULL)
+);
+
+ALTER TABLE persistent_damage
+DROP COLUMN IF EXISTS encounter_custom_combatant_id,
+ADD CONSTRAINT chk_persistent_damage_player_or_monster CHECK (
+    (encounter_player_id IS NOT NULL AND encounter_monster_id IS NULL) OR
+    (encounter_player_id IS NULL AND encounter_monster_id IS NOT NULL)
+);
+
+ALTER TABLE combatant_effects
+DROP COLUMN IF EXISTS encounter_custom_combatant_id,
+DROP COLUMN IF EXISTS anchor_custom_combatant_id,
+ADD CONSTRAINT chk_combatant_effects_player_or_monster CHECK (
+    (encounter_player_id IS NOT NULL AND encounter_monster_id IS NULL) OR
+    (encounter_player_id IS NULL AND encounter_monster_id IS NOT NULL)
+),
+ADD CONSTRAINT chk_combatant_effects_anchor_player_or_monster CHECK (
+    (anchor_player_id IS NOT NULL AND anchor_monster_id IS NULL) OR
+    (anchor_player_id IS NULL AND anchor_monster_id IS NOT NULL)
+);
+
+CREATE OR REPLACE FUNCTION delete_monster_combatant_state() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM combatant_conditions WHERE encounter_monster_id = OLD.id;
+    DELETE FROM persistent_damage WHERE encounter_monster_id = OLD.id;
+    DELETE FROM combatant_effects WHERE encounter_monster_id = OLD.id OR anchor_monster_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER encounter_monsters_delete_state
+AFTER DELETE ON encounter_monsters
+FOR EACH ROW EXECUTE FUNCTION delete_monster_combatant_state();
+
+CREATE TRIGGER encounter_custom_combatants_delete_state
+AFTER DELETE ON encounter_custom_combatants
+FOR EACH ROW EXECUTE FUNCTION delete_monster_combatant_state();
@@ -0,0 +1,74 @@
+-- Custom combatants get their own columns in the conditions, persistent damage
+-- and effects tables, so foreign keys remove those rows with their combatant
+-- instead of a trigger
+ALTER TABLE combatant_conditions
+DROP CONSTRAINT IF EXISTS chk_encounter_player_or_monster,
+ADD COLUMN encounter_custom_combatant_id INTEGER REFERENCES encounter_custom_combatants(id) ON DELETE CASCADE;
+
+ALTER TABLE persistent_damage
+DROP CONSTRAINT IF EXISTS chk_persistent_damage_player_or_monster,
+ADD COLUMN encounter_custom_combatant_id INTEGER REFERENCES encounter_custom_combatants(id) ON DELETE CASCADE;
+
+ALTER TABLE combatant_effects
+DROP CONSTRAINT IF EXISTS chk_combatant_effects_player_or_monster,
+DROP CONSTRAINT IF EXISTS chk_combatant_effects_anchor_player_or_monster,
+ADD COLUMN encounter_custom_combatant_id INTEGER REFERENCES encounter_custom_combatants(id) ON DELETE CASCADE,
+ADD COLUMN anchor_custom_combatant_id INTEGER REFERENCES encounter_custom_combatants(id) ON DELETE CASCADE;
+
+-- Move the rows of custom combatants out of the monster columns
+UPDATE combatant_conditions
+SET encounter_custom_combatant_id = encounter_monster_id, encounter_monster_id = NULL
+WHERE encounter_monster_id IN (SELECT id FROM encounter_custom_combatants);
+
+UPDATE persistent_damage
+SET encounter_custom_combatant_id = encounter_monster_id, encounter_monster_id = NULL
+WHERE encounter_monster_id IN (SELECT id FROM encounter_custom_combatants);
+
+UPDATE combatant_effects
+SET encounter_custom_combatant_id = encounter_monster_id, encounter_monster_id = NULL
+WHERE encounter_monster_id IN (SELECT id FROM encounter_custom_combatants);
+
+UPDATE combatant_effects
+SET anchor_custom_combatant_id = anchor_monster_id, anchor_monster_id = NULL
+WHERE anchor_monster_id IN (SELECT id FROM encounter_custom_combatants);
+
+-- Undo history still refers to custom combatants through the monster columns
+DELETE FROM encounter_history;
+
+DROP TRIGGER IF EXISTS encounter_custom_combatants_delete_state ON encounter_custom_combatants;
+DROP TRIGGER IF EXISTS encounter_monsters_delete_state ON encounter_monsters;
+DROP FUNCTION IF EXISTS delete_monster_combatant_state();
+
+ALTER TABLE combatant_conditions
+ADD CONSTRAINT combatant_conditions_encounter_monster_id_fkey
+FOREIGN KEY (encounter_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT chk_encounter_player_or_monster CHECK (
+    num_nonnulls(encounter_player_id, encounter_monster_id, encounter_custom_combatant_id) = 1
+);
+
+ALTER TABLE persistent_damage
+ADD CONSTRAINT persistent_damage_encounter_monster_id_fkey
+FOREIGN KEY (encounter_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT chk_persistent_damage_player_or_monster CHECK (
+    num_nonnulls(encounter_player_id, encounter_monster_id, encounter_custom_combatant_id) = 1
+);
+
+ALTER TABLE combatant_effects
+ADD CONSTRAINT combatant_effects_encounter_monster_id_fkey
+FOREIGN KEY (encounter_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT combatant_effects_anchor_monster_id_fkey
+FOREIGN KEY (anchor_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT chk_combatant_effects_player_or_monster CHECK (
+    num_nonnulls(encounter_player_id, encounter_monster_id, encounter_custom_combatant_id) = 1
+),
+ADD CONSTRAINT chk_combatant_effects_anchor_player_or_monster CHECK (
+    num_nonnulls(anchor_player_id, anchor_monster_id, anchor_custom_combatant_id) = 1
+);
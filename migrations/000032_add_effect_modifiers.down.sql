@@ -0,0 +1,29 @@
+-- Effects whose anchor was removed can't be represented without one
+DELETE FROM combatant_effects
+WHERE num_nonnulls(anchor_player_id, anchor_monster_id, anchor_custom_combatant_id) = 0;
+
+ALTER TABLE combatant_effects
+DROP COLUMN IF EXISTS selector,
+DROP COLUMN IF EXISTS modifier_type,
+DROP COLUMN IF EXISTS modifier_value,
+DROP CONSTRAINT IF EXISTS chk_combatant_effects_anchor_player_or_monster,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_player_id_fkey,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_monster_id_fkey,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_custom_combatant_id_fkey;
+
+ALTER TABLE combatant_effects
+ADD CONSTRAINT combatant_effects_anchor_player_id_fkey
+FOREIGN KEY (anchor_player_id)
+REFERENCES encounter_players(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT combatant_effects_anchor_monster_id_fkey
+FOREIGN KEY (anchor_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT combatant_effects_anchor_custom_combatant_id_fkey
+FOREIGN KEY (anchor_custom_combatant_id)
+REFERENCES encounter_custom_combatants(id)
+ON DELETE CASCADE,
+ADD CONSTRAINT chk_combatant_effects_anchor_player_or_monster CHECK (
+    num_nonnulls(anchor_player_id, anchor_monster_id, anchor_custom_combatant_id) = 1
+);
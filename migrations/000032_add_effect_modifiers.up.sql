@@ -0,0 +1,28 @@
+-- Effects carry the bonus or penalty they apply, like +1 status to attack
+-- rolls for Bless, and outlive the combatant they count down on: without
+-- an anchor they count down on their target's own turn
+ALTER TABLE combatant_effects
+ADD COLUMN selector VARCHAR(50) NOT NULL DEFAULT '',
+ADD COLUMN modifier_type VARCHAR(20) NOT NULL DEFAULT 'untyped',
+ADD COLUMN modifier_value INTEGER NOT NULL DEFAULT 0,
+DROP CONSTRAINT IF EXISTS chk_combatant_effects_anchor_player_or_monster,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_player_id_fkey,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_monster_id_fkey,
+DROP CONSTRAINT IF EXISTS combatant_effects_anchor_custom_combatant_id_fkey;
+
+ALTER TABLE combatant_effects
+ADD CONSTRAINT combatant_effects_anchor_player_id_fkey
+FOREIGN KEY (anchor_player_id)
+REFERENCES encounter_players(id)
+ON DELETE SET NULL,
+ADD CONSTRAINT combatant_effects_anchor_monster_id_fkey
+FOREIGN KEY (anchor_monster_id)
+REFERENCES encounter_monsters(id)
+ON DELETE SET NULL,
+ADD CONSTRAINT combatant_effects_anchor_custom_combatant_id_fkey
+FOREIGN KEY (anchor_custom_combatant_id)
+REFERENCES encounter_custom_combatants(id)
+ON DELETE SET NULL,
+ADD CONSTRAINT chk_combatant_effects_anchor_player_or_monster CHECK (
+    num_nonnulls(anchor_player_id, anchor_monster_id, anchor_custom_combatant_id) <= 1
+);
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+func TestActionsPerTurn_Quickened(t *testing.T) {
+	player := CreateSamplePlayer()
+	if actions := models.ActionsPerTurn(&player); actions != 3 {
+		t.Errorf("expected 3 actions, got %d", actions)
+	}
+
+	player.Conditions = []models.Condition{createValuedCondition(9, "Quickened", 0)}
+	if actions := models.ActionsPerTurn(&player); actions != 4 {
+		t.Errorf("expected 4 actions while quickened, got %d", actions)
+	}
+}
+
+func TestSpendActions(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	monster.ActionsRemaining = 3
+
+	// A two action Strike, then giving one back
+	expectActions(mockDB, "encounter_monsters", 1, false, monster.AssociationID)
+	expectActions(mockDB, "encounter_monsters", 2, false, monster.AssociationID)
+
+	if err := models.SpendActions(mockDB, &monster, 2); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := models.SpendActions(mockDB, &monster, -1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if monster.GetActionsRemaining() != 2 {
+		t.Errorf("expected 2 actions left, got %d", monster.GetActionsRemaining())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSpendActions_Invalid(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.ActionsRemaining = 1
+
+	if err := models.SpendActions(mockDB, &player, 2); err == nil {
+		t.Error("expected an error when spending more actions than are left")
+	}
+	if err := models.SpendActions(mockDB, &player, -3); err == nil {
+		t.Error("expected an error when giving back more actions than the turn has")
+	}
+	if err := models.SpendActions(mockDB, &player, 0); err == nil {
+		t.Error("expected an error when spending nothing")
+	}
+}
+
+func TestUseReaction(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.ActionsRemaining = 2
+
+	expectActions(mockDB, "encounter_players", 2, true, player.AssociationID)
+
+	if err := models.UseReaction(mockDB, &player, true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !player.IsReactionUsed() {
+		t.Error("expected the reaction to be used")
+	}
+	if err := models.UseReaction(mockDB, &player, true); err == nil {
+		t.Error("expected an error when using the reaction twice")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMultipleAttackPenalty(t *testing.T) {
+	tests := []struct {
+		attacksMade int
+		agile       bool
+		expected    int
+	}{
+		{0, false, 0},
+		{1, false, -5},
+		{2, false, -10},
+		{5, false, -10},
+		{1, true, -4},
+		{3, true, -8},
+	}
+
+	for _, tt := range tests {
+		if penalty := models.MultipleAttackPenalty(tt.attacksMade, tt.agile); penalty != tt.expected {
+			t.Errorf("expected %d after %d attacks (agile %t), got %d", tt.expected, tt.attacksMade, tt.agile, penalty)
+		}
+	}
+}
+
+func TestMakeAttack(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	monster.ActionsRemaining = 3
+
+	expectActions(mockDB, "encounter_monsters", 2, false, monster.AssociationID)
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET attacks_made").
+		WithArgs(1, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := models.MakeAttack(mockDB, &monster, 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if monster.GetAttacksMade() != 1 {
+		t.Errorf("expected 1 attack, got %d", monster.GetAttacksMade())
+	}
+
+	// Not enough actions left for a 3 action activity
+	if err := models.MakeAttack(mockDB, &monster, 3); err == nil {
+		t.Error("expected an error without enough actions")
+	}
+	if monster.GetAttacksMade() != 1 {
+		t.Errorf("expected the failed attack not to count, got %d", monster.GetAttacksMade())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProcessTurnEnd_ResetsAttacks(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	monster.AttacksMade = 2
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET attacks_made").
+		WithArgs(0, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := models.ProcessTurnEnd(mockDB, TestEncounterID, &monster); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if monster.GetAttacksMade() != 0 {
+		t.Errorf("expected the attacks to reset, got %d", monster.GetAttacksMade())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
@@ -145,9 +145,11 @@ func TestEncounterCreateHandler(t *testing.T) {
 			},
 			mockSetup: func(mockDB *StandardMockDB) {
 				mockDB.SetupMockForPartyExists(1, true)
+				mockDB.Mock.ExpectBegin()
 				mockDB.Mock.ExpectQuery("INSERT INTO encounters").
 					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
 					WillReturnError(ErrNotFound)
+				mockDB.Mock.ExpectRollback()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectError:    true,
@@ -228,7 +230,7 @@ func TestEncounterEditHandler(t *testing.T) {
 			name:        "encounter not found",
 			encounterID: "999",
 			mockSetup: func(mockDB *StandardMockDB) {
-				mockDB.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn, e\.round, u\.name AS user_name, p\.name AS party_name FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
+				mockDB.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn_association_id, e\.turn_is_monster, e\.round, u\.name AS user_name, p\.name AS party_name, e\.status, e\.started_at, e\.finished_at FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
 					WithArgs(1, 999).
 					WillReturnError(ErrNotFound)
 			},
@@ -517,7 +519,7 @@ func TestEncounterListHandler(t *testing.T) {
 		{
 			name: "database error when fetching encounters",
 			mockSetup: func(mockDB *StandardMockDB) {
-				mockDB.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, u\.name AS user_name, p\.name AS party_name FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 ORDER BY e\.id`).
+				mockDB.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, u\.name AS user_name, p\.name AS party_name, e\.round, e\.status FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 ORDER BY e\.id`).
 					WithArgs(1).
 					WillReturnError(ErrNotFound)
 			},
@@ -593,7 +595,7 @@ func TestEncounterShowHandler(t *testing.T) {
 			name:        "encounter not found",
 			encounterID: "999",
 			mockSetup: func(mockDB *StandardMockDB) {
-				mockDB.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn, e\.round, u\.name AS user_name, p\.name AS party_name FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
+				mockDB.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn_association_id, e\.turn_is_monster, e\.round, u\.name AS user_name, p\.name AS party_name, e\.status, e\.started_at, e\.finished_at FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
 					WithArgs(1, 999).
 					WillReturnError(ErrNotFound)
 			},
@@ -917,6 +919,19 @@ func TestEncounterAddMonster(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectError:    true,
 		},
+		{
+			name:        "too many copies",
+			encounterID: "1",
+			monsterID:   "1",
+			formData: url.Values{
+				"level_adjustment": {"0"},
+				"quantity":         {"1000000000"},
+			},
+			// Rejected before the monster is looked up or initiative is rolled
+			mockSetup:      func(mockDB *StandardMockDB) {},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1042,3 +1057,70 @@ func TestEncounterHandlersIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestEncounterAddCustomCombatant_InvalidQuantity(t *testing.T) {
+	for _, quantity := range []string{"0", "21", "1000000000"} {
+		t.Run(quantity, func(t *testing.T) {
+			mockDB, cleanup := NewStandardMockDB(t)
+			defer cleanup()
+
+			formData := url.Values{"name": {"City Guard"}, "hp": {"30"}, "quantity": {quantity}}
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/encounters/1/add_custom", strings.NewReader(formData.Encode()))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("encounter_id")
+			c.SetParamValues("1")
+
+			// Rejected before anything is rolled or written
+			if err := encounter.EncounterAddCustomCombatant(mockDB)(c); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+			}
+
+			if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestEncounterGenerateSave_InvalidForm(t *testing.T) {
+	tests := map[string]url.Values{
+		"no creatures":           {"name": {"Ambush"}, "party_id": {"1"}},
+		"invalid monster ID":     {"name": {"Ambush"}, "party_id": {"1"}, "monster_id[]": {"troll"}, "level_adjustment[]": {"0"}, "quantity[]": {"1"}},
+		"invalid adjustment":     {"name": {"Ambush"}, "party_id": {"1"}, "monster_id[]": {"2"}, "level_adjustment[]": {"5"}, "quantity[]": {"1"}},
+		"invalid quantity":       {"name": {"Ambush"}, "party_id": {"1"}, "monster_id[]": {"2"}, "level_adjustment[]": {"0"}, "quantity[]": {"many"}},
+		"too many copies":        {"name": {"Ambush"}, "party_id": {"1"}, "monster_id[]": {"2"}, "level_adjustment[]": {"0"}, "quantity[]": {"1000000000"}},
+		"mismatched form fields": {"name": {"Ambush"}, "party_id": {"1"}, "monster_id[]": {"2", "3"}, "level_adjustment[]": {"0"}, "quantity[]": {"1"}},
+	}
+
+	for name, formData := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockDB, cleanup := NewStandardMockDB(t)
+			defer cleanup()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/encounters/generate", strings.NewReader(formData.Encode()))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Rejected before any monster is looked up or anything is saved
+			if err := encounter.EncounterGenerateSave(mockDB)(c); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+			}
+
+			if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
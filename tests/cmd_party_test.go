@@ -202,6 +202,7 @@ func TestPartyEditHandler(t *testing.T) {
 			mockSetup: func(mockDB *StandardMockDB) {
 				party := CreateSampleParty()
 				mockDB.SetupMockForGetParty(party)
+				expectPartyProgress(mockDB, party.ID, 1040)
 			},
 			expectedStatus: http.StatusOK,
 			expectError:    false,
@@ -296,8 +297,8 @@ func TestPartyUpdateHandler(t *testing.T) {
 
 				// Mock player updates for existing players
 				for _, player := range party.Players {
-					mockDB.Mock.ExpectExec(`UPDATE players SET name = \$1, level = \$2, ac = \$3, hp = \$4, fort = \$5, ref = \$6, will = \$7, perception = \$8 WHERE id = \$9 AND party_id = \$10`).
-						WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), player.ID, party.ID).
+					mockDB.Mock.ExpectExec(`UPDATE players SET name = \$1, level = \$2, ac = \$3, hp = \$4, fort = \$5, ref = \$6, will = \$7, perception = \$8, skills = \$9 WHERE id = \$10 AND party_id = \$11`).
+						WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), player.ID, party.ID).
 						WillReturnResult(sqlmock.NewResult(1, 1))
 				}
 
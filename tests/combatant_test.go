@@ -119,7 +119,7 @@ func TestPlayer_SetHp_Success(t *testing.T) {
 	expectedNewHp := player.Hp - damage
 
 	mockDB.Mock.ExpectExec("UPDATE encounter_players").
-		WithArgs(expectedNewHp, player.ID, player.AssociationID).
+		WithArgs(expectedNewHp, player.AssociationID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err := player.SetHp(mockDB, damage)
@@ -136,6 +136,53 @@ func TestPlayer_SetHp_Success(t *testing.T) {
 	}
 }
 
+func TestPlayer_SetHp_HealingStopsAtMax(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.MaxHp = 50
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players").
+		WithArgs(50, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := player.SetHp(mockDB, -20); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if player.GetHp() != 50 {
+		t.Errorf("expected healing to stop at 50 HP, got %d", player.GetHp())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestIsBloodied(t *testing.T) {
+	player := CreateSamplePlayer()
+	player.MaxHp = 50
+
+	tests := []struct {
+		hp       int
+		percent  int
+		bloodied bool
+	}{
+		{hp: 50, percent: 100, bloodied: false},
+		{hp: 26, percent: 52, bloodied: false},
+		{hp: 25, percent: 50, bloodied: true},
+		{hp: 0, percent: 0, bloodied: false},
+	}
+
+	for _, tt := range tests {
+		player.Hp = tt.hp
+		if models.HpPercent(&player) != tt.percent || models.IsBloodied(&player) != tt.bloodied {
+			t.Errorf("at %d HP expected %d%% bloodied %t, got %d%% bloodied %t", tt.hp, tt.percent, tt.bloodied, models.HpPercent(&player), models.IsBloodied(&player))
+		}
+	}
+}
+
 func TestPlayer_GetAc(t *testing.T) {
 	player := CreateSamplePlayer()
 	ac := player.GetAc()
@@ -487,6 +534,95 @@ func TestMonster_SetHp_Success(t *testing.T) {
 	}
 }
 
+func TestMonster_SetHp_DrainsTempHpFirst(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	monster.Data.System.Attributes.Hp.Temp = 4
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET temp_hp").
+		WithArgs(0, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET hp").
+		WithArgs(29, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := monster.SetHp(mockDB, 10)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if monster.GetTempHp() != 0 {
+		t.Errorf("expected temp HP 0, got %d", monster.GetTempHp())
+	}
+	if monster.GetHp() != 29 {
+		t.Errorf("expected HP 29, got %d", monster.GetHp())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPlayer_SetHp_TempHpAbsorbsAllDamage(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.TempHp = 10
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET temp_hp").
+		WithArgs(4, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET hp").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := player.SetHp(mockDB, 6)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if player.GetTempHp() != 4 {
+		t.Errorf("expected temp HP 4, got %d", player.GetTempHp())
+	}
+	if player.GetHp() != 45 {
+		t.Errorf("expected HP 45, got %d", player.GetHp())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCombatant_GainTempHp_KeepsHigherValue(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET temp_hp").
+		WithArgs(8, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := monster.GainTempHp(mockDB, 8); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	// A lower value doesn't replace the current temporary hit points
+	if err := monster.GainTempHp(mockDB, 5); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if monster.GetTempHp() != 8 {
+		t.Errorf("expected temp HP 8, got %d", monster.GetTempHp())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
 func TestGetMonster_Success(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
@@ -859,6 +995,7 @@ func TestCombatant_InterfaceMethods(t *testing.T) {
 			_ = combatant.GetInitiative()
 			_ = combatant.GetHp()
 			_ = combatant.GetMaxHp()
+			_ = combatant.GetTempHp()
 			_ = combatant.GetAc()
 			_ = combatant.GetAcDetails()
 			_ = combatant.GetType()
@@ -882,6 +1019,7 @@ func TestCombatant_InterfaceMethods(t *testing.T) {
 			_ = combatant.GetImmunities()
 			_ = combatant.GetResistances()
 			_ = combatant.GetWeaknesses()
+			_ = combatant.GetDamageDefenses()
 			_ = combatant.GetSpeed()
 			_ = combatant.GetOtherSpeeds()
 			_ = combatant.GetAttacks()
@@ -0,0 +1,253 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+const (
+	TestGrabbedConditionID     = 20
+	TestOffGuardConditionID    = 21
+	TestImmobilizedConditionID = 22
+	TestHostileConditionID     = 23
+	TestFriendlyConditionID    = 24
+)
+
+// createLinkedCondition creates an unvalued condition that grants the named
+// conditions and overrides the given slugs, like the seeded condition data
+func createLinkedCondition(id int, name string, grants []string, overrides ...string) models.Condition {
+	condition := createValuedCondition(id, name, 0)
+	condition.Data.System.Value.IsValued = false
+
+	for _, granted := range grants {
+		condition.Data.System.Rules = append(condition.Data.System.Rules, map[string]any{
+			"key":  "GrantItem",
+			"uuid": "Compendium.pf2e.conditionitems.Item." + granted,
+		})
+	}
+	for _, slug := range overrides {
+		condition.Data.System.Overrides = append(condition.Data.System.Overrides, slug)
+	}
+
+	return condition
+}
+
+func grabbed() models.Condition {
+	return createLinkedCondition(TestGrabbedConditionID, "Grabbed", []string{"Off-Guard", "Immobilized"})
+}
+
+func hostile() models.Condition {
+	return createLinkedCondition(TestHostileConditionID, "Hostile", nil, "helpful", "friendly", "indifferent", "unfriendly")
+}
+
+func friendly() models.Condition {
+	return createLinkedCondition(TestFriendlyConditionID, "Friendly", nil, "helpful", "indifferent", "unfriendly", "hostile")
+}
+
+func expectConditionByID(mockDB *StandardMockDB, condition models.Condition) {
+	jsonData, _ := json.Marshal(condition.Data)
+	mockDB.Mock.ExpectQuery(`SELECT id, data FROM conditions p WHERE id = \$1`).
+		WithArgs(condition.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data"}).AddRow(condition.ID, jsonData))
+}
+
+func expectConditionByName(mockDB *StandardMockDB, condition models.Condition) {
+	jsonData, _ := json.Marshal(condition.Data)
+	mockDB.Mock.ExpectQuery(`SELECT id, data FROM conditions WHERE name = \$1`).
+		WithArgs(condition.Data.Name).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data"}).AddRow(condition.ID, jsonData))
+}
+
+func TestCondition_GetGrantedConditions(t *testing.T) {
+	granted := grabbed().GetGrantedConditions()
+
+	if len(granted) != 2 || granted[0] != "Off-Guard" || granted[1] != "Immobilized" {
+		t.Errorf("expected Off-Guard and Immobilized, got %v", granted)
+	}
+}
+
+func TestCondition_IsOverridden(t *testing.T) {
+	blinded := createLinkedCondition(30, "Blinded", nil, "dazzled")
+	dazzled := createRuledCondition(31, "Dazzled", 0, map[string]any{
+		"key": "FlatModifier", "selector": "perception", "type": "status", "value": float64(-1),
+	})
+	conditions := []models.Condition{blinded, dazzled}
+
+	if !dazzled.IsOverridden(conditions) {
+		t.Error("expected dazzled to be overridden by blinded")
+	}
+	if blinded.IsOverridden(conditions) {
+		t.Error("expected blinded not to be overridden")
+	}
+
+	if modifier := models.ConditionModifier(conditions, nil, []string{"perception"}); modifier != 0 {
+		t.Errorf("expected overridden dazzled to have no effect, got %d", modifier)
+	}
+}
+
+func TestMonster_SetCondition_AddsGrantedConditions(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	offGuard := createLinkedCondition(TestOffGuardConditionID, "Off-Guard", nil)
+	immobilized := createLinkedCondition(TestImmobilizedConditionID, "Immobilized", nil)
+
+	expectConditionByID(mockDB, grabbed())
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, monster.AssociationID, TestGrabbedConditionID, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectConditionByName(mockDB, offGuard)
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, monster.AssociationID, TestOffGuardConditionID, 0, TestGrabbedConditionID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectConditionByName(mockDB, immobilized)
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, monster.AssociationID, TestImmobilizedConditionID, 0, TestGrabbedConditionID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := monster.SetCondition(mockDB, TestEncounterID, TestGrabbedConditionID, 0); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(monster.Conditions) != 3 {
+		t.Fatalf("expected 3 conditions, got %d", len(monster.Conditions))
+	}
+	if !monster.IsOffGuard() {
+		t.Error("expected grabbed monster to be off-guard")
+	}
+	if monster.Conditions[2].GrantedBy != TestGrabbedConditionID {
+		t.Errorf("expected immobilized to be granted by grabbed, got %d", monster.Conditions[2].GrantedBy)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMonster_SetCondition_KeepsExistingCondition(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	monster.Conditions = []models.Condition{createLinkedCondition(TestOffGuardConditionID, "Off-Guard", nil)}
+	immobilized := createLinkedCondition(TestImmobilizedConditionID, "Immobilized", nil)
+
+	expectConditionByID(mockDB, grabbed())
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, monster.AssociationID, TestGrabbedConditionID, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectConditionByName(mockDB, immobilized)
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, monster.AssociationID, TestImmobilizedConditionID, 0, TestGrabbedConditionID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := monster.SetCondition(mockDB, TestEncounterID, TestGrabbedConditionID, 0); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if monster.Conditions[0].GrantedBy != 0 {
+		t.Error("expected the manually added off-guard to stay independent of grabbed")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMonster_RemoveCondition_RemovesGrantedConditions(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	offGuard := createLinkedCondition(TestOffGuardConditionID, "Off-Guard", nil)
+	offGuard.GrantedBy = TestGrabbedConditionID
+	immobilized := createLinkedCondition(TestImmobilizedConditionID, "Immobilized", nil)
+	immobilized.GrantedBy = TestGrabbedConditionID
+
+	monster := CreateSampleMonster()
+	monster.Conditions = []models.Condition{grabbed(), offGuard, immobilized}
+
+	for _, id := range []int{TestGrabbedConditionID, TestOffGuardConditionID, TestImmobilizedConditionID} {
+		mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+			WithArgs(TestEncounterID, monster.AssociationID, id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	if err := monster.RemoveCondition(mockDB, TestEncounterID, TestGrabbedConditionID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(monster.Conditions) != 0 {
+		t.Errorf("expected all conditions to be removed, got %d", len(monster.Conditions))
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMonster_RemoveCondition_RegrantsSharedCondition(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	prone := createLinkedCondition(25, "Prone", []string{"Off-Guard"})
+	offGuard := createLinkedCondition(TestOffGuardConditionID, "Off-Guard", nil)
+	offGuard.GrantedBy = TestGrabbedConditionID
+	immobilized := createLinkedCondition(TestImmobilizedConditionID, "Immobilized", nil)
+	immobilized.GrantedBy = TestGrabbedConditionID
+
+	monster := CreateSampleMonster()
+	monster.Conditions = []models.Condition{grabbed(), prone, offGuard, immobilized}
+
+	for _, id := range []int{TestGrabbedConditionID, TestOffGuardConditionID, TestImmobilizedConditionID} {
+		mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+			WithArgs(TestEncounterID, monster.AssociationID, id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, monster.AssociationID, TestOffGuardConditionID, 0, 25).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := monster.RemoveCondition(mockDB, TestEncounterID, TestGrabbedConditionID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !monster.IsOffGuard() {
+		t.Error("expected prone monster to stay off-guard")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPlayer_SetCondition_ReplacesExclusiveCondition(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.Conditions = []models.Condition{hostile()}
+
+	expectConditionByID(mockDB, friendly())
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, TestFriendlyConditionID, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, TestHostileConditionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := player.SetCondition(mockDB, TestEncounterID, TestFriendlyConditionID, 0); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(player.Conditions) != 1 || player.Conditions[0].ID != TestFriendlyConditionID {
+		t.Errorf("expected friendly to replace hostile, got %v", player.Conditions)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createSampleCustomCombatant creates a city guard made up by the GM
+func createSampleCustomCombatant() models.CustomCombatant {
+	return models.CustomCombatant{
+		AssociationID: 400,
+		EncounterID:   TestEncounterID,
+		Name:          "City Guard",
+		Level:         3,
+		MaxHp:         30,
+		Hp:            30,
+		Ac:            18,
+		Fort:          9,
+		Ref:           7,
+		Will:          6,
+		Perception:    9,
+		Attacks:       "Melee halberd +11, Damage 1d10+5 piercing",
+		Initiative:    14,
+	}
+}
+
+func TestAddCustomCombatantsToEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	custom := models.CustomCombatant{Name: " City Guard ", Level: 3, MaxHp: 30, Ac: 18, Fort: 9, Ref: 7, Will: 6, Perception: 9}
+	roll := models.InitiativeRoll{Statistic: models.InitiativePerception, Die: 5, Total: 14}
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectQuery("SELECT COALESCE\\(MAX\\(enumeration\\), 0\\) FROM encounter_custom_combatants").
+		WithArgs(TestEncounterID, "City Guard").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(1))
+	mockDB.Mock.ExpectQuery("SELECT COALESCE\\(MAX\\(initiative_group\\), 0\\) \\+ 1").
+		WithArgs(TestEncounterID).
+		WillReturnRows(sqlmock.NewRows([]string{"group"}).AddRow(2))
+	// The copies are numbered after the guard already in the encounter and
+	// share one initiative roll
+	for i := range 3 {
+		mockDB.Mock.ExpectExec("INSERT INTO encounter_custom_combatants").
+			WithArgs(TestEncounterID, "City Guard", 3, 30, 30, 18, 9, 7, 6, 9, "", i+2, 14, models.InitiativePerception, 5, 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mockDB.Mock.ExpectCommit()
+
+	initiatives := []models.InitiativeRoll{roll, roll, roll}
+	if err := models.AddCustomCombatantsToEncounter(mockDB, TestEncounterID, custom, initiatives, true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddCustomCombatantsToEncounter_Invalid(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	roll := []models.InitiativeRoll{{Statistic: models.InitiativePerception, Total: 10}}
+	tests := map[string]struct {
+		custom      models.CustomCombatant
+		initiatives []models.InitiativeRoll
+	}{
+		"no name":   {models.CustomCombatant{MaxHp: 30}, roll},
+		"no hp":     {models.CustomCombatant{Name: "City Guard"}, roll},
+		"no copies": {models.CustomCombatant{Name: "City Guard", MaxHp: 30}, nil},
+	}
+
+	for name, tt := range tests {
+		if err := models.AddCustomCombatantsToEncounter(mockDB, TestEncounterID, tt.custom, tt.initiatives, false); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCustomCombatant_SetHp(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	custom := createSampleCustomCombatant()
+	custom.TempHp = 5
+
+	// Temporary HP absorb the damage first and HP don't drop below 0
+	mockDB.Mock.ExpectExec("UPDATE encounter_custom_combatants SET temp_hp = \\$1").
+		WithArgs(0, custom.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("UPDATE encounter_custom_combatants SET hp = \\$1").
+		WithArgs(0, custom.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := custom.SetHp(mockDB, 40); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Healing stops at the maximum
+	mockDB.Mock.ExpectExec("UPDATE encounter_custom_combatants SET hp = \\$1").
+		WithArgs(30, custom.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := custom.SetHp(mockDB, -50); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if custom.GetHp() != 30 || custom.GetTempHp() != 0 {
+		t.Errorf("expected 30 HP and no temporary HP, got %d and %d", custom.GetHp(), custom.GetTempHp())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCustomCombatant_SetCondition(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	custom := createSampleCustomCombatant()
+	condition := frightened(0)
+
+	// Custom combatants use the monster column of combatant_conditions
+	expectConditionByID(mockDB, condition)
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions \\(encounter_id, encounter_custom_combatant_id, condition_id, condition_value\\)").
+		WithArgs(TestEncounterID, custom.AssociationID, condition.ID, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := custom.SetCondition(mockDB, TestEncounterID, condition.ID, 2); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if custom.GetAc() != 16 || custom.GetPerceptionMod() != 7 {
+		t.Errorf("expected frightened 2 to lower AC and Perception, got AC %d and Perception %d", custom.GetAc(), custom.GetPerceptionMod())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetDifficulty_CountsCustomCombatants(t *testing.T) {
+	player := CreateSamplePlayer()
+	player.Level = 3
+	monster := CreateSampleMonster()
+	custom := createSampleCustomCombatant()
+
+	encounter := CreateSampleEncounter()
+	encounter.Players = []*models.Player{&player, &player, &player, &player}
+	encounter.Combatants = []models.Combatant{&monster, &monster}
+	twoMonsters := encounter.GetDifficulty()
+
+	// A custom combatant is worth as much as a monster of the same level
+	encounter.Combatants = []models.Combatant{&monster, &custom}
+	if difficulty := encounter.GetDifficulty(); difficulty != twoMonsters || difficulty == 0 {
+		t.Errorf("expected difficulty %d, got %d", twoMonsters, difficulty)
+	}
+}
+
+func TestRemoveCustomCombatantFromEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_custom_combatants WHERE id = \\$1 AND encounter_id = \\$2").
+		WithArgs(400, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := models.RemoveCustomCombatantFromEncounter(mockDB, TestEncounterID, 400); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
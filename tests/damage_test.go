@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"pf2.encounterbrew.com/internal/models"
+)
+
+func TestParseDamage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []models.DamageInstance
+		wantErr  bool
+	}{
+		{
+			name:  "single typed damage",
+			input: "8 slashing",
+			expected: []models.DamageInstance{
+				{Amount: 8, Type: "slashing", Tags: []string{"slashing"}},
+			},
+		},
+		{
+			name:  "multiple types with traits",
+			input: "8 Slashing cold-iron + 4 fire",
+			expected: []models.DamageInstance{
+				{Amount: 8, Type: "slashing", Tags: []string{"slashing", "cold-iron"}},
+				{Amount: 4, Type: "fire", Tags: []string{"fire"}},
+			},
+		},
+		{
+			name:  "untyped damage",
+			input: "12",
+			expected: []models.DamageInstance{
+				{Amount: 12, Tags: []string{}},
+			},
+		},
+		{
+			name:    "missing amount",
+			input:   "slashing",
+			wantErr: true,
+		},
+		{
+			name:    "empty input",
+			input:   " + ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := models.ParseDamage(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got nil", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(instances, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, instances)
+			}
+		})
+	}
+}
+
+func TestApplyDamageDefenses(t *testing.T) {
+	defenses := models.DamageDefenses{
+		Immunities: []string{"poison"},
+		Resistances: []models.DamageModifier{
+			{Type: "slashing", Value: 3},
+			{Type: "physical", Value: 5, Exceptions: []string{"silver"}},
+		},
+		Weaknesses: []models.DamageModifier{
+			{Type: "fire", Value: 5},
+			{Type: "cold-iron", Value: 2},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		input             string
+		expectedTotal     int
+		expectedBreakdown []string
+	}{
+		{
+			name:              "weakness and highest resistance",
+			input:             "8 slashing + 4 fire",
+			expectedTotal:     3 + 9,
+			expectedBreakdown: []string{"resist physical 5 applied", "weak fire 5 applied"},
+		},
+		{
+			name:              "resistance exception",
+			input:             "8 slashing silver",
+			expectedTotal:     5,
+			expectedBreakdown: []string{"resist slashing 3 applied"},
+		},
+		{
+			name:              "immunity",
+			input:             "6 poison + 2 piercing",
+			expectedTotal:     0,
+			expectedBreakdown: []string{"immune to poison, 6 poison ignored", "resist physical 5 applied"},
+		},
+		{
+			name:              "weakness to a material",
+			input:             "6 piercing cold-iron",
+			expectedTotal:     3,
+			expectedBreakdown: []string{"weak cold-iron 2 applied", "resist physical 5 applied"},
+		},
+		{
+			name:          "untyped damage",
+			input:         "10",
+			expectedTotal: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := models.ParseDamage(tt.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			result := models.ApplyDamageDefenses(instances, defenses)
+
+			if result.Total != tt.expectedTotal {
+				t.Errorf("expected total %d, got %d", tt.expectedTotal, result.Total)
+			}
+
+			if len(result.Breakdown) != len(tt.expectedBreakdown) {
+				t.Fatalf("expected breakdown %v, got %v", tt.expectedBreakdown, result.Breakdown)
+			}
+
+			for i, entry := range tt.expectedBreakdown {
+				if result.Breakdown[i] != entry {
+					t.Errorf("expected breakdown entry %q, got %q", entry, result.Breakdown[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyDamageDefenses_DoubleResistance(t *testing.T) {
+	defenses := models.DamageDefenses{
+		Resistances: []models.DamageModifier{
+			{Type: "physical", Value: 5, DoubleVs: []string{"non-magical"}},
+		},
+	}
+
+	instances, _ := models.ParseDamage("12 bludgeoning non-magical")
+	result := models.ApplyDamageDefenses(instances, defenses)
+
+	if result.Total != 2 {
+		t.Errorf("expected total 2, got %d", result.Total)
+	}
+}
+
+func TestApplyDamageDefenses_Hardness(t *testing.T) {
+	defenses := models.DamageDefenses{Hardness: 10}
+
+	instances, _ := models.ParseDamage("8 slashing + 6 fire")
+	result := models.ApplyDamageDefenses(instances, defenses)
+
+	// Hardness applies once to the whole hit, not to each damage type
+	if result.Total != 4 {
+		t.Errorf("expected total 4, got %d", result.Total)
+	}
+
+	instances, _ = models.ParseDamage("7 slashing")
+	if result := models.ApplyDamageDefenses(instances, defenses); result.Total != 0 {
+		t.Errorf("expected hardness to absorb the hit, got %d", result.Total)
+	}
+}
+
+func TestDamageResult_Summary(t *testing.T) {
+	result := models.DamageResult{
+		Total:     12,
+		Breakdown: []string{"weak fire 5 applied", "resist slashing 3 applied"},
+	}
+
+	summary := result.Summary("Goblin Warrior")
+
+	if !strings.HasPrefix(summary, "Goblin Warrior takes 12 damage") {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+	if !strings.Contains(summary, "weak fire 5 applied, resist slashing 3 applied") {
+		t.Errorf("expected breakdown in summary, got: %s", summary)
+	}
+}
+
+func TestMonster_GetDamageDefenses(t *testing.T) {
+	monster := CreateSampleMonster()
+	monster.Data.System.Attributes.Immunities = append(monster.Data.System.Attributes.Immunities, struct {
+		Type string `json:"type"`
+	}{Type: "fire"})
+
+	defenses := monster.GetDamageDefenses()
+	if len(defenses.Immunities) != 1 || defenses.Immunities[0] != "fire" {
+		t.Errorf("expected fire immunity, got %v", defenses.Immunities)
+	}
+
+	player := CreateSamplePlayer()
+	if len(player.GetDamageDefenses().Immunities) != 0 {
+		t.Error("expected players to have no immunities")
+	}
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createDelayEncounter creates an encounter in round 1 where the player
+// (initiative 20) acts before the monster (initiative 15)
+func createDelayEncounter() (models.Encounter, *models.Player, *models.Monster) {
+	player := CreateSamplePlayer()
+	player.Initiative = 20
+	monster := CreateSampleMonster()
+
+	encounter := CreateSampleEncounter()
+	encounter.Combatants = []models.Combatant{&player, &monster}
+
+	return encounter, &player, &monster
+}
+
+func expectTurnAndRound(mockDB *StandardMockDB, active models.Combatant, round int) {
+	mockDB.Mock.ExpectExec("UPDATE encounters SET turn_association_id").
+		WithArgs(active.GetAssociationID(), active.IsMonster(), round, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectDelayState(mockDB *StandardMockDB, table string, state string, associationID int) {
+	mockDB.Mock.ExpectExec("UPDATE "+table+" SET delay_state").
+		WithArgs(state, associationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectInitiativeOrder(mockDB *StandardMockDB, table string, order int, associationID int) {
+	mockDB.Mock.ExpectExec("UPDATE "+table+" SET initiative_order").
+		WithArgs(order, associationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestDelay_MovesOnToTheNextCombatant(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter, player, monster := createDelayEncounter()
+	encounter.Round = 1
+	player.Conditions = []models.Condition{createValuedCondition(5, "Frightened", 1)}
+
+	// Delaying doesn't avoid the end of turn rules
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDelayState(mockDB, "encounter_players", models.DelayStateDelaying, player.AssociationID)
+	expectTurnAndRound(mockDB, monster, 1)
+	expectActions(mockDB, "encounter_monsters", 3, false, monster.AssociationID)
+
+	changes, err := models.Delay(mockDB, &encounter)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	summary := strings.Join(changes, "\n")
+	if !strings.Contains(summary, "Test Player delays") || !strings.Contains(summary, "no longer frightened") {
+		t.Errorf("expected the delay and the end of turn changes, got %v", changes)
+	}
+	if encounter.Turn != 1 || !models.IsDelaying(player) {
+		t.Errorf("expected the monster's turn with the player delaying, got turn %d", encounter.Turn)
+	}
+
+	if _, err := models.Delay(mockDB, &models.Encounter{}); err == nil {
+		t.Error("expected an error without combatants")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReturnFromDelay_ReordersInitiative(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter, player, monster := createDelayEncounter()
+	encounter.Turn = 1
+	encounter.Round = 1
+	player.DelayState = models.DelayStateDelaying
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET initiative").
+		WithArgs(monster.Initiative, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDelayState(mockDB, "encounter_players", models.DelayStateReturned, player.AssociationID)
+	// The player ties with the monster and is ordered right after them
+	expectInitiativeOrder(mockDB, "encounter_monsters", 1, monster.AssociationID)
+	expectInitiativeOrder(mockDB, "encounter_players", 2, player.AssociationID)
+	expectTurnAndRound(mockDB, player, 1)
+
+	changes, err := models.ReturnFromDelay(mockDB, &encounter, player)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(strings.Join(changes, "\n"), "returns to the initiative order after Test Monster 1 with initiative 15") {
+		t.Errorf("expected a return message, got %v", changes)
+	}
+	if encounter.Combatants[1] != models.Combatant(player) || encounter.Turn != 1 {
+		t.Errorf("expected the player to act right after the monster, got turn %d", encounter.Turn)
+	}
+
+	// The returned combatant already went through the end of turn rules
+	expectDelayState(mockDB, "encounter_players", "", player.AssociationID)
+	expectTurnAndRound(mockDB, monster, 2)
+	expectActions(mockDB, "encounter_monsters", 3, false, monster.AssociationID)
+
+	if _, err := models.NextTurn(mockDB, &encounter); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if player.GetDelayState() != "" {
+		t.Errorf("expected the delay state to be cleared, got %q", player.GetDelayState())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReturnFromDelay_NotDelaying(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter, player, _ := createDelayEncounter()
+
+	if _, err := models.ReturnFromDelay(mockDB, &encounter, player); err == nil {
+		t.Error("expected an error for a combatant that isn't delaying")
+	}
+}
+
+func TestNextTurn_DelayingAWholeRound(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter, player, _ := createDelayEncounter()
+	encounter.Turn = 1
+	encounter.Round = 1
+	player.DelayState = models.DelayStateDelaying
+
+	expectTurnAndRound(mockDB, player, 2)
+	expectDelayState(mockDB, "encounter_players", "", player.AssociationID)
+	expectActions(mockDB, "encounter_players", 3, false, player.AssociationID)
+
+	changes, err := models.NextTurn(mockDB, &encounter)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(strings.Join(changes, "\n"), "Test Player delayed a whole round") {
+		t.Errorf("expected the lost turn to be reported, got %v", changes)
+	}
+	if models.IsDelaying(player) {
+		t.Error("expected the player to be back in the initiative order")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReadyAction(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+
+	mockDB.Mock.ExpectQuery("INSERT INTO combatant_effects").
+		WithArgs(TestEncounterID, player.AssociationID, "Readied: Strike when the door opens", 1, models.EffectAnchorStart, player.AssociationID, "", "untyped", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	if err := models.ReadyAction(mockDB, TestEncounterID, &player, "Strike when the door opens"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := models.ReadyAction(mockDB, TestEncounterID, &player, " "); err == nil {
+		t.Error("expected an error without an action")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+const (
+	TestDyingConditionID   = 7
+	TestWoundedConditionID = 8
+	TestDoomedConditionID  = 9
+)
+
+// expectConditionLookup mocks loading a condition by name and then by ID,
+// as happens when a condition is added to a combatant for the first time
+func expectConditionLookup(mockDB *StandardMockDB, id int, name string) {
+	jsonData, _ := json.Marshal(createValuedCondition(id, name, 0).Data)
+
+	mockDB.Mock.ExpectQuery(`SELECT id, data FROM conditions WHERE name = \$1`).
+		WithArgs(name).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data"}).AddRow(id, jsonData))
+	mockDB.Mock.ExpectQuery(`SELECT id, data FROM conditions p WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data"}).AddRow(id, jsonData))
+}
+
+func TestPlayer_SetHp_KnockedOutAppliesDyingPlusWounded(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.EncounterID = TestEncounterID
+	player.Conditions = []models.Condition{createValuedCondition(TestWoundedConditionID, "Wounded", 1)}
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET hp").
+		WithArgs(0, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectConditionLookup(mockDB, TestDyingConditionID, "Dying")
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, TestDyingConditionID, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := player.SetHp(mockDB, 50); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if player.GetHp() != 0 {
+		t.Errorf("expected HP to stop at 0, got %d", player.GetHp())
+	}
+	if models.GetDyingValue(&player) != 2 {
+		t.Errorf("expected dying 2, got %d", models.GetDyingValue(&player))
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPlayer_SetHpCritical_KnockedOutAppliesDyingTwo(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.EncounterID = TestEncounterID
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET hp").
+		WithArgs(0, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectConditionLookup(mockDB, TestDyingConditionID, "Dying")
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, TestDyingConditionID, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := player.SetHpCritical(mockDB, 45); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPlayer_SetHp_DamageWhileDyingIncreasesDying(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.Hp = 0
+	player.EncounterID = TestEncounterID
+	player.Conditions = []models.Condition{
+		createValuedCondition(TestDyingConditionID, "Dying", 1),
+		createValuedCondition(TestWoundedConditionID, "Wounded", 2),
+	}
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET hp").
+		WithArgs(0, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("UPDATE combatant_conditions").
+		WithArgs(2, TestEncounterID, player.AssociationID, TestDyingConditionID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := player.SetHp(mockDB, 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if models.GetDyingValue(&player) != 2 {
+		t.Errorf("expected wounded to be ignored while already dying, got dying %d", models.GetDyingValue(&player))
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPlayer_SetHp_HealingRemovesDyingAndAddsWounded(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.Hp = 0
+	player.EncounterID = TestEncounterID
+	player.Conditions = []models.Condition{createValuedCondition(TestDyingConditionID, "Dying", 2)}
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET hp").
+		WithArgs(5, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, TestDyingConditionID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectConditionLookup(mockDB, TestWoundedConditionID, "Wounded")
+	mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, TestWoundedConditionID, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := player.SetHp(mockDB, -5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if models.GetDyingValue(&player) != 0 {
+		t.Errorf("expected dying to be removed, got %d", models.GetDyingValue(&player))
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeathThreshold_ReducedByDoomed(t *testing.T) {
+	player := CreateSamplePlayer()
+	player.Conditions = []models.Condition{
+		createValuedCondition(TestDyingConditionID, "Dying", 2),
+		createValuedCondition(TestDoomedConditionID, "Doomed", 1),
+	}
+
+	if models.DeathThreshold(&player) != 3 {
+		t.Errorf("expected death threshold 3, got %d", models.DeathThreshold(&player))
+	}
+	if models.RecoveryCheckDC(&player) != 12 {
+		t.Errorf("expected recovery check DC 12, got %d", models.RecoveryCheckDC(&player))
+	}
+	if !models.IsDying(&player) || models.IsDead(&player) {
+		t.Error("expected player to be dying but alive")
+	}
+}
+
+func TestApplyRecoveryCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcome  string
+		dying    int
+		doomed   int
+		expected string
+		setup    func(mockDB *StandardMockDB, player *models.Player)
+	}{
+		{
+			name:     "success reduces dying",
+			outcome:  "success",
+			dying:    2,
+			expected: "is dying 1 and dies at dying 4",
+			setup: func(mockDB *StandardMockDB, player *models.Player) {
+				mockDB.Mock.ExpectExec("UPDATE combatant_conditions").
+					WithArgs(1, TestEncounterID, player.AssociationID, TestDyingConditionID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:     "failure reaching the doomed threshold kills",
+			outcome:  "failure",
+			dying:    2,
+			doomed:   1,
+			expected: "has died (dying 3)",
+			setup: func(mockDB *StandardMockDB, player *models.Player) {
+				mockDB.Mock.ExpectExec("UPDATE combatant_conditions").
+					WithArgs(3, TestEncounterID, player.AssociationID, TestDyingConditionID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:     "critical success recovers",
+			outcome:  "critical-success",
+			dying:    1,
+			expected: "is no longer dying and is now wounded 1",
+			setup: func(mockDB *StandardMockDB, player *models.Player) {
+				mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+					WithArgs(TestEncounterID, player.AssociationID, TestDyingConditionID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectConditionLookup(mockDB, TestWoundedConditionID, "Wounded")
+				mockDB.Mock.ExpectExec("INSERT INTO combatant_conditions").
+					WithArgs(TestEncounterID, player.AssociationID, TestWoundedConditionID, 1).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, cleanup := NewStandardMockDB(t)
+			defer cleanup()
+
+			player := CreateSamplePlayer()
+			player.Hp = 0
+			player.Conditions = []models.Condition{createValuedCondition(TestDyingConditionID, "Dying", tt.dying)}
+			if tt.doomed > 0 {
+				player.Conditions = append(player.Conditions, createValuedCondition(TestDoomedConditionID, "Doomed", tt.doomed))
+			}
+
+			tt.setup(mockDB, &player)
+
+			result, err := models.ApplyRecoveryCheck(mockDB, TestEncounterID, &player, tt.outcome)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("expected result to contain %q, got %q", tt.expected, result)
+			}
+
+			if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestApplyRecoveryCheck_NotDying(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+
+	if _, err := models.ApplyRecoveryCheck(mockDB, TestEncounterID, &player, "success"); err == nil {
+		t.Error("expected error for a combatant that isn't dying")
+	}
+}
+
+func TestProcessTurnStart_PromptsRecoveryCheck(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.Hp = 0
+	player.Conditions = []models.Condition{createValuedCondition(TestDyingConditionID, "Dying", 3)}
+
+	expectActions(mockDB, "encounter_players", 3, false, player.AssociationID)
+
+	changes, err := models.ProcessTurnStart(mockDB, TestEncounterID, &player)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(changes) != 1 || !strings.Contains(changes[0], "recovery check (DC 13)") {
+		t.Errorf("expected recovery check prompt, got %v", changes)
+	}
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+func TestAddEffect(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	monster := CreateSampleMonster()
+
+	mockDB.Mock.ExpectQuery("INSERT INTO combatant_effects \\(encounter_id, encounter_player_id, name, rounds, anchor, anchor_monster_id, selector, modifier_type, modifier_value\\)").
+		WithArgs(TestEncounterID, player.AssociationID, "Bless", 10, models.EffectAnchorStart, monster.AssociationID, "attack", "status", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	modifier := models.EffectModifier{Selector: "attack", Type: "status", Value: 1}
+	err := models.AddEffect(mockDB, TestEncounterID, &player, " Bless ", 10, models.EffectAnchorStart, &monster, modifier)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	effects := player.GetEffects()
+	if len(effects) != 1 || effects[0].ID != 3 || effects[0].String() != "Bless (10 rounds)" {
+		t.Fatalf("expected Bless (10 rounds), got %v", effects)
+	}
+	if !effects[0].IsAnchoredTo(&monster) || effects[0].IsAnchoredTo(&player) {
+		t.Error("expected the effect to be anchored to the monster")
+	}
+	if !strings.HasPrefix(effects[0].Description(), "+1 status to attack rolls. ") {
+		t.Errorf("expected the description to show the modifier, got %q", effects[0].Description())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddEffect_CustomCombatant(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	custom := createSampleCustomCombatant()
+
+	// Custom combatants have their own columns, removed with them by their
+	// foreign keys
+	mockDB.Mock.ExpectQuery("INSERT INTO combatant_effects \\(encounter_id, encounter_custom_combatant_id, name, rounds, anchor, anchor_custom_combatant_id, selector, modifier_type, modifier_value\\)").
+		WithArgs(TestEncounterID, custom.AssociationID, "Shield", 1, models.EffectAnchorEnd, custom.AssociationID, "", "untyped", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	// A modifier of 0 only tracks the duration, whatever else the form sent
+	modifier := models.EffectModifier{Selector: "ac", Type: "circumstance"}
+	err := models.AddEffect(mockDB, TestEncounterID, &custom, "Shield", 1, models.EffectAnchorEnd, &custom, modifier)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if effects := custom.GetEffects(); len(effects) != 1 || !effects[0].IsAnchoredTo(&custom) {
+		t.Errorf("expected the effect to be anchored to the custom combatant, got %v", effects)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddEffect_Invalid(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+
+	tests := []struct {
+		name     string
+		rounds   int
+		anchor   string
+		modifier models.EffectModifier
+	}{
+		{name: "", rounds: 1, anchor: models.EffectAnchorStart},
+		{name: "Shield", rounds: 0, anchor: models.EffectAnchorStart},
+		{name: "Shield", rounds: 1, anchor: "middle"},
+		{name: "Shield", rounds: 1, anchor: models.EffectAnchorStart, modifier: models.EffectModifier{Selector: "damage", Type: "status", Value: 1}},
+		{name: "Shield", rounds: 1, anchor: models.EffectAnchorStart, modifier: models.EffectModifier{Selector: "ac", Type: "luck", Value: 1}},
+	}
+
+	for _, tt := range tests {
+		if err := models.AddEffect(mockDB, TestEncounterID, &player, tt.name, tt.rounds, tt.anchor, &player, tt.modifier); err == nil {
+			t.Errorf("expected error for %+v, got nil", tt)
+		}
+	}
+}
+
+func TestProcessEffects_CountsDownAndExpires(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	caster := CreateSamplePlayer()
+	monster := CreateSampleMonster()
+
+	anchoredToCaster := func(id int, name string, rounds int, anchor string) models.Effect {
+		return models.Effect{ID: id, Name: name, Rounds: rounds, Anchor: anchor, AnchorAssociationID: caster.AssociationID}
+	}
+
+	caster.Effects = []models.Effect{
+		anchoredToCaster(1, "Shield", 1, models.EffectAnchorStart),
+		anchoredToCaster(2, "Bless", 10, models.EffectAnchorStart),
+		anchoredToCaster(3, "Inspire Courage", 1, models.EffectAnchorEnd),
+	}
+	monster.Effects = []models.Effect{
+		anchoredToCaster(4, "Fear", 1, models.EffectAnchorStart),
+		{ID: 5, Name: "Rage", Rounds: 1, Anchor: models.EffectAnchorStart, AnchorAssociationID: monster.AssociationID, AnchorIsMonster: true},
+	}
+
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_effects WHERE id = \\$1 AND encounter_id = \\$2 AND encounter_player_id = \\$3").
+		WithArgs(1, TestEncounterID, caster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("UPDATE combatant_effects SET rounds = \\$1 WHERE id = \\$2 AND encounter_id = \\$3 AND encounter_player_id = \\$4").
+		WithArgs(9, 2, TestEncounterID, caster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_effects WHERE id = \\$1 AND encounter_id = \\$2 AND encounter_monster_id = \\$3").
+		WithArgs(4, TestEncounterID, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	combatants := []models.Combatant{&caster, &monster}
+	changes, err := models.ProcessEffects(mockDB, TestEncounterID, combatants, &caster, models.EffectAnchorStart)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	summary := strings.Join(changes, "\n")
+	if !strings.Contains(summary, "Shield on Test Player has expired") || !strings.Contains(summary, "Fear on Test Monster 1 has expired") {
+		t.Errorf("expected Shield and Fear to expire, got %v", changes)
+	}
+	if len(changes) != 2 {
+		t.Errorf("expected 2 expired effects, got %v", changes)
+	}
+
+	if len(caster.Effects) != 2 || caster.Effects[0].Rounds != 9 {
+		t.Errorf("expected Bless at 9 rounds and Inspire Courage untouched, got %v", caster.Effects)
+	}
+	if len(monster.Effects) != 1 || monster.Effects[0].Name != "Rage" {
+		t.Errorf("expected only Rage to remain on the monster, got %v", monster.Effects)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetCombatantEffects(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "rounds", "anchor", "anchor_association_id", "anchor_is_monster", "selector", "modifier_type", "modifier_value"}).
+		AddRow(1, "Bless", 10, "start", 100, false, "attack", "status", 1).
+		AddRow(2, "Frightful Presence", 1, "end", 200, true, "", "untyped", 0).
+		AddRow(3, "Fear", 1, "end", nil, true, "all", "status", -1)
+	mockDB.Mock.ExpectQuery("SELECT id, name, rounds, anchor, .* FROM combatant_effects WHERE encounter_id = \\$1 AND COALESCE\\(encounter_monster_id, encounter_custom_combatant_id\\) = \\$2").
+		WithArgs(TestEncounterID, 200).
+		WillReturnRows(rows)
+
+	effects, err := models.GetCombatantEffects(mockDB, TestEncounterID, 200, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(effects) != 3 || effects[1].Anchor != models.EffectAnchorEnd || !effects[1].AnchorIsMonster {
+		t.Fatalf("unexpected effects %v", effects)
+	}
+	if effects[0].Modifier.Value != 1 || effects[0].Modifier.Selector != "attack" {
+		t.Errorf("expected Bless to carry its modifier, got %+v", effects[0].Modifier)
+	}
+
+	// The caster of Fear was removed, so it counts down on the target's turn
+	target := CreateSampleMonster()
+	target.AssociationID = 200
+	if !effects[2].IsAnchoredTo(&target) {
+		t.Errorf("expected an effect without an anchor to be anchored to its target, got %+v", effects[2])
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
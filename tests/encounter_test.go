@@ -23,6 +23,7 @@ func TestCreateEncounter_Success(t *testing.T) {
 	expectedEncounterID := 1
 
 	// Mock encounter creation
+	mockDB.Mock.ExpectBegin()
 	mockDB.Mock.ExpectQuery("INSERT INTO encounters").
 		WithArgs(encounterName, partyID, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(expectedEncounterID))
@@ -42,6 +43,7 @@ func TestCreateEncounter_Success(t *testing.T) {
 	mockDB.Mock.ExpectExec("INSERT INTO encounter_players").
 		WithArgs(expectedEncounterID, 2, 0, 30).
 		WillReturnResult(sqlmock.NewResult(2, 1))
+	mockDB.Mock.ExpectCommit()
 
 	encounter, err := models.CreateEncounter(mockDB, encounterName, partyID)
 	if err != nil {
@@ -85,9 +87,11 @@ func TestCreateEncounter_InsertError(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
 
+	mockDB.Mock.ExpectBegin()
 	mockDB.Mock.ExpectQuery("INSERT INTO encounters").
 		WithArgs("Test", 1, 1).
 		WillReturnError(sql.ErrConnDone)
+	mockDB.Mock.ExpectRollback()
 
 	encounter, err := models.CreateEncounter(mockDB, "Test", 1)
 	if err == nil {
@@ -107,6 +111,7 @@ func TestCreateEncounter_PlayerQueryError(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
 
+	mockDB.Mock.ExpectBegin()
 	mockDB.Mock.ExpectQuery("INSERT INTO encounters").
 		WithArgs("Test", 1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
@@ -114,6 +119,7 @@ func TestCreateEncounter_PlayerQueryError(t *testing.T) {
 	mockDB.Mock.ExpectQuery("SELECT id, hp FROM players WHERE party_id = \\$1").
 		WithArgs(1).
 		WillReturnError(sql.ErrConnDone)
+	mockDB.Mock.ExpectRollback()
 
 	_, err := models.CreateEncounter(mockDB, "Test", 1)
 	if err == nil {
@@ -186,8 +192,15 @@ func TestUpdateEncounter_Success_PartyChange(t *testing.T) {
 	// Mock transaction
 	mockDB.Mock.ExpectBegin()
 
-	// Mock deleting existing players
-	mockDB.Mock.ExpectExec("DELETE FROM encounter_players WHERE encounter_id = \\$1").
+	// Mock releasing the minions of the old party
+	for _, table := range []string{"encounter_monsters", "encounter_players", "encounter_custom_combatants"} {
+		mockDB.Mock.ExpectExec("UPDATE " + table + " SET owner_association_id = 0, owner_is_monster = false .+ player_id IS NOT NULL").
+			WithArgs(encounterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	// Mock deleting existing players, keeping companions
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_players WHERE encounter_id = \\$1 AND player_id IS NOT NULL").
 		WithArgs(encounterID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -212,6 +225,11 @@ func TestUpdateEncounter_Success_PartyChange(t *testing.T) {
 		WithArgs(newName, newPartyID, encounterID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// The history of the old party can't be restored
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_history WHERE encounter_id = \\$1").
+		WithArgs(encounterID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
 	// Mock transaction commit
 	mockDB.Mock.ExpectCommit()
 
@@ -266,19 +284,27 @@ func TestUpdateTurnAndRound_Success(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
 
-	encounterID := 1
-	turn := 2
-	round := 3
+	player := CreateSamplePlayer()
+	monster := CreateSampleMonster()
+
+	encounter := CreateSampleEncounter()
+	encounter.Combatants = []models.Combatant{&player, &monster}
+	encounter.Turn = 1
+	encounter.Round = 3
 
-	mockDB.Mock.ExpectExec("UPDATE encounters SET turn = \\$1, round = \\$2 WHERE id = \\$3").
-		WithArgs(turn, round, encounterID).
+	mockDB.Mock.ExpectExec("UPDATE encounters SET turn_association_id = \\$1, turn_is_monster = \\$2, round = \\$3 WHERE id = \\$4").
+		WithArgs(monster.AssociationID, true, 3, encounter.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := models.UpdateTurnAndRound(mockDB, turn, round, encounterID)
+	err := models.UpdateTurnAndRound(mockDB, &encounter)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
 
+	if encounter.TurnAssociationID != monster.AssociationID || !encounter.TurnIsMonster {
+		t.Errorf("expected the turn to belong to the monster, got %d", encounter.TurnAssociationID)
+	}
+
 	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unfulfilled expectations: %s", err)
 	}
@@ -288,7 +314,7 @@ func TestUpdateTurnAndRound_InvalidEncounterID(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
 
-	err := models.UpdateTurnAndRound(mockDB, 1, 1, 0)
+	err := models.UpdateTurnAndRound(mockDB, &models.Encounter{Turn: 1, Round: 1})
 	if err == nil {
 		t.Error("expected error for invalid encounter ID, got nil")
 	}
@@ -308,6 +334,7 @@ func TestUpdateTurnAndRound_InvalidTurnOrRound(t *testing.T) {
 		{"negative turn", -1, 1},
 		{"negative round", 1, -1},
 		{"both negative", -1, -1},
+		{"turn past the last combatant", 1, 1},
 	}
 
 	for _, tt := range tests {
@@ -315,7 +342,10 @@ func TestUpdateTurnAndRound_InvalidTurnOrRound(t *testing.T) {
 			mockDB, cleanup := NewStandardMockDB(t)
 			defer cleanup()
 
-			err := models.UpdateTurnAndRound(mockDB, tt.turn, tt.round, 1)
+			player := CreateSamplePlayer()
+			encounter := models.Encounter{ID: 1, Turn: tt.turn, Round: tt.round, Combatants: []models.Combatant{&player}}
+
+			err := models.UpdateTurnAndRound(mockDB, &encounter)
 			if err == nil {
 				t.Error("expected error for invalid turn or round, got nil")
 			}
@@ -383,23 +413,25 @@ func TestGetEncounter_Success(t *testing.T) {
 	encounterID := 1
 
 	// Mock main encounter query
-	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn", "round", "user_name", "party_name"}).
-		AddRow(1, "Test Encounter", 1, 1, 0, 1, "Test User", "Test Party")
-	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn, e.round, u.name AS user_name, p.name AS party_name").
+	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn_association_id", "turn_is_monster", "round", "user_name", "party_name", "status", "started_at", "finished_at"}).
+		AddRow(1, "Test Encounter", 1, 1, 0, false, 1, "Test User", "Test Party", models.EncounterRunning, nil, nil)
+	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn_association_id, e.turn_is_monster, e.round, u.name AS user_name, p.name AS party_name").
 		WithArgs(1, encounterID).
 		WillReturnRows(encounterRows)
 
 	// Mock monsters query (empty)
-	mockDB.Mock.ExpectQuery("SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.enumeration").
+	mockDB.Mock.ExpectQuery("SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.temp_hp, em.enumeration").
 		WithArgs(encounterID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "enumeration"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "temp_hp", "enumeration", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "initiative_group", "minion_kind", "owner_association_id", "owner_is_monster"}))
 
 	// Mock players query
-	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp"}).
-		AddRow(1, "Test Player", 5, 25, 18, 8, 6, 7, 12, 100, 25)
+	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp", "temp_hp", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "skills"}).
+		AddRow(1, "Test Player", 5, 25, 18, 8, 6, 7, 12, 100, 25, 0, "", 0, 3, false, 0, "", 0, []byte("{}"))
 	mockDB.Mock.ExpectQuery("SELECT p.id, p.name, p.level, p.hp, p.ac, p.fort, p.ref, p.will, ep.initiative, ep.id as association_id, ep.hp as current_hp").
 		WithArgs(encounterID).
 		WillReturnRows(playerRows)
+	mockDB.ExpectCompanions(encounterID)
+	mockDB.ExpectCustomCombatants(encounterID)
 
 	encounter, err := models.GetEncounter(mockDB, encounterID)
 	if err != nil {
@@ -429,7 +461,7 @@ func TestGetEncounter_NotFound(t *testing.T) {
 
 	encounterID := 999
 
-	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn, e.round, u.name AS user_name, p.name AS party_name").
+	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn_association_id, e.turn_is_monster, e.round, u.name AS user_name, p.name AS party_name").
 		WithArgs(1, encounterID).
 		WillReturnError(sql.ErrNoRows)
 
@@ -474,9 +506,9 @@ func TestGetAllEncounters_Success(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
 
-	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "user_name", "party_name"}).
-		AddRow(1, "Encounter 1", 1, 1, "Test User", "Party 1").
-		AddRow(2, "Encounter 2", 1, 2, "Test User", "Party 2")
+	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "user_name", "party_name", "round", "status"}).
+		AddRow(1, "Encounter 1", 1, 1, "Test User", "Party 1", 0, models.EncounterPreparing).
+		AddRow(2, "Encounter 2", 1, 2, "Test User", "Party 2", 2, models.EncounterRunning)
 
 	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, u.name AS user_name, p.name AS party_name").
 		WithArgs(1).
@@ -525,7 +557,7 @@ func TestAddMonsterToEncounter_Success(t *testing.T) {
 	encounterID := 1
 	monsterID := 1
 	levelAdjustment := 0
-	initiative := 15
+	initiative := models.InitiativeRoll{Statistic: "stealth", Die: 8, Total: 15}
 
 	monster := CreateSampleMonster()
 	jsonData, _ := json.Marshal(monster.Data)
@@ -545,28 +577,30 @@ func TestAddMonsterToEncounter_Success(t *testing.T) {
 
 	// Mock monster insertion
 	mockDB.Mock.ExpectExec("INSERT INTO encounter_monsters").
-		WithArgs(encounterID, monsterID, levelAdjustment, initiative, 35, 1).
+		WithArgs(encounterID, monsterID, levelAdjustment, 15, "stealth", 8, 0, 35, 1, "", 0, false).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Mock transaction commit
 	mockDB.Mock.ExpectCommit()
 
 	// Mock GetEncounter call
-	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn", "round", "user_name", "party_name"}).
-		AddRow(1, "Test Encounter", 1, 1, 0, 1, "Test User", "Test Party")
-	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn, e.round, u.name AS user_name, p.name AS party_name").
+	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn_association_id", "turn_is_monster", "round", "user_name", "party_name", "status", "started_at", "finished_at"}).
+		AddRow(1, "Test Encounter", 1, 1, 0, false, 1, "Test User", "Test Party", models.EncounterRunning, nil, nil)
+	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn_association_id, e.turn_is_monster, e.round, u.name AS user_name, p.name AS party_name").
 		WithArgs(1, encounterID).
 		WillReturnRows(encounterRows)
 
 	// Mock monsters query for GetEncounter
-	mockDB.Mock.ExpectQuery("SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.enumeration").
+	mockDB.Mock.ExpectQuery("SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.temp_hp, em.enumeration").
 		WithArgs(encounterID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "enumeration"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "temp_hp", "enumeration", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "initiative_group", "minion_kind", "owner_association_id", "owner_is_monster"}))
 
 	// Mock players query for GetEncounter
 	mockDB.Mock.ExpectQuery("SELECT p.id, p.name, p.level, p.hp, p.ac, p.fort, p.ref, p.will, ep.initiative, ep.id as association_id, ep.hp as current_hp").
 		WithArgs(encounterID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp", "temp_hp", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "skills"}))
+	mockDB.ExpectCompanions(encounterID)
+	mockDB.ExpectCustomCombatants(encounterID)
 
 	encounter, err := models.AddMonsterToEncounter(mockDB, encounterID, monsterID, levelAdjustment, initiative)
 	if err != nil {
@@ -582,13 +616,55 @@ func TestAddMonsterToEncounter_Success(t *testing.T) {
 	}
 }
 
+func TestAddMonstersToEncounter_Grouped(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter := CreateSampleEncounter()
+	monster := CreateSampleMonster()
+	jsonData, _ := json.Marshal(monster.Data)
+	initiative := models.InitiativeRoll{Statistic: "perception", Die: 10, Total: 16}
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectQuery("SELECT data FROM monsters WHERE id = \\$1").
+		WithArgs(monster.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(jsonData))
+	// Two copies are already in the encounter
+	mockDB.Mock.ExpectQuery("SELECT COALESCE\\(MAX\\(em.enumeration\\), 0\\)").
+		WithArgs(encounter.ID, "Test Monster").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(2))
+	mockDB.Mock.ExpectQuery("SELECT COALESCE\\(MAX\\(initiative_group\\), 0\\) \\+ 1").
+		WithArgs(encounter.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"group"}).AddRow(4))
+	for enumeration := 3; enumeration <= 5; enumeration++ {
+		mockDB.Mock.ExpectExec("INSERT INTO encounter_monsters").
+			WithArgs(encounter.ID, monster.ID, -1, 16, "perception", 10, 4, 35, enumeration, "", 0, false).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mockDB.Mock.ExpectCommit()
+	mockDB.SetupMockForGetEncounterWithCombatants(encounter)
+
+	initiatives := []models.InitiativeRoll{initiative, initiative, initiative}
+	if _, err := models.AddMonstersToEncounter(mockDB, encounter.ID, monster.ID, -1, initiatives, true); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if _, err := models.AddMonstersToEncounter(mockDB, encounter.ID, monster.ID, 0, nil, false); err == nil {
+		t.Error("expected an error without any copies")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
 func TestAddMonsterToEncounter_TransactionError(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
 
 	mockDB.Mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
 
-	encounter, err := models.AddMonsterToEncounter(mockDB, 1, 1, 0, 15)
+	encounter, err := models.AddMonsterToEncounter(mockDB, 1, 1, 0, models.InitiativeRoll{Total: 15})
 	if err == nil {
 		t.Error("expected error when transaction fails, got nil")
 	}
@@ -713,10 +789,10 @@ func TestGetCombatantConditions_Success_Monster(t *testing.T) {
 	}
 	jsonData, _ := json.Marshal(conditionData)
 
-	conditionRows := sqlmock.NewRows([]string{"id", "data", "condition_value"}).
-		AddRow(1, jsonData, 5)
+	conditionRows := sqlmock.NewRows([]string{"id", "data", "condition_value", "granted_by"}).
+		AddRow(1, jsonData, 5, 0)
 
-	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND cc.encounter_monster_id = \\$2").
+	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value, COALESCE\\(cc.granted_by, 0\\) FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND COALESCE\\(cc.encounter_monster_id, cc.encounter_custom_combatant_id\\) = \\$2").
 		WithArgs(encounterID, associationID).
 		WillReturnRows(conditionRows)
 
@@ -746,9 +822,9 @@ func TestGetCombatantConditions_Success_Player(t *testing.T) {
 	associationID := 100
 	isMonster := false
 
-	conditionRows := sqlmock.NewRows([]string{"id", "data", "condition_value"})
+	conditionRows := sqlmock.NewRows([]string{"id", "data", "condition_value", "granted_by"})
 
-	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND cc.encounter_player_id = \\$2").
+	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value, COALESCE\\(cc.granted_by, 0\\) FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND cc.encounter_player_id = \\$2").
 		WithArgs(encounterID, associationID).
 		WillReturnRows(conditionRows)
 
@@ -770,7 +846,7 @@ func TestGetCombatantConditions_QueryError(t *testing.T) {
 	mockDB, cleanup := NewStandardMockDB(t)
 	defer cleanup()
 
-	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND cc.encounter_monster_id = \\$2").
+	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value, COALESCE\\(cc.granted_by, 0\\) FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND COALESCE\\(cc.encounter_monster_id, cc.encounter_custom_combatant_id\\) = \\$2").
 		WithArgs(1, 100).
 		WillReturnError(sql.ErrConnDone)
 
@@ -801,28 +877,40 @@ func TestGetEncounterWithCombatants_Success(t *testing.T) {
 	encounterID := 1
 
 	// Mock GetEncounter call
-	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn", "round", "user_name", "party_name"}).
-		AddRow(1, "Test Encounter", 1, 1, 0, 1, "Test User", "Test Party")
-	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn, e.round, u.name AS user_name, p.name AS party_name").
+	encounterRows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn_association_id", "turn_is_monster", "round", "user_name", "party_name", "status", "started_at", "finished_at"}).
+		AddRow(1, "Test Encounter", 1, 1, 0, false, 1, "Test User", "Test Party", models.EncounterRunning, nil, nil)
+	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn_association_id, e.turn_is_monster, e.round, u.name AS user_name, p.name AS party_name").
 		WithArgs(1, encounterID).
 		WillReturnRows(encounterRows)
 
 	// Mock monsters query (empty)
-	mockDB.Mock.ExpectQuery("SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.enumeration").
+	mockDB.Mock.ExpectQuery("SELECT m.id, m.data, em.level_adjustment, em.id, em.initiative, em.hp as current_hp, em.temp_hp, em.enumeration").
 		WithArgs(encounterID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "enumeration"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "temp_hp", "enumeration", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "initiative_group", "minion_kind", "owner_association_id", "owner_is_monster"}))
 
 	// Mock players query
-	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp"}).
-		AddRow(1, "Test Player", 5, 25, 18, 8, 6, 7, 12, 100, 25)
+	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp", "temp_hp", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "skills"}).
+		AddRow(1, "Test Player", 5, 30, 18, 8, 6, 7, 12, 100, 12, 0, "", 0, 3, false, 0, "", 0, []byte("{}"))
 	mockDB.Mock.ExpectQuery("SELECT p.id, p.name, p.level, p.hp, p.ac, p.fort, p.ref, p.will, ep.initiative, ep.id as association_id, ep.hp as current_hp").
 		WithArgs(encounterID).
 		WillReturnRows(playerRows)
+	mockDB.ExpectCompanions(encounterID)
+	mockDB.ExpectCustomCombatants(encounterID)
 
 	// Mock condition queries for each combatant
-	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND cc.encounter_player_id = \\$2").
+	mockDB.Mock.ExpectQuery("SELECT c.id, c.data, cc.condition_value, COALESCE\\(cc.granted_by, 0\\) FROM combatant_conditions cc JOIN conditions c ON cc.condition_id = c.id WHERE cc.encounter_id = \\$1 AND cc.encounter_player_id = \\$2").
 		WithArgs(encounterID, 100).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "condition_value"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data", "condition_value", "granted_by"}))
+
+	// Mock persistent damage queries for each combatant
+	mockDB.Mock.ExpectQuery("SELECT id, formula, damage_type, assisted FROM persistent_damage WHERE encounter_id = \\$1 AND encounter_player_id = \\$2").
+		WithArgs(encounterID, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "formula", "damage_type", "assisted"}))
+
+	// Mock effect queries for each combatant
+	mockDB.Mock.ExpectQuery("SELECT id, name, rounds, anchor, COALESCE\\(anchor_monster_id, anchor_custom_combatant_id, anchor_player_id\\), anchor_player_id IS NULL, selector, modifier_type, modifier_value FROM combatant_effects WHERE encounter_id = \\$1 AND encounter_player_id = \\$2").
+		WithArgs(encounterID, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "rounds", "anchor", "anchor_association_id", "anchor_is_monster", "selector", "modifier_type", "modifier_value"}))
 
 	encounter, err := models.GetEncounterWithCombatants(mockDB, encounterID)
 	if err != nil {
@@ -834,7 +922,13 @@ func TestGetEncounterWithCombatants_Success(t *testing.T) {
 	}
 
 	if len(encounter.Combatants) != 1 {
-		t.Errorf("expected 1 combatant, got %d", len(encounter.Combatants))
+		t.Fatalf("expected 1 combatant, got %d", len(encounter.Combatants))
+	}
+
+	// Current HP comes from the encounter, max HP from the player
+	player := encounter.Combatants[0]
+	if player.GetHp() != 12 || player.GetMaxHp() != 30 {
+		t.Errorf("expected 12/30 HP, got %d/%d", player.GetHp(), player.GetMaxHp())
 	}
 
 	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
@@ -848,7 +942,7 @@ func TestGetEncounterWithCombatants_GetEncounterError(t *testing.T) {
 
 	encounterID := 999
 
-	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn, e.round, u.name AS user_name, p.name AS party_name").
+	mockDB.Mock.ExpectQuery("SELECT e.id, e.name, e.user_id, e.party_id, e.turn_association_id, e.turn_is_monster, e.round, u.name AS user_name, p.name AS party_name").
 		WithArgs(1, encounterID).
 		WillReturnError(sql.ErrNoRows)
 
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+func TestLogEvents(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	monster := CreateSampleMonster()
+
+	encounter := CreateSampleEncounter()
+	encounter.Round = 2
+	encounter.Combatants = []models.Combatant{&monster, &player}
+	encounter.TurnAssociationID = player.AssociationID
+
+	// Events are stamped with the round and whose turn it is
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_events").
+		WithArgs(TestEncounterID, models.EventHp, "Test Monster 1", 8, "fire", "Test Player", "", 2, "Test Player").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_events").
+		WithArgs(TestEncounterID, models.EventRule, "", 0, "", "", "Test Monster 1 is no longer frightened", 2, "Test Player").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	events := append([]models.EncounterEvent{models.NewHpEvent(&monster, 8, "fire", "Test Player")}, models.NewRuleEvents([]string{"Test Monster 1 is no longer frightened"})...)
+	if err := models.LogEvents(mockDB, &encounter, events...); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetEncounterEvents(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	createdAt := time.Date(2024, 5, 1, 20, 15, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "encounter_id", "kind", "combatant_name", "amount", "damage_type", "source", "description", "round", "turn_combatant_name", "created_at"}).
+		AddRow(1, TestEncounterID, models.EventTurn, "Test Player", 0, "", "", "Round 1: Test Player's turn", 1, "Test Player", createdAt).
+		AddRow(2, TestEncounterID, models.EventHp, "Test Monster 1", -5, "", "", "", 1, "Test Player", createdAt)
+	mockDB.Mock.ExpectQuery("SELECT id, encounter_id, kind, .* FROM encounter_events WHERE encounter_id = \\$1 ORDER BY id").
+		WithArgs(TestEncounterID).
+		WillReturnRows(rows)
+
+	events, err := models.GetEncounterEvents(mockDB, TestEncounterID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(events) != 2 || events[0].String() != "Round 1: Test Player's turn" || !events[1].CreatedAt.Equal(createdAt) {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if events[1].String() != "Test Monster 1 heals 5 HP" {
+		t.Errorf("expected healing to be described, got %q", events[1].String())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEncounterEvent_String(t *testing.T) {
+	player := CreateSamplePlayer()
+	monster := CreateSampleMonster()
+	frightened := createValuedCondition(5, "Frightened", 2)
+
+	tests := []struct {
+		event    models.EncounterEvent
+		expected string
+	}{
+		{models.NewHpEvent(&monster, 8, "slashing, fire", "Test Player"), "Test Monster 1 takes 8 slashing, fire damage from Test Player"},
+		{models.NewHpEvent(&monster, 3, "", ""), "Test Monster 1 takes 3 damage"},
+		{models.NewConditionEvent(&player, frightened, false), "Test Player is now frightened 2"},
+		{models.NewConditionEvent(&player, frightened, true), "Test Player is no longer frightened"},
+		{models.NewInitiativeEvent(&player), "Test Player's initiative is now 12"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.event.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createCandidate creates a bestiary creature of the level for the generator
+func createCandidate(id int, name string, level int) models.Monster {
+	monster := CreateSampleMonster()
+	monster.ID = id
+	monster.AssociationID = 0
+	monster.Enumeration = 0
+	monster.Data.Name = name
+	monster.Data.System.Details.Level.Value = level
+	return monster
+}
+
+func planEncounter(t *testing.T, candidates []models.Monster, options models.GeneratorOptions) models.GeneratedEncounter {
+	t.Helper()
+
+	generated, err := models.PlanEncounter(candidates, options, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return generated
+}
+
+func TestPlanEncounter_Solo(t *testing.T) {
+	candidates := []models.Monster{
+		createCandidate(1, "Ogre", 3),
+		createCandidate(2, "Troll", 5),
+		createCandidate(3, "Young Red Dragon", 10),
+	}
+	options := models.GeneratorOptions{PartyLevel: 3, PartySize: 4, Threat: models.ThreatModerate, Pattern: models.PatternSolo}
+
+	// A creature two levels above the party spends the moderate budget of 80
+	generated := planEncounter(t, candidates, options)
+	if len(generated.Creatures) != 1 || generated.Creatures[0].Monster.GetName() != "Troll" {
+		t.Fatalf("expected a troll, got %+v", generated.Creatures)
+	}
+	if generated.Xp != 80 || generated.Budget != 80 || generated.ThreatName != "Moderate" {
+		t.Errorf("expected 80 of 80 XP for a moderate threat, got %d of %d for %s", generated.Xp, generated.Budget, generated.ThreatName)
+	}
+}
+
+func TestPlanEncounter_BossAndMinions(t *testing.T) {
+	candidates := []models.Monster{
+		createCandidate(1, "Hobgoblin General", 7),
+		createCandidate(2, "Hobgoblin Soldier", 3),
+	}
+	options := models.GeneratorOptions{PartyLevel: 5, PartySize: 4, Threat: models.ThreatSevere, Pattern: models.PatternBoss}
+
+	// An 80 XP boss and two 20 XP minions for the severe budget of 120
+	generated := planEncounter(t, candidates, options)
+	if len(generated.Creatures) != 2 {
+		t.Fatalf("expected a boss and minions, got %+v", generated.Creatures)
+	}
+
+	boss, minions := generated.Creatures[0], generated.Creatures[1]
+	if boss.Monster.ID != 1 || boss.Quantity != 1 || boss.Xp != 80 {
+		t.Errorf("expected the general as boss worth 80 XP, got %+v", boss)
+	}
+	if minions.Monster.ID != 2 || minions.Quantity != 2 || minions.Xp != 40 {
+		t.Errorf("expected two soldiers worth 40 XP, got %+v", minions)
+	}
+	if generated.Xp != 120 {
+		t.Errorf("expected 120 XP, got %d", generated.Xp)
+	}
+}
+
+func TestPlanEncounter_Horde(t *testing.T) {
+	candidates := []models.Monster{
+		createCandidate(1, "Zombie Shambler", 2),
+		createCandidate(2, "Skeleton Guard", 4),
+	}
+	options := models.GeneratorOptions{PartyLevel: 5, PartySize: 4, Threat: models.ThreatLow, Pattern: models.PatternHorde}
+
+	// Two skeletons are too few for a horde, four zombies fill the 60 XP
+	generated := planEncounter(t, candidates, options)
+	if len(generated.Creatures) != 1 {
+		t.Fatalf("expected a single horde, got %+v", generated.Creatures)
+	}
+	if horde := generated.Creatures[0]; horde.Monster.ID != 1 || horde.Quantity != 4 || generated.Xp != 60 {
+		t.Errorf("expected four zombies worth 60 XP, got %d × %s worth %d XP", horde.Quantity, horde.Monster.GetName(), generated.Xp)
+	}
+}
+
+func TestPlanEncounter_Adjustments(t *testing.T) {
+	candidates := []models.Monster{createCandidate(1, "Ogre", 4)}
+	options := models.GeneratorOptions{PartyLevel: 3, PartySize: 4, Threat: models.ThreatModerate, Pattern: models.PatternSolo}
+
+	// Without adjustments the ogre is only worth 60 XP
+	generated := planEncounter(t, candidates, options)
+	if generated.Xp != 60 || generated.Creatures[0].Monster.LevelAdjustment != 0 {
+		t.Errorf("expected a normal ogre worth 60 XP, got %s worth %d XP", generated.Creatures[0].Monster.GetName(), generated.Xp)
+	}
+
+	// An elite ogre is two levels above the party
+	options.AllowAdjustments = true
+	generated = planEncounter(t, candidates, options)
+	if name := generated.Creatures[0].Monster.GetName(); name != "Elite Ogre" || generated.Xp != 80 {
+		t.Errorf("expected an elite ogre worth 80 XP, got %s worth %d XP", name, generated.Xp)
+	}
+}
+
+func TestPlanEncounter_SpendsLeftover(t *testing.T) {
+	candidates := []models.Monster{
+		createCandidate(1, "Hobgoblin General", 8),
+		createCandidate(2, "Hobgoblin Soldier", 4),
+	}
+	options := models.GeneratorOptions{PartyLevel: 5, PartySize: 5, Threat: models.ThreatExtreme, Pattern: models.PatternBoss, AllowAdjustments: true}
+
+	// The boss and two minions leave 20 of the 200 XP, enough to make the
+	// minions elite
+	generated := planEncounter(t, candidates, options)
+	if generated.Xp != 200 {
+		t.Fatalf("expected 200 XP, got %d", generated.Xp)
+	}
+	if minions := generated.Creatures[1]; minions.Monster.LevelAdjustment != 1 || minions.Xp != 80 {
+		t.Errorf("expected two elite soldiers worth 80 XP, got %+v", minions)
+	}
+	if boss := generated.Creatures[0]; boss.Monster.LevelAdjustment != 0 {
+		t.Errorf("expected the boss to stay normal, got %s", boss.Monster.GetName())
+	}
+}
+
+func TestPlanEncounter_Errors(t *testing.T) {
+	candidates := []models.Monster{createCandidate(1, "Goblin Warrior", -1)}
+	rng := rand.New(rand.NewSource(1))
+
+	tests := map[string]models.GeneratorOptions{
+		"no party":        {PartyLevel: 5, Threat: models.ThreatLow, Pattern: models.PatternSolo},
+		"unknown threat":  {PartyLevel: 5, PartySize: 4, Threat: 7, Pattern: models.PatternSolo},
+		"unknown pattern": {PartyLevel: 5, PartySize: 4, Threat: models.ThreatLow, Pattern: "ambush"},
+		"nothing fits":    {PartyLevel: 5, PartySize: 4, Threat: models.ThreatLow, Pattern: models.PatternHorde},
+	}
+
+	for name, options := range tests {
+		if _, err := models.PlanEncounter(candidates, options, rng); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGenerateEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	troll := createCandidate(2, "Troll", 5)
+	data, _ := json.Marshal(troll.Data)
+
+	// The level range of the filters narrows the one the party can face
+	maxLevel := 6
+	options := models.GeneratorOptions{
+		PartyLevel: 3,
+		PartySize:  4,
+		Threat:     models.ThreatModerate,
+		Pattern:    models.PatternSolo,
+		Filters:    models.MonsterSearchFilters{MaxLevel: &maxLevel, Traits: models.ParseTraits(" Giant, ")},
+	}
+
+	mockDB.Mock.ExpectQuery("SELECT id, data\\s+FROM monsters\\s+WHERE data->>'type' <> 'hazard' AND .+ \\?& \\$3\\s+ORDER BY random\\(\\)").
+		WithArgs(-1, 6, sqlmock.AnyArg(), 200).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "data"}).AddRow(troll.ID, data))
+
+	generated, err := models.GenerateEncounter(mockDB, options)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(generated.Creatures) != 1 || generated.Creatures[0].Monster.ID != troll.ID {
+		t.Errorf("expected the troll, got %+v", generated.Creatures)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSaveGeneratedEncounter_RollsBack(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	troll := createCandidate(2, "Troll", 5)
+	creatures := []models.GeneratedCreature{{Monster: troll, Quantity: 1}}
+	initiatives := [][]models.InitiativeRoll{{{Total: 15, Statistic: "perception", Die: 8}}}
+
+	// The encounter isn't kept when one of its creatures can't be added
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectQuery("INSERT INTO encounters").
+		WithArgs("Generated", TestPartyID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(TestEncounterID))
+	mockDB.Mock.ExpectQuery("SELECT id, hp FROM players WHERE party_id = \\$1").
+		WithArgs(TestPartyID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hp"}))
+	mockDB.Mock.ExpectQuery("SELECT data FROM monsters WHERE id = \\$1").
+		WithArgs(troll.ID).
+		WillReturnError(sqlmock.ErrCancelled)
+	mockDB.Mock.ExpectRollback()
+
+	if _, err := models.SaveGeneratedEncounter(mockDB, "Generated", TestPartyID, creatures, initiatives); err == nil {
+		t.Fatal("expected an error when a creature can't be added")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
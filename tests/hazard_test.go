@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"testing"
+
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createSampleHazard creates a trap of the given level
+func createSampleHazard(level int, complex bool) models.Monster {
+	hazard := models.Monster{ID: TestMonsterID + 1, AssociationID: 250}
+	hazard.Data.Name = "Poisoned Dart Gallery"
+	hazard.Data.Type = models.HazardType
+	hazard.Data.System.Details.Level.Value = level
+	hazard.Data.System.Details.IsComplex = complex
+	hazard.Data.System.Details.Disable = "<p>@Check[thievery|dc:21] (trained) on the control panel</p>"
+	hazard.Data.System.Attributes.Stealth.Value = 11
+	hazard.Data.System.Attributes.Stealth.Details = "<p>(trained)</p>"
+	hazard.Data.System.Attributes.Hardness = 5
+	return hazard
+}
+
+func TestMonster_Hazard(t *testing.T) {
+	hazard := createSampleHazard(3, true)
+
+	if !hazard.IsHazard() || !hazard.IsComplexHazard() || hazard.IsSimpleHazard() {
+		t.Error("expected a complex hazard")
+	}
+	if hazard.GetType() != models.HazardType {
+		t.Errorf("expected type %q, got %q", models.HazardType, hazard.GetType())
+	}
+	if hazard.GetStealthDC() != 21 {
+		t.Errorf("expected stealth DC 21, got %d", hazard.GetStealthDC())
+	}
+	if hazard.HasHealth() {
+		t.Error("expected a hazard without hit points")
+	}
+	if disable := hazard.GetDisable(); disable == "" || disable == hazard.Data.System.Details.Disable {
+		t.Errorf("expected the inline check to be replaced, got %q", disable)
+	}
+
+	// Complex hazards roll initiative with their Stealth
+	if hazard.GetInitiativeStatistic() != "stealth" {
+		t.Errorf("expected stealth initiative, got %q", hazard.GetInitiativeStatistic())
+	}
+	if modifier := hazard.GetInitiativeModifier("stealth"); modifier != 11 {
+		t.Errorf("expected initiative modifier 11, got %d", modifier)
+	}
+
+	if hazard.GetDamageDefenses().Hardness != 5 {
+		t.Errorf("expected hardness 5, got %d", hazard.GetDamageDefenses().Hardness)
+	}
+
+	monster := CreateSampleMonster()
+	if monster.IsHazard() || !monster.HasHealth() || monster.GetType() != "monster" {
+		t.Error("expected creatures not to be hazards")
+	}
+}
+
+func TestEncounter_SimpleHazards(t *testing.T) {
+	monster := CreateSampleMonster()
+	simple := createSampleHazard(3, false)
+	complex := createSampleHazard(3, true)
+
+	encounter := CreateSampleEncounter()
+	encounter.Monsters = []*models.Monster{&monster, &simple, &complex}
+
+	hazards := encounter.SimpleHazards()
+	if len(hazards) != 1 || hazards[0] != &simple {
+		t.Errorf("expected only the simple hazard, got %v", hazards)
+	}
+}
+
+func TestGetDifficulty_Hazards(t *testing.T) {
+	encounter := CreateSampleEncounter()
+	for range 4 {
+		player := CreateSamplePlayer()
+		player.Level = 3
+		encounter.Players = append(encounter.Players, &player)
+	}
+
+	first := CreateSampleMonster()
+	second := CreateSampleMonster()
+	encounter.Monsters = []*models.Monster{&first, &second}
+	encounter.Combatants = []models.Combatant{&first, &second}
+
+	// Two creatures of the party's level make a moderate encounter
+	if difficulty := encounter.GetDifficulty(); difficulty != 2 {
+		t.Fatalf("expected moderate difficulty, got %d", difficulty)
+	}
+
+	// A simple hazard of the party's level adds 8 XP
+	simple := createSampleHazard(3, false)
+	encounter.Monsters = append(encounter.Monsters, &simple)
+	if xp := encounter.GetXpBudget().Xp; xp != 88 {
+		t.Errorf("expected 88 XP with the simple hazard, got %d", xp)
+	}
+
+	// A complex hazard is worth as much as a creature of its level
+	complex := createSampleHazard(3, true)
+	encounter.Monsters = []*models.Monster{&first, &complex}
+	encounter.Combatants = []models.Combatant{&first, &complex}
+	if difficulty := encounter.GetDifficulty(); difficulty != 2 {
+		t.Errorf("expected moderate difficulty with the complex hazard, got %d", difficulty)
+	}
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+var historyTables = []string{
+	"encounter_monsters",
+	"encounter_players",
+	"encounter_custom_combatants",
+	"combatant_conditions",
+	"persistent_damage",
+	"combatant_effects",
+}
+
+// expectEncounterState sets up the queries that snapshot the encounter's
+// combat state
+func expectEncounterState(mockDB *StandardMockDB, round int) {
+	mockDB.Mock.ExpectQuery("SELECT turn_association_id, turn_is_monster, round, status, COALESCE\\(\\(SELECT id FROM party_xp_awards .+\\), 0\\) FROM encounters").
+		WithArgs(TestEncounterID).
+		WillReturnRows(sqlmock.NewRows([]string{"turn_association_id", "turn_is_monster", "round", "status", "xp_award_id"}).AddRow(200, true, round, models.EncounterRunning, 0))
+
+	for _, table := range historyTables {
+		mockDB.Mock.ExpectQuery("SELECT COALESCE\\(json_agg\\(t\\), '\\[\\]'\\) FROM " + table).
+			WithArgs(TestEncounterID).
+			WillReturnRows(sqlmock.NewRows([]string{"json_agg"}).AddRow([]byte("[]")))
+	}
+}
+
+func TestRecordUndo(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	expectEncounterState(mockDB, 2)
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_history \\(encounter_id, stack, label, state\\)").
+		WithArgs(TestEncounterID, "undo", "Damage to Test Player", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_history WHERE encounter_id = \\$1 AND stack = \\$2").
+		WithArgs(TestEncounterID, "redo").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_history WHERE encounter_id = \\$1 AND stack = \\$2 AND id NOT IN").
+		WithArgs(TestEncounterID, "undo", models.HistoryLimit).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.Mock.ExpectCommit()
+
+	if err := models.RecordUndo(mockDB, TestEncounterID, "Damage to Test Player"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSnapshotUndo(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	// The snapshot only reads the state, a failed change leaves no history
+	expectEncounterState(mockDB, 2)
+
+	snapshot, err := models.SnapshotUndo(mockDB, TestEncounterID, "Actions of Test Player")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+
+	// Once the change succeeded it is saved
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_history \\(encounter_id, stack, label, state\\)").
+		WithArgs(TestEncounterID, "undo", "Actions of Test Player", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_history WHERE encounter_id = \\$1 AND stack = \\$2").
+		WithArgs(TestEncounterID, "redo").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_history WHERE encounter_id = \\$1 AND stack = \\$2 AND id NOT IN").
+		WithArgs(TestEncounterID, "undo", models.HistoryLimit).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.Mock.ExpectCommit()
+
+	if err := snapshot.Save(mockDB); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	state := []byte(`{"turn_association_id":100,"turn_is_monster":false,"round":1,"tables":{"encounter_players":[{"id":100,"turn":2}]}}`)
+	mockDB.Mock.ExpectQuery("SELECT id, label, state FROM encounter_history").
+		WithArgs(TestEncounterID, "undo").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "label", "state"}).AddRow(7, "Next turn", state))
+	expectEncounterState(mockDB, 2)
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_history WHERE id = \\$1").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_history").
+		WithArgs(TestEncounterID, "redo", "Next turn", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(8, 1))
+
+	// Dependent tables are cleared first, then only the saved tables come back
+	for i := len(historyTables) - 1; i >= 0; i-- {
+		mockDB.Mock.ExpectExec("DELETE FROM " + historyTables[i] + " WHERE encounter_id = \\$1").
+			WithArgs(TestEncounterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	// Columns the table no longer has are left out, new ones get their defaults
+	mockDB.Mock.ExpectQuery("SELECT column_name FROM information_schema.columns").
+		WithArgs("encounter_players").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("hp"))
+	mockDB.Mock.ExpectExec(`INSERT INTO encounter_players \("id"\) SELECT "id" FROM json_populate_recordset`).
+		WithArgs(`[{"id":100,"turn":2}]`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("UPDATE encounters SET turn_association_id = \\$1, turn_is_monster = \\$2, round = \\$3").
+		WithArgs(100, false, 1, "", TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectCommit()
+
+	label, err := models.Undo(mockDB, TestEncounterID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if label != "Next turn" {
+		t.Errorf("expected the undone change's label, got %q", label)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUndo_ReattachesXpAward(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	// The snapshot from before a reset still has the encounter's award
+	state := []byte(`{"turn_association_id":100,"turn_is_monster":false,"round":3,"status":"finished","xp_award_id":4,"tables":{}}`)
+	mockDB.Mock.ExpectQuery("SELECT id, label, state FROM encounter_history").
+		WithArgs(TestEncounterID, "undo").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "label", "state"}).AddRow(7, "Reset", state))
+	expectEncounterState(mockDB, 0)
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectExec("DELETE FROM encounter_history WHERE id = \\$1").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_history").
+		WithArgs(TestEncounterID, "redo", "Reset", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(8, 1))
+	for i := len(historyTables) - 1; i >= 0; i-- {
+		mockDB.Mock.ExpectExec("DELETE FROM " + historyTables[i] + " WHERE encounter_id = \\$1").
+			WithArgs(TestEncounterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mockDB.Mock.ExpectExec("UPDATE encounters SET turn_association_id = \\$1").
+		WithArgs(100, false, 3, models.EncounterFinished, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("UPDATE party_xp_awards SET encounter_id = \\$1 WHERE id = \\$2 AND encounter_id IS NULL AND NOT EXISTS").
+		WithArgs(TestEncounterID, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectCommit()
+
+	if _, err := models.Undo(mockDB, TestEncounterID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRedo_NothingToRedo(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	mockDB.Mock.ExpectQuery("SELECT id, label, state FROM encounter_history").
+		WithArgs(TestEncounterID, "redo").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := models.Redo(mockDB, TestEncounterID)
+	if err == nil || err.Error() != "nothing to redo" {
+		t.Errorf("expected nothing to redo, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
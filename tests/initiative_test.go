@@ -0,0 +1,273 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createTiedEncounter creates an encounter where two players and a monster
+// all rolled 15 on initiative
+func createTiedEncounter() (models.Encounter, *models.Player, *models.Player, *models.Monster) {
+	first := CreateSamplePlayer()
+	first.Initiative = 15
+	second := CreateSamplePlayer()
+	second.AssociationID = 101
+	second.Initiative = 15
+	monster := CreateSampleMonster()
+
+	encounter := CreateSampleEncounter()
+	encounter.Combatants = []models.Combatant{&second, &first, &monster}
+
+	return encounter, &first, &second, &monster
+}
+
+func TestSortCombatantsByInitiative_BreaksTies(t *testing.T) {
+	encounter, first, second, monster := createTiedEncounter()
+
+	models.SortCombatantsByInitiative(encounter.Combatants)
+
+	// Monsters win ties against players, then the order they were added decides
+	expected := []models.Combatant{monster, first, second}
+	for i, c := range expected {
+		if encounter.Combatants[i] != c {
+			t.Fatalf("expected %s at position %d, got %s", c.GetName(), i, encounter.Combatants[i].GetName())
+		}
+	}
+
+	// The order set by the GM takes precedence
+	monster.InitiativeOrder = 3
+	first.InitiativeOrder = 2
+	second.InitiativeOrder = 1
+
+	models.SortCombatantsByInitiative(encounter.Combatants)
+
+	if encounter.Combatants[0] != models.Combatant(second) || encounter.Combatants[2] != models.Combatant(monster) {
+		t.Errorf("expected the stored tie order to be used, got %s first", encounter.Combatants[0].GetName())
+	}
+}
+
+func TestEncounter_SortCombatants_KeepsTurn(t *testing.T) {
+	encounter, first, _, _ := createTiedEncounter()
+	encounter.TurnAssociationID = first.AssociationID
+	encounter.TurnIsMonster = false
+
+	encounter.SortCombatants()
+
+	if encounter.Combatants[encounter.Turn] != models.Combatant(first) {
+		t.Errorf("expected the turn to follow the active combatant, got turn %d", encounter.Turn)
+	}
+
+	// A player and a monster can share the same association id
+	encounter.TurnAssociationID = 200
+	encounter.TurnIsMonster = false
+
+	encounter.SortCombatants()
+
+	if encounter.Turn != 0 {
+		t.Errorf("expected an unknown combatant to fall back to the first turn, got %d", encounter.Turn)
+	}
+}
+
+func TestMoveInTie(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter, first, second, monster := createTiedEncounter()
+	encounter.TurnAssociationID = monster.AssociationID
+	encounter.TurnIsMonster = true
+	encounter.SortCombatants()
+
+	// Let the second player act before the first one
+	expectInitiativeOrder(mockDB, "encounter_monsters", 1, monster.AssociationID)
+	expectInitiativeOrder(mockDB, "encounter_players", 2, second.AssociationID)
+	expectInitiativeOrder(mockDB, "encounter_players", 3, first.AssociationID)
+
+	other, err := models.MoveInTie(mockDB, &encounter, 2, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if other != models.Combatant(first) {
+		t.Errorf("expected the second player to swap with the first one, got %s", other.GetName())
+	}
+
+	if encounter.Combatants[1] != models.Combatant(second) || encounter.Combatants[2] != models.Combatant(first) {
+		t.Errorf("expected the players to swap places, got %s second", encounter.Combatants[1].GetName())
+	}
+	if encounter.Turn != 0 {
+		t.Errorf("expected the monster to keep the turn, got turn %d", encounter.Turn)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMoveInTie_NotTied(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter, first, _, _ := createTiedEncounter()
+	first.Initiative = 10
+	encounter.SortCombatants()
+
+	if _, err := models.MoveInTie(mockDB, &encounter, 2, true); err == nil {
+		t.Error("expected an error when moving past a combatant with a different initiative")
+	}
+	if _, err := models.MoveInTie(mockDB, &encounter, 0, true); err == nil {
+		t.Error("expected an error when moving the first combatant up")
+	}
+}
+
+func TestPassTurnFrom(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter, first, second, monster := createTiedEncounter()
+	encounter.Round = 1
+	encounter.SortCombatants()
+
+	// Not the active combatant, nothing changes
+	if _, err := models.PassTurnFrom(mockDB, &encounter, first); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The next combatant's turn starts
+	expectTurnAndRound(mockDB, first, 1)
+	expectActions(mockDB, "encounter_players", 3, false, first.AssociationID)
+
+	if _, err := models.PassTurnFrom(mockDB, &encounter, monster); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.Turn != 1 {
+		t.Errorf("expected the turn to pass to the next combatant, got %d", encounter.Turn)
+	}
+
+	// The last combatant passes the turn on to the next round
+	encounter.Turn = 2
+	expectTurnAndRound(mockDB, monster, 2)
+	expectActions(mockDB, "encounter_monsters", 3, false, monster.AssociationID)
+
+	if _, err := models.PassTurnFrom(mockDB, &encounter, second); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.Turn != 0 || encounter.Round != 2 {
+		t.Errorf("expected the first turn of round 2, got turn %d round %d", encounter.Turn, encounter.Round)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRollInitiative(t *testing.T) {
+	defer fixRolls(12)()
+
+	player := CreateSamplePlayer()
+	player.Skills = map[string]int{"stealth": 9}
+
+	tests := []struct {
+		statistic string
+		expected  int
+	}{
+		{statistic: "perception", expected: 22},
+		{statistic: " Stealth ", expected: 21},
+		// Untrained skills fall back to the attribute modifier, 0 for the sample player
+		{statistic: "athletics", expected: 12},
+	}
+
+	for _, tt := range tests {
+		roll, err := models.RollInitiative(&player, tt.statistic)
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %v", tt.statistic, err)
+		}
+		if roll.Die != 12 || roll.Total != tt.expected {
+			t.Errorf("expected %d with %q, got %+v", tt.expected, tt.statistic, roll)
+		}
+	}
+
+	roll, _ := models.RollInitiative(&player, "stealth")
+	if roll.String() != "Stealth: 12 + 9 = 21" {
+		t.Errorf("unexpected roll description %q", roll.String())
+	}
+
+	if _, err := models.RollInitiative(&player, "luck"); err == nil {
+		t.Error("expected an error for an unknown statistic")
+	}
+}
+
+func TestRollCopies(t *testing.T) {
+	defer fixRolls(12)()
+
+	monster := CreateSampleMonster()
+
+	// A group shares a single roll
+	initiatives, err := models.RollCopies(&monster, "perception", 3, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(initiatives) != 3 || initiatives[1] != initiatives[0] || initiatives[2] != initiatives[0] {
+		t.Errorf("expected three copies of one roll, got %+v", initiatives)
+	}
+
+	// Quantities are checked before anything is rolled
+	for _, quantity := range []int{0, -1, 21, 1000000000} {
+		if _, err := models.RollCopies(&monster, "perception", quantity, false); err == nil {
+			t.Errorf("expected an error for %d copies", quantity)
+		}
+	}
+}
+
+func TestRecordInitiative(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.Skills = map[string]int{"stealth": 9}
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET initiative = \\$1, initiative_statistic = \\$2, initiative_die = \\$3").
+		WithArgs(25, "stealth", 16, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := models.RecordInitiative(mockDB, &player, "Stealth", 25); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if player.GetInitiativeStatistic() != "stealth" || player.GetInitiativeRoll().Die != 16 {
+		t.Errorf("expected the stealth roll to be stored, got %+v", player.GetInitiativeRoll())
+	}
+
+	if err := models.RecordInitiative(mockDB, &player, "luck", 25); err == nil {
+		t.Error("expected an error for an unknown statistic")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMonster_InitiativeStatistic(t *testing.T) {
+	monster := CreateSampleMonster()
+	if monster.GetInitiativeStatistic() != models.InitiativePerception {
+		t.Errorf("expected perception by default, got %q", monster.GetInitiativeStatistic())
+	}
+
+	// Ambushers roll Stealth, using the skill from their statblock
+	monster.Data.System.Initiative.Statistic = "stealth"
+	monster.Data.System.Skills = map[string]struct {
+		Base int `json:"base"`
+	}{"stealth": {Base: 11}}
+
+	if monster.GetInitiativeStatistic() != "stealth" {
+		t.Errorf("expected stealth from the monster data, got %q", monster.GetInitiativeStatistic())
+	}
+	if modifier := monster.GetInitiativeModifier("stealth"); modifier != 11 {
+		t.Errorf("expected a stealth modifier of 11, got %d", modifier)
+	}
+
+	// The statistic the monster actually rolled with wins
+	monster.InitiativeStatistic = models.InitiativePerception
+	if monster.GetInitiativeStatistic() != models.InitiativePerception {
+		t.Errorf("expected the rolled statistic, got %q", monster.GetInitiativeStatistic())
+	}
+}
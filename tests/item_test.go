@@ -42,6 +42,9 @@ func createSampleItem() models.Item {
 			AttackEffects struct {
 				Value []any `json:"value"`
 			} `json:"attackEffects"`
+			Ability struct {
+				Value string `json:"value"`
+			} `json:"ability"`
 			Area struct {
 				Type  string `json:"type"`
 				Value int    `json:"value"`
@@ -107,6 +110,9 @@ func createSampleItem() models.Item {
 			Time struct {
 				Value string `json:"value"`
 			} `json:"time"`
+			Tradition struct {
+				Value string `json:"value"`
+			} `json:"tradition"`
 			Traits struct {
 				Value      []string `json:"value"`
 				Traditions []string `json:"traditions"`
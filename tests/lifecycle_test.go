@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+
+	"pf2.encounterbrew.com/cmd/web/encounter"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+func TestStartEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	// The monster hasn't rolled yet, the ogre's initiative was entered by hand
+	monster := CreateSampleMonster()
+	monster.Initiative = 0
+	ogre := CreateSampleMonster()
+	ogre.AssociationID = 201
+	ogre.Initiative = 30
+	player := CreateSamplePlayer()
+	player.Initiative = 0
+
+	encounter := CreateSampleEncounter()
+	encounter.Round = 0
+	encounter.Combatants = []models.Combatant{&player, &monster, &ogre}
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET initiative = \\$1, initiative_statistic = \\$2, initiative_die = \\$3").
+		WithArgs(sqlmock.AnyArg(), "perception", sqlmock.AnyArg(), monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("UPDATE encounters SET turn_association_id").
+		WithArgs(ogre.AssociationID, true, 0, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectQuery("UPDATE encounters SET status = \\$1, started_at = CURRENT_TIMESTAMP").
+		WithArgs(models.EncounterRunning, TestEncounterID).
+		WillReturnRows(sqlmock.NewRows([]string{"started_at"}).AddRow(time.Now()))
+	expectActions(mockDB, "encounter_monsters", 3, false, ogre.AssociationID)
+
+	changes, err := models.StartEncounter(mockDB, &encounter)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(changes) != 2 || !strings.Contains(changes[0], "Test Player has no initiative yet") || !strings.Contains(changes[1], "rolled initiative") {
+		t.Errorf("expected the roll and the missing player initiative, got %v", changes)
+	}
+	if !encounter.IsRunning() || encounter.StartedAt == nil || encounter.Round != 0 || encounter.Turn != 0 {
+		t.Errorf("expected a running encounter in round 1, got %+v", encounter)
+	}
+	if ogre.Initiative != 30 {
+		t.Errorf("expected the entered initiative to be kept, got %d", ogre.Initiative)
+	}
+
+	// A running encounter can't start again
+	if _, err := models.StartEncounter(mockDB, &encounter); err == nil {
+		t.Error("expected an error when starting a running encounter")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEndEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter := CreateSampleEncounter()
+	if err := models.EndEncounter(mockDB, &encounter); err == nil {
+		t.Error("expected an error when ending an encounter that hasn't started")
+	}
+
+	mockDB.Mock.ExpectQuery("UPDATE encounters SET status = \\$1, finished_at = CURRENT_TIMESTAMP").
+		WithArgs(models.EncounterFinished, TestEncounterID).
+		WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(time.Now()))
+
+	encounter.Status = models.EncounterRunning
+	if err := models.EndEncounter(mockDB, &encounter); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !encounter.IsFinished() || encounter.FinishedAt == nil {
+		t.Errorf("expected a finished encounter with its end time, got %+v", encounter)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestResetEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters em SET hp = .+'hp'.+ initiative = 0, .+ FROM monsters m").
+		WithArgs(TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.Mock.ExpectExec("UPDATE encounter_players ep SET hp = COALESCE\\(\\(SELECT p.hp FROM players p .+\\), ep.max_hp\\), .+ initiative = 0").
+		WithArgs(TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mockDB.Mock.ExpectExec("UPDATE encounter_custom_combatants SET hp = max_hp, .+ initiative = 0").
+		WithArgs(TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, table := range []string{"combatant_conditions", "persistent_damage", "combatant_effects"} {
+		mockDB.Mock.ExpectExec("DELETE FROM " + table).
+			WithArgs(TestEncounterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mockDB.Mock.ExpectExec("UPDATE party_xp_awards SET encounter_id = NULL").
+		WithArgs(TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("UPDATE encounters SET status = 'preparing', started_at = NULL, finished_at = NULL, round = 0").
+		WithArgs(TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectCommit()
+
+	if err := models.ResetEncounter(mockDB, TestEncounterID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRejectFinished(t *testing.T) {
+	tests := map[string]int{
+		models.EncounterRunning:  http.StatusOK,
+		models.EncounterFinished: http.StatusConflict,
+	}
+
+	for status, expected := range tests {
+		t.Run(status, func(t *testing.T) {
+			mockDB, cleanup := NewStandardMockDB(t)
+			defer cleanup()
+
+			mockDB.Mock.ExpectQuery("SELECT status FROM encounters").
+				WithArgs(TestEncounterID).
+				WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("encounter_id")
+			c.SetParamValues("1")
+
+			handler := encounter.RejectFinished(mockDB)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			if err := handler(c); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if rec.Code != expected {
+				t.Errorf("expected status %d, got %d", expected, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createSampleCompanion creates an animal companion owned by the combatant
+func createSampleCompanion(owner models.Combatant) models.Player {
+	return models.Player{
+		AssociationID: 300,
+		Name:          "Wolf",
+		Level:         5,
+		MaxHp:         40,
+		Hp:            40,
+		Ac:            21,
+		Initiative:    owner.GetInitiative(),
+		Minion:        models.MinionLinkTo(models.MinionCompanion, owner),
+	}
+}
+
+func TestSortCombatants_PlacesMinionsBehindOwner(t *testing.T) {
+	player := CreateSamplePlayer()
+	monster := CreateSampleMonster()
+	companion := createSampleCompanion(&player)
+	// A higher initiative doesn't take the companion ahead of its owner
+	companion.Initiative = 20
+
+	// Summons whose owner left the encounter take their own place
+	orphan := CreateSampleMonster()
+	orphan.AssociationID = 201
+	orphan.Initiative = 13
+	orphan.Minion = models.MinionLink{Kind: models.MinionSummon, OwnerAssociationID: 999}
+
+	combatants := []models.Combatant{&companion, &player, &orphan, &monster}
+	models.SortCombatantsByInitiative(combatants)
+
+	expected := []models.Combatant{&monster, &orphan, &player, &companion}
+	for i, c := range expected {
+		if combatants[i] != c {
+			t.Errorf("expected %s at position %d, got %s", c.GetName(), i, combatants[i].GetName())
+		}
+	}
+
+	if !models.HasOwner(combatants, &companion) || models.HasOwner(combatants, &orphan) {
+		t.Error("expected only the companion to have an owner in the encounter")
+	}
+}
+
+func TestNextTurn_MinionsActOnOwnersTurn(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	companion := createSampleCompanion(&player)
+	monster := CreateSampleMonster()
+	monster.Initiative = 10
+
+	encounter := CreateSampleEncounter()
+	encounter.Combatants = []models.Combatant{&player, &companion, &monster}
+	encounter.Round = 0
+
+	// The companion's turn is skipped, the monster is up next
+	expectTurnAndRound(mockDB, &monster, 0)
+	expectActions(mockDB, "encounter_monsters", 3, false, monster.AssociationID)
+
+	if _, err := models.NextTurn(mockDB, &encounter); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.Turn != 2 {
+		t.Errorf("expected the monster's turn, got turn %d", encounter.Turn)
+	}
+
+	// The player's turn starts the companion's as well
+	expectTurnAndRound(mockDB, &player, 1)
+	expectActions(mockDB, "encounter_players", 3, false, player.AssociationID)
+	expectActions(mockDB, "encounter_players", 3, false, companion.AssociationID)
+
+	if _, err := models.NextTurn(mockDB, &encounter); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.Turn != 0 || encounter.Round != 1 {
+		t.Errorf("expected the player's turn in round 1, got turn %d round %d", encounter.Turn, encounter.Round)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPassTurnFrom_SkipsMinionsAndStartsTurn(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	companion := createSampleCompanion(&player)
+	monster := CreateSampleMonster()
+	monster.Initiative = 10
+	monster.Effects = []models.Effect{{ID: 8, Name: "Rage", Rounds: 1, Anchor: models.EffectAnchorStart, AnchorAssociationID: monster.AssociationID, AnchorIsMonster: true}}
+
+	encounter := CreateSampleEncounter()
+	encounter.Combatants = []models.Combatant{&player, &companion, &monster}
+	encounter.Round = 1
+
+	// The player leaves with their companion, so the monster is up next and
+	// its turn starts like on any other turn change
+	expectTurnAndRound(mockDB, &monster, 1)
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_effects").
+		WithArgs(8, TestEncounterID, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectActions(mockDB, "encounter_monsters", 3, false, monster.AssociationID)
+
+	changes, err := models.PassTurnFrom(mockDB, &encounter, &player)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.Turn != 2 || encounter.Round != 1 {
+		t.Errorf("expected the monster's turn in round 1, got turn %d round %d", encounter.Turn, encounter.Round)
+	}
+	if len(changes) != 1 || changes[0] != "Rage on Test Monster 1 has expired" {
+		t.Errorf("expected Rage to expire at the start of the monster's turn, got %v", changes)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddCompanionToEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	owner := CreateSamplePlayer()
+	owner.InitiativeStatistic = "perception"
+	owner.InitiativeDie = 2
+
+	mockDB.Mock.ExpectQuery("INSERT INTO encounter_players \\(encounter_id, name, level, max_hp, hp, ac, fort, ref, will, perception, initiative, initiative_statistic, initiative_die, minion_kind, owner_association_id, owner_is_monster\\)").
+		WithArgs(TestEncounterID, "Wolf", 5, 40, 40, 21, 0, 0, 0, 0, 12, "perception", 2, models.MinionCompanion, owner.AssociationID, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(300))
+
+	companion := models.Player{Name: " Wolf ", Level: 5, MaxHp: 40, Ac: 21, Minion: models.MinionLink{Kind: models.MinionCompanion}}
+	if err := models.AddCompanionToEncounter(mockDB, TestEncounterID, &companion, &owner); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if companion.AssociationID != 300 || !models.IsMinionOf(&companion, &owner) {
+		t.Errorf("expected the new companion to belong to its owner, got %+v", companion.Minion)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddCompanionToEncounter_Invalid(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	owner := CreateSamplePlayer()
+	tests := map[string]models.Player{
+		"no name":      {MaxHp: 10, Minion: models.MinionLink{Kind: models.MinionFamiliar}},
+		"no hp":        {Name: "Owl", Minion: models.MinionLink{Kind: models.MinionFamiliar}},
+		"unknown kind": {Name: "Owl", MaxHp: 10, Minion: models.MinionLink{Kind: models.MinionSummon}},
+	}
+
+	for name, companion := range tests {
+		if err := models.AddCompanionToEncounter(mockDB, TestEncounterID, &companion, &owner); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddSummonToEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter := CreateSampleEncounter()
+	owner := CreateSamplePlayer()
+	owner.InitiativeStatistic = "perception"
+	owner.InitiativeDie = 2
+	monster := CreateSampleMonster()
+	jsonData, _ := json.Marshal(monster.Data)
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectQuery("SELECT data FROM monsters WHERE id = \\$1").
+		WithArgs(monster.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(jsonData))
+	mockDB.Mock.ExpectQuery("SELECT COALESCE\\(MAX\\(em.enumeration\\), 0\\)").
+		WithArgs(encounter.ID, "Test Monster").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(0))
+	// The summon shares the owner's initiative and is linked to them
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_monsters").
+		WithArgs(encounter.ID, monster.ID, 0, 12, "perception", 2, 0, 35, 1, models.MinionSummon, owner.AssociationID, false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectCommit()
+	mockDB.SetupMockForGetEncounterWithCombatants(encounter)
+
+	if err := models.AddSummonToEncounter(mockDB, encounter.ID, monster.ID, 0, &owner); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReleaseMinions(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	companion := createSampleCompanion(&player)
+	combatants := []models.Combatant{&player, &companion}
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_players SET minion_kind = \\$1, owner_association_id = \\$2, owner_is_monster = \\$3").
+		WithArgs(models.MinionCompanion, 0, false, companion.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := models.ReleaseMinions(mockDB, combatants, &player); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if models.HasOwner(combatants, &companion) || !models.IsMinion(&companion) {
+		t.Error("expected the companion to stay a companion without an owner")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetDifficulty_IgnoresSummons(t *testing.T) {
+	player := CreateSamplePlayer()
+	player.Level = 3
+	monster := CreateSampleMonster()
+	summon := CreateSampleMonster()
+	summon.AssociationID = 201
+	summon.Minion = models.MinionLinkTo(models.MinionSummon, &player)
+
+	encounter := CreateSampleEncounter()
+	encounter.Players = []*models.Player{&player}
+	encounter.Combatants = []models.Combatant{&monster}
+	alone := encounter.GetDifficulty()
+
+	encounter.Combatants = []models.Combatant{&monster, &player, &summon}
+	if difficulty := encounter.GetDifficulty(); difficulty != alone {
+		t.Errorf("expected the summon not to change the difficulty %d, got %d", alone, difficulty)
+	}
+}
@@ -439,11 +439,11 @@ func (s *StandardMockDB) SetupMockForGetParty(party models.Party) {
 		WillReturnRows(rows)
 
 	// Mock the players query - include perception column
-	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "perception"})
+	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "perception", "skills"})
 	for _, player := range party.Players {
-		playerRows.AddRow(player.ID, player.Name, player.Level, player.Hp, player.Ac, player.Fort, player.Ref, player.Will, player.Perception)
+		playerRows.AddRow(player.ID, player.Name, player.Level, player.Hp, player.Ac, player.Fort, player.Ref, player.Will, player.Perception, []byte("{}"))
 	}
-	s.Mock.ExpectQuery(`SELECT id, name, level, hp, ac, fort, ref, will, perception`).
+	s.Mock.ExpectQuery(`SELECT id, name, level, hp, ac, fort, ref, will, perception, skills`).
 		WithArgs(party.ID).
 		WillReturnRows(playerRows)
 }
@@ -469,7 +469,7 @@ func (s *StandardMockDB) SetupMockForGetAllMonsters(monsters []models.Monster) {
 
 // SetupMockForGetAllEncounters sets up mock expectations for models.GetAllEncounters
 func (s *StandardMockDB) SetupMockForGetAllEncounters(encounters []models.Encounter) {
-	rows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "user_name", "party_name"})
+	rows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "user_name", "party_name", "round", "status"})
 	for _, encounter := range encounters {
 		userName := ""
 		partyName := ""
@@ -479,9 +479,9 @@ func (s *StandardMockDB) SetupMockForGetAllEncounters(encounters []models.Encoun
 		if encounter.Party != nil {
 			partyName = encounter.Party.Name
 		}
-		rows.AddRow(encounter.ID, encounter.Name, encounter.UserID, encounter.PartyID, userName, partyName)
+		rows.AddRow(encounter.ID, encounter.Name, encounter.UserID, encounter.PartyID, userName, partyName, encounter.Round, encounter.Status)
 	}
-	s.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, u\.name AS user_name, p\.name AS party_name FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 ORDER BY e\.id`).
+	s.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, u\.name AS user_name, p\.name AS party_name, e\.round, e\.status FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 ORDER BY e\.id`).
 		WithArgs(1).
 		WillReturnRows(rows)
 }
@@ -489,6 +489,7 @@ func (s *StandardMockDB) SetupMockForGetAllEncounters(encounters []models.Encoun
 // SetupMockForCreateEncounter sets up mock expectations for models.CreateEncounter
 func (s *StandardMockDB) SetupMockForCreateEncounter(encounterID int, partyID int, players []models.Player) {
 	// Mock the encounter creation
+	s.Mock.ExpectBegin()
 	s.Mock.ExpectQuery(`INSERT INTO encounters \(name, party_id, user_id\) VALUES \(\$1, \$2, \$3\) RETURNING id`).
 		WithArgs(sqlmock.AnyArg(), partyID, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(encounterID))
@@ -508,6 +509,7 @@ func (s *StandardMockDB) SetupMockForCreateEncounter(encounterID int, partyID in
 			WithArgs(encounterID, player.ID, 0, player.Hp).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 	}
+	s.Mock.ExpectCommit()
 }
 
 // SetupMockForGetEncounter sets up mock expectations for models.GetEncounter
@@ -521,25 +523,31 @@ func (s *StandardMockDB) SetupMockForGetEncounter(encounter models.Encounter) {
 		partyName = encounter.Party.Name
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn", "round", "user_name", "party_name"}).
-		AddRow(encounter.ID, encounter.Name, encounter.UserID, encounter.PartyID, encounter.Turn, encounter.Round, userName, partyName)
+	rows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn_association_id", "turn_is_monster", "round", "user_name", "party_name", "status", "started_at", "finished_at"}).
+		AddRow(encounter.ID, encounter.Name, encounter.UserID, encounter.PartyID, encounter.TurnAssociationID, encounter.TurnIsMonster, encounter.Round, userName, partyName, encounter.Status, nil, nil)
 
-	s.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn, e\.round, u\.name AS user_name, p\.name AS party_name FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
+	s.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn_association_id, e\.turn_is_monster, e\.round, u\.name AS user_name, p\.name AS party_name, e\.status, e\.started_at, e\.finished_at FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
 		WithArgs(1, encounter.ID).
 		WillReturnRows(rows)
 
 	// Mock the monsters query
-	monsterRows := sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "enumeration"})
-	s.Mock.ExpectQuery(`SELECT m\.id, m\.data, em\.level_adjustment, em\.id, em\.initiative, em\.hp as current_hp, em\.enumeration FROM monsters m JOIN encounter_monsters em ON m\.id = em\.monster_id WHERE em\.encounter_id = \$1`).
+	monsterRows := sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "temp_hp", "enumeration", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "initiative_group", "minion_kind", "owner_association_id", "owner_is_monster"})
+	s.Mock.ExpectQuery(`SELECT m\.id, m\.data, em\.level_adjustment, em\.id, em\.initiative, em\.hp as current_hp, em\.temp_hp, em\.enumeration, em\.delay_state, em\.initiative_order, em\.actions_remaining, em\.reaction_used, em\.attacks_made, em\.initiative_statistic, em\.initiative_die, em\.initiative_group, em\.minion_kind, em\.owner_association_id, em\.owner_is_monster FROM monsters m JOIN encounter_monsters em ON m\.id = em\.monster_id WHERE em\.encounter_id = \$1`).
 		WithArgs(encounter.ID).
 		WillReturnRows(monsterRows)
 
 	// Mock the players query
-	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp"})
-	s.Mock.ExpectQuery(`SELECT p\.id, p\.name, p\.level, p\.hp, p\.ac, p\.fort, p\.ref, p\.will, ep\.initiative, ep\.id as association_id, ep\.hp as current_hp FROM players p JOIN encounter_players ep ON p\.id = ep\.player_id WHERE ep\.encounter_id = \$1`).
+	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp", "temp_hp", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "skills"})
+	s.Mock.ExpectQuery(`SELECT p\.id, p\.name, p\.level, p\.hp, p\.ac, p\.fort, p\.ref, p\.will, ep\.initiative, ep\.id as association_id, ep\.hp as current_hp, ep\.temp_hp, ep\.delay_state, ep\.initiative_order, ep\.actions_remaining, ep\.reaction_used, ep\.attacks_made, ep\.initiative_statistic, ep\.initiative_die, p\.skills FROM players p JOIN encounter_players ep ON p\.id = ep\.player_id WHERE ep\.encounter_id = \$1`).
 		WithArgs(encounter.ID).
 		WillReturnRows(playerRows)
 
+	// Mock the companions query
+	s.ExpectCompanions(encounter.ID)
+
+	// Mock the custom combatants query
+	s.ExpectCustomCombatants(encounter.ID)
+
 	// Mock the GetParty query for party level calculation
 	s.SetupMockForGetParty(models.Party{ID: encounter.PartyID, Name: partyName})
 }
@@ -556,24 +564,55 @@ func (s *StandardMockDB) SetupMockForGetEncounterWithCombatants(encounter models
 		partyName = encounter.Party.Name
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn", "round", "user_name", "party_name"}).
-		AddRow(encounter.ID, encounter.Name, encounter.UserID, encounter.PartyID, encounter.Turn, encounter.Round, userName, partyName)
+	rows := sqlmock.NewRows([]string{"id", "name", "user_id", "party_id", "turn_association_id", "turn_is_monster", "round", "user_name", "party_name", "status", "started_at", "finished_at"}).
+		AddRow(encounter.ID, encounter.Name, encounter.UserID, encounter.PartyID, encounter.TurnAssociationID, encounter.TurnIsMonster, encounter.Round, userName, partyName, encounter.Status, nil, nil)
 
-	s.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn, e\.round, u\.name AS user_name, p\.name AS party_name FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
+	s.Mock.ExpectQuery(`SELECT e\.id, e\.name, e\.user_id, e\.party_id, e\.turn_association_id, e\.turn_is_monster, e\.round, u\.name AS user_name, p\.name AS party_name, e\.status, e\.started_at, e\.finished_at FROM encounters e JOIN users u ON e\.user_id = u\.id JOIN parties p ON e\.party_id = p\.id WHERE e\.user_id = \$1 AND e\.id = \$2`).
 		WithArgs(1, encounter.ID).
 		WillReturnRows(rows)
 
 	// Mock the monsters query
-	monsterRows := sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "enumeration"})
-	s.Mock.ExpectQuery(`SELECT m\.id, m\.data, em\.level_adjustment, em\.id, em\.initiative, em\.hp as current_hp, em\.enumeration FROM monsters m JOIN encounter_monsters em ON m\.id = em\.monster_id WHERE em\.encounter_id = \$1`).
+	monsterRows := sqlmock.NewRows([]string{"id", "data", "level_adjustment", "id", "initiative", "current_hp", "temp_hp", "enumeration", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "initiative_group", "minion_kind", "owner_association_id", "owner_is_monster"})
+	s.Mock.ExpectQuery(`SELECT m\.id, m\.data, em\.level_adjustment, em\.id, em\.initiative, em\.hp as current_hp, em\.temp_hp, em\.enumeration, em\.delay_state, em\.initiative_order, em\.actions_remaining, em\.reaction_used, em\.attacks_made, em\.initiative_statistic, em\.initiative_die, em\.initiative_group, em\.minion_kind, em\.owner_association_id, em\.owner_is_monster FROM monsters m JOIN encounter_monsters em ON m\.id = em\.monster_id WHERE em\.encounter_id = \$1`).
 		WithArgs(encounter.ID).
 		WillReturnRows(monsterRows)
 
 	// Mock the players query
-	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp"})
-	s.Mock.ExpectQuery(`SELECT p\.id, p\.name, p\.level, p\.hp, p\.ac, p\.fort, p\.ref, p\.will, ep\.initiative, ep\.id as association_id, ep\.hp as current_hp FROM players p JOIN encounter_players ep ON p\.id = ep\.player_id WHERE ep\.encounter_id = \$1`).
+	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "initiative", "association_id", "current_hp", "temp_hp", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "initiative_statistic", "initiative_die", "skills"})
+	s.Mock.ExpectQuery(`SELECT p\.id, p\.name, p\.level, p\.hp, p\.ac, p\.fort, p\.ref, p\.will, ep\.initiative, ep\.id as association_id, ep\.hp as current_hp, ep\.temp_hp, ep\.delay_state, ep\.initiative_order, ep\.actions_remaining, ep\.reaction_used, ep\.attacks_made, ep\.initiative_statistic, ep\.initiative_die, p\.skills FROM players p JOIN encounter_players ep ON p\.id = ep\.player_id WHERE ep\.encounter_id = \$1`).
 		WithArgs(encounter.ID).
 		WillReturnRows(playerRows)
+
+	// Mock the companions query
+	s.ExpectCompanions(encounter.ID)
+
+	// Mock the custom combatants query
+	s.ExpectCustomCombatants(encounter.ID)
+}
+
+// ExpectCompanions sets up the query for the encounter's companions
+func (s *StandardMockDB) ExpectCompanions(encounterID int, companions ...models.Player) {
+	rows := sqlmock.NewRows([]string{"id", "name", "level", "max_hp", "hp", "temp_hp", "ac", "fort", "ref", "will", "perception", "initiative", "initiative_statistic", "initiative_die", "delay_state", "initiative_order", "actions_remaining", "reaction_used", "attacks_made", "minion_kind", "owner_association_id", "owner_is_monster"})
+	for _, c := range companions {
+		rows.AddRow(c.AssociationID, c.Name, c.Level, c.MaxHp, c.Hp, c.TempHp, c.Ac, c.Fort, c.Ref, c.Will, c.Perception, c.Initiative, c.InitiativeStatistic, c.InitiativeDie, c.DelayState, c.InitiativeOrder, c.ActionsRemaining, c.ReactionUsed, c.AttacksMade, c.Minion.Kind, c.Minion.OwnerAssociationID, c.Minion.OwnerIsMonster)
+	}
+
+	s.Mock.ExpectQuery(`SELECT ep\.id, ep\.name, ep\.level, ep\.max_hp, .* FROM encounter_players ep WHERE ep\.encounter_id = \$1 AND ep\.player_id IS NULL`).
+		WithArgs(encounterID).
+		WillReturnRows(rows)
+}
+
+// ExpectCustomCombatants sets up the query for the encounter's custom
+// combatants
+func (s *StandardMockDB) ExpectCustomCombatants(encounterID int, customs ...models.CustomCombatant) {
+	rows := sqlmock.NewRows([]string{"id", "name", "level", "max_hp", "hp", "temp_hp", "ac", "fort", "ref", "will", "perception", "attacks", "enumeration", "initiative", "initiative_statistic", "initiative_die", "initiative_order", "initiative_group", "delay_state", "actions_remaining", "reaction_used", "attacks_made", "minion_kind", "owner_association_id", "owner_is_monster"})
+	for _, c := range customs {
+		rows.AddRow(c.AssociationID, c.Name, c.Level, c.MaxHp, c.Hp, c.TempHp, c.Ac, c.Fort, c.Ref, c.Will, c.Perception, c.Attacks, c.Enumeration, c.Initiative, c.InitiativeStatistic, c.InitiativeDie, c.InitiativeOrder, c.InitiativeGroup, c.DelayState, c.ActionsRemaining, c.ReactionUsed, c.AttacksMade, c.Minion.Kind, c.Minion.OwnerAssociationID, c.Minion.OwnerIsMonster)
+	}
+
+	s.Mock.ExpectQuery(`SELECT cc\.id, cc\.name, cc\.level, cc\.max_hp, .* FROM encounter_custom_combatants cc WHERE cc\.encounter_id = \$1`).
+		WithArgs(encounterID).
+		WillReturnRows(rows)
 }
 
 // SetupMockForUpdateEncounter sets up mock expectations for models.UpdateEncounter
@@ -657,7 +696,7 @@ func (s *StandardMockDB) SetupMockForGetMonster(monster models.Monster) {
 
 // SetupMockForAddMonsterToEncounter sets up mock expectations for models.AddMonsterToEncounter
 func (s *StandardMockDB) SetupMockForAddMonsterToEncounter(encounter models.Encounter) {
-	// 1. Transaction begin (though the code doesn't use it properly)
+	// 1. Transaction begin
 	s.Mock.ExpectBegin()
 
 	// 2. Get monster data query
@@ -671,8 +710,8 @@ func (s *StandardMockDB) SetupMockForAddMonsterToEncounter(encounter models.Enco
 		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(0))
 
 	// 4. Insert into encounter_monsters
-	s.Mock.ExpectExec(`INSERT INTO encounter_monsters \(encounter_id, monster_id, level_adjustment, initiative, hp, enumeration\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
-		WithArgs(encounter.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+	s.Mock.ExpectExec(`INSERT INTO encounter_monsters \(encounter_id, monster_id, level_adjustment, initiative, initiative_statistic, initiative_die, initiative_group, hp, enumeration, minion_kind, owner_association_id, owner_is_monster\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12\)`).
+		WithArgs(encounter.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// 5. Transaction commit
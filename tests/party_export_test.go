@@ -25,19 +25,19 @@ func TestExportAllParties(t *testing.T) {
 		WillReturnRows(partyRows)
 
 	// Mock the players query for party 1
-	playerRows1 := sqlmock.NewRows([]string{"name", "level", "hp", "ac", "fort", "ref", "will", "perception"}).
-		AddRow("Player 1", 5, 45, 18, 8, 6, 7, 10).
-		AddRow("Player 2", 4, 40, 17, 7, 5, 6, 9)
+	playerRows1 := sqlmock.NewRows([]string{"name", "level", "hp", "ac", "fort", "ref", "will", "perception", "skills"}).
+		AddRow("Player 1", 5, 45, 18, 8, 6, 7, 10, []byte(`{"stealth":9}`)).
+		AddRow("Player 2", 4, 40, 17, 7, 5, 6, 9, []byte("{}"))
 
-	mockDB.Mock.ExpectQuery(`SELECT name, level, hp, ac, fort, ref, will, perception FROM players WHERE party_id = \$1 ORDER BY id`).
+	mockDB.Mock.ExpectQuery(`SELECT name, level, hp, ac, fort, ref, will, perception, skills FROM players WHERE party_id = \$1 ORDER BY id`).
 		WithArgs(1).
 		WillReturnRows(playerRows1)
 
 	// Mock the players query for party 2
-	playerRows2 := sqlmock.NewRows([]string{"name", "level", "hp", "ac", "fort", "ref", "will", "perception"}).
-		AddRow("Player 3", 6, 50, 19, 9, 7, 8, 11)
+	playerRows2 := sqlmock.NewRows([]string{"name", "level", "hp", "ac", "fort", "ref", "will", "perception", "skills"}).
+		AddRow("Player 3", 6, 50, 19, 9, 7, 8, 11, []byte("{}"))
 
-	mockDB.Mock.ExpectQuery(`SELECT name, level, hp, ac, fort, ref, will, perception FROM players WHERE party_id = \$1 ORDER BY id`).
+	mockDB.Mock.ExpectQuery(`SELECT name, level, hp, ac, fort, ref, will, perception, skills FROM players WHERE party_id = \$1 ORDER BY id`).
 		WithArgs(2).
 		WillReturnRows(playerRows2)
 
@@ -62,7 +62,7 @@ func TestExportAllParties(t *testing.T) {
 
 	// Verify player 1 data
 	player1 := exportData.Parties[0].Players[0]
-	if player1.Name != "Player 1" || player1.Level != 5 || player1.Hp != 45 {
+	if player1.Name != "Player 1" || player1.Level != 5 || player1.Hp != 45 || player1.Skills["stealth"] != 9 {
 		t.Errorf("Player 1 data mismatch: %+v", player1)
 	}
 
@@ -136,8 +136,8 @@ func TestImportParties(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	// Mock insert player for party 1
-	mockDB.Mock.ExpectExec(`INSERT INTO players \( name, level, hp, ac, fort, ref, will, perception, party_id \) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\)`).
-		WithArgs("Imported Player 1", 5, 45, 18, 8, 6, 7, 10, 1).
+	mockDB.Mock.ExpectExec(`INSERT INTO players \( name, level, hp, ac, fort, ref, will, perception, skills, party_id \) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10\)`).
+		WithArgs("Imported Player 1", 5, 45, 18, 8, 6, 7, 10, []byte("{}"), 1).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Mock insert party 2
@@ -146,8 +146,8 @@ func TestImportParties(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
 
 	// Mock insert player for party 2
-	mockDB.Mock.ExpectExec(`INSERT INTO players \( name, level, hp, ac, fort, ref, will, perception, party_id \) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\)`).
-		WithArgs("Imported Player 2", 6, 50, 19, 9, 7, 8, 11, 2).
+	mockDB.Mock.ExpectExec(`INSERT INTO players \( name, level, hp, ac, fort, ref, will, perception, skills, party_id \) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10\)`).
+		WithArgs("Imported Player 2", 6, 50, 19, 9, 7, 8, 11, []byte("{}"), 2).
 		WillReturnResult(sqlmock.NewResult(2, 1))
 
 	// Mock commit
@@ -255,9 +255,9 @@ func TestGetParty_Success(t *testing.T) {
 		WillReturnRows(partyRows)
 
 	// Mock players query
-	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "perception"}).
-		AddRow(1, "Player 1", 5, 45, 18, 8, 6, 7, 5)
-	mockDB.Mock.ExpectQuery("SELECT id, name, level, hp, ac, fort, ref, will, perception FROM players WHERE party_id = \\$1").
+	playerRows := sqlmock.NewRows([]string{"id", "name", "level", "hp", "ac", "fort", "ref", "will", "perception", "skills"}).
+		AddRow(1, "Player 1", 5, 45, 18, 8, 6, 7, 5, []byte(`{"stealth":9}`))
+	mockDB.Mock.ExpectQuery("SELECT id, name, level, hp, ac, fort, ref, will, perception, skills FROM players WHERE party_id = \\$1").
 		WithArgs(partyID).
 		WillReturnRows(playerRows)
 
@@ -282,6 +282,10 @@ func TestGetParty_Success(t *testing.T) {
 		t.Errorf("expected player party ID %d, got %d", partyID, party.Players[0].PartyID)
 	}
 
+	if party.Players[0].Skills["stealth"] != 9 {
+		t.Errorf("expected the player's skills to be loaded, got %v", party.Players[0].Skills)
+	}
+
 	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unfulfilled expectations: %s", err)
 	}
@@ -558,14 +562,14 @@ func TestParty_UpdateWithPlayers_Success_NewPlayer(t *testing.T) {
 
 	// Mock existing player updates
 	for _, player := range party.Players[:2] { // First two are existing
-		mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8 WHERE id = \\$9 AND party_id = \\$10").
-			WithArgs(player.Name, player.Level, player.Ac, player.Hp, player.Fort, player.Ref, player.Will, player.Perception, player.ID, party.ID).
+		mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8, skills = \\$9 WHERE id = \\$10 AND party_id = \\$11").
+			WithArgs(player.Name, player.Level, player.Ac, player.Hp, player.Fort, player.Ref, player.Will, player.Perception, []byte("{}"), player.ID, party.ID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 	}
 
 	// Mock new player insert
-	mockDB.Mock.ExpectQuery("INSERT INTO players \\(name, level, ac, hp, fort, ref, will, perception, party_id\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7, \\$8, \\$9\\) RETURNING id").
-		WithArgs(newPlayer.Name, newPlayer.Level, newPlayer.Ac, newPlayer.Hp, newPlayer.Fort, newPlayer.Ref, newPlayer.Will, newPlayer.Perception, party.ID).
+	mockDB.Mock.ExpectQuery("INSERT INTO players \\(name, level, ac, hp, fort, ref, will, perception, skills, party_id\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7, \\$8, \\$9, \\$10\\) RETURNING id").
+		WithArgs(newPlayer.Name, newPlayer.Level, newPlayer.Ac, newPlayer.Hp, newPlayer.Fort, newPlayer.Ref, newPlayer.Will, newPlayer.Perception, []byte("{}"), party.ID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
 
 	// Mock transaction commit
@@ -603,8 +607,8 @@ func TestParty_UpdateWithPlayers_Success_DeletePlayers(t *testing.T) {
 
 	// Mock existing player updates
 	for _, player := range party.Players {
-		mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8 WHERE id = \\$9 AND party_id = \\$10").
-			WithArgs(player.Name, player.Level, player.Ac, player.Hp, player.Fort, player.Ref, player.Will, player.Perception, player.ID, party.ID).
+		mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8, skills = \\$9 WHERE id = \\$10 AND party_id = \\$11").
+			WithArgs(player.Name, player.Level, player.Ac, player.Hp, player.Fort, player.Ref, player.Will, player.Perception, []byte("{}"), player.ID, party.ID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 	}
 
@@ -672,13 +676,13 @@ func TestParty_UpdateWithPlayers_PlayerNotFound(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Mock first player update (success)
-	mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8 WHERE id = \\$9 AND party_id = \\$10").
-		WithArgs(party.Players[0].Name, party.Players[0].Level, party.Players[0].Ac, party.Players[0].Hp, party.Players[0].Fort, party.Players[0].Ref, party.Players[0].Will, party.Players[0].Perception, party.Players[0].ID, party.ID).
+	mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8, skills = \\$9 WHERE id = \\$10 AND party_id = \\$11").
+		WithArgs(party.Players[0].Name, party.Players[0].Level, party.Players[0].Ac, party.Players[0].Hp, party.Players[0].Fort, party.Players[0].Ref, party.Players[0].Will, party.Players[0].Perception, []byte("{}"), party.Players[0].ID, party.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Mock second player update (no rows affected)
-	mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8 WHERE id = \\$9 AND party_id = \\$10").
-		WithArgs(party.Players[1].Name, party.Players[1].Level, party.Players[1].Ac, party.Players[1].Hp, party.Players[1].Fort, party.Players[1].Ref, party.Players[1].Will, party.Players[1].Perception, party.Players[1].ID, party.ID).
+	mockDB.Mock.ExpectExec("UPDATE players SET name = \\$1, level = \\$2, ac = \\$3, hp = \\$4, fort = \\$5, ref = \\$6, will = \\$7, perception = \\$8, skills = \\$9 WHERE id = \\$10 AND party_id = \\$11").
+		WithArgs(party.Players[1].Name, party.Players[1].Level, party.Players[1].Ac, party.Players[1].Hp, party.Players[1].Fort, party.Players[1].Ref, party.Players[1].Will, party.Players[1].Perception, []byte("{}"), party.Players[1].ID, party.ID).
 		WillReturnResult(sqlmock.NewResult(1, 0))
 
 	// Mock transaction rollback
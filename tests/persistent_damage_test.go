@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// fixRolls makes every die roll return the given value until the returned
+// function is called
+func fixRolls(value int) func() {
+	original := models.RollDie
+	models.RollDie = func(sides int) int {
+		return min(value, sides)
+	}
+
+	return func() {
+		models.RollDie = original
+	}
+}
+
+func TestRollFormula(t *testing.T) {
+	defer fixRolls(4)()
+
+	tests := []struct {
+		formula  string
+		expected int
+		wantErr  bool
+	}{
+		{formula: "2d6", expected: 8},
+		{formula: "1d8+3", expected: 7},
+		{formula: "d4 - 1", expected: 3},
+		{formula: "5", expected: 5},
+		{formula: "1d4-10", expected: 0},
+		{formula: "fire", wantErr: true},
+		{formula: "", wantErr: true},
+		{formula: "101d6", wantErr: true},
+		{formula: "1d1000", wantErr: true},
+		{formula: "60d6+60d6", wantErr: true},
+		{formula: "100d100", expected: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			total, err := models.RollFormula(tt.formula)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got nil", tt.formula)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if total != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, total)
+			}
+		})
+	}
+}
+
+func TestAddPersistentDamage(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+
+	mockDB.Mock.ExpectQuery(`INSERT INTO persistent_damage \(encounter_id, encounter_monster_id, formula, damage_type\)`).
+		WithArgs(TestEncounterID, monster.AssociationID, "2d6", "fire").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	if err := models.AddPersistentDamage(mockDB, TestEncounterID, &monster, "2d6", " Fire "); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(monster.GetPersistentDamage()) != 1 || monster.GetPersistentDamage()[0].ID != 3 {
+		t.Errorf("expected persistent damage to be added, got %+v", monster.GetPersistentDamage())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddPersistentDamage_InvalidFormula(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+
+	if err := models.AddPersistentDamage(mockDB, TestEncounterID, &monster, "lots", "fire"); err == nil {
+		t.Error("expected error for an invalid formula")
+	}
+}
+
+func TestProcessTurnEnd_PersistentDamageContinues(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+	defer fixRolls(3)()
+
+	monster := CreateSampleMonster()
+	monster.PersistentDamage = []models.PersistentDamage{{ID: 3, Formula: "2d6", DamageType: "fire", Assisted: true}}
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET hp").
+		WithArgs(monster.GetHp()-6, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("UPDATE persistent_damage SET assisted = \\$1 WHERE id = \\$2 AND encounter_id = \\$3 AND encounter_monster_id = \\$4").
+		WithArgs(false, 3, TestEncounterID, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	changes, err := models.ProcessTurnEnd(mockDB, TestEncounterID, &monster)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(changes) != 2 || !strings.Contains(changes[1], "flat check 3 vs DC 10") {
+		t.Errorf("expected damage and failed flat check to be reported, got %v", changes)
+	}
+	if monster.GetPersistentDamage()[0].Assisted {
+		t.Error("expected assisted recovery to be used up")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProcessTurnEnd_PersistentDamageEndsAndRespectsImmunity(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+	defer fixRolls(15)()
+
+	monster := CreateSampleMonster()
+	monster.PersistentDamage = []models.PersistentDamage{{ID: 5, Formula: "5", DamageType: "fire"}}
+	monster.Data.System.Attributes.Immunities = append(monster.Data.System.Attributes.Immunities, struct {
+		Type string `json:"type"`
+	}{Type: "persistent-damage"})
+
+	mockDB.Mock.ExpectExec("UPDATE encounter_monsters SET hp").
+		WithArgs(monster.GetHp(), monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.Mock.ExpectExec("DELETE FROM persistent_damage WHERE id = \\$1 AND encounter_id = \\$2 AND encounter_monster_id = \\$3").
+		WithArgs(5, TestEncounterID, monster.AssociationID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	changes, err := models.ProcessTurnEnd(mockDB, TestEncounterID, &monster)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(changes) != 2 || !strings.Contains(changes[0], "immune to persistent-damage") {
+		t.Errorf("expected immunity to be reported, got %v", changes)
+	}
+	if !strings.Contains(changes[1], "ends 5 persistent fire") {
+		t.Errorf("expected persistent damage to end, got %v", changes)
+	}
+	if len(monster.GetPersistentDamage()) != 0 {
+		t.Errorf("expected persistent damage to be removed, got %+v", monster.GetPersistentDamage())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRemovePersistentDamage_ScopedToCombatant(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.PersistentDamage = []models.PersistentDamage{{ID: 7, Formula: "1d6", DamageType: "bleed"}}
+
+	// Only the player's own persistent damage in this encounter is removed
+	mockDB.Mock.ExpectExec("DELETE FROM persistent_damage WHERE id = \\$1 AND encounter_id = \\$2 AND encounter_player_id = \\$3").
+		WithArgs(7, TestEncounterID, player.AssociationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := models.RemovePersistentDamage(mockDB, TestEncounterID, &player, 7); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(player.GetPersistentDamage()) != 0 {
+		t.Errorf("expected persistent damage to be removed, got %+v", player.GetPersistentDamage())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// expectPartyProgress expects the party's XP total and a single award
+func expectPartyProgress(mockDB *StandardMockDB, partyID int, xp int) {
+	mockDB.Mock.ExpectQuery("SELECT xp FROM parties").
+		WithArgs(partyID).
+		WillReturnRows(sqlmock.NewRows([]string{"xp"}).AddRow(xp))
+	mockDB.Mock.ExpectQuery("SELECT id, party_id, encounter_id, encounter_name, xp, created_at FROM party_xp_awards WHERE party_id = \\$1").
+		WithArgs(partyID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "party_id", "encounter_id", "encounter_name", "xp", "created_at"}).
+			AddRow(1, partyID, TestEncounterID, "Test Encounter", xp, time.Now()))
+}
+
+// finishedEncounter creates an encounter that finished a minute ago
+func finishedEncounter() models.Encounter {
+	finishedAt := time.Now().Add(-time.Minute)
+
+	encounter := CreateSampleEncounter()
+	encounter.Status = models.EncounterFinished
+	encounter.FinishedAt = &finishedAt
+	return encounter
+}
+
+func TestGetEarnedXp(t *testing.T) {
+	encounter := CreateSampleEncounter()
+	encounter.Players = createSampleParty(5, 5, 5, 5)
+
+	boss := CreateSampleMonster()
+	boss.Data.System.Details.Level.Value = 7
+	boss.Data.System.Attributes.Hp.Value = 0
+	survivor := CreateSampleMonster()
+	survivor.AssociationID = 201
+	survivor.Enumeration = 2
+	hazard := createSampleHazard(5, true)
+	encounter.Combatants = []models.Combatant{&boss, &survivor, &hazard, encounter.Players[0]}
+
+	// The creature still standing isn't counted, the hazard always is
+	earned := encounter.GetEarnedXp()
+	if len(earned.Creatures) != 2 || earned.Creatures[0].Name != boss.GetName() || earned.Creatures[1].Name != hazard.GetName() {
+		t.Fatalf("expected the boss and the hazard, got %+v", earned.Creatures)
+	}
+	if earned.Xp != 120 {
+		t.Errorf("expected 120 XP, got %d", earned.Xp)
+	}
+
+	// The budget still counts everyone
+	if budget := encounter.GetXpBudget(); len(budget.Creatures) != 3 {
+		t.Errorf("expected the budget to count all three, got %+v", budget.Creatures)
+	}
+}
+
+func TestAwardXp(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	// XP is only awarded once the encounter has finished
+	running := CreateSampleEncounter()
+	running.Status = models.EncounterRunning
+	if err := models.AwardXp(mockDB, &running, 80); err == nil {
+		t.Error("expected an error for a running encounter")
+	}
+
+	encounter := finishedEncounter()
+	if err := models.AwardXp(mockDB, &encounter, -10); err == nil {
+		t.Error("expected an error for negative XP")
+	}
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectQuery("INSERT INTO party_xp_awards").
+		WithArgs(TestPartyID, TestEncounterID, "Test Encounter", 90).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()))
+	mockDB.Mock.ExpectExec("UPDATE parties SET xp = xp \\+ \\$1").
+		WithArgs(90, TestPartyID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectCommit()
+
+	if err := models.AwardXp(mockDB, &encounter, 90); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.XpAward == nil || encounter.XpAward.ID != 3 || encounter.XpAward.Xp != 90 {
+		t.Errorf("expected award 3 of 90 XP, got %+v", encounter.XpAward)
+	}
+
+	// The party can't earn the XP twice
+	if err := models.AwardXp(mockDB, &encounter, 90); err == nil {
+		t.Error("expected an error when awarding XP twice")
+	}
+
+	// Not even when another request awarded it first
+	other := finishedEncounter()
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectQuery("INSERT INTO party_xp_awards").
+		WithArgs(TestPartyID, TestEncounterID, "Test Encounter", 90).
+		WillReturnError(&pq.Error{Code: "23505"})
+	mockDB.Mock.ExpectRollback()
+
+	if err := models.AwardXp(mockDB, &other, 90); err == nil || !strings.Contains(err.Error(), "already earned") {
+		t.Errorf("expected the award to be rejected, got %v", err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLoadXpAward(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	encounter := finishedEncounter()
+
+	mockDB.Mock.ExpectQuery("SELECT .+ FROM party_xp_awards WHERE encounter_id = \\$1").
+		WithArgs(TestEncounterID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "party_id", "encounter_id", "encounter_name", "xp", "created_at"}).
+			AddRow(3, TestPartyID, TestEncounterID, "Test Encounter", 80, time.Now()))
+
+	if err := models.LoadXpAward(mockDB, &encounter); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.XpAward == nil || encounter.XpAward.Xp != 80 || *encounter.XpAward.EncounterID != TestEncounterID {
+		t.Errorf("expected an award of 80 XP, got %+v", encounter.XpAward)
+	}
+
+	// Encounters that haven't finished have no award to look up
+	running := CreateSampleEncounter()
+	if err := models.LoadXpAward(mockDB, &running); err != nil || running.XpAward != nil {
+		t.Errorf("expected no award and no error, got %+v and %v", running.XpAward, err)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLoadPartyProgress(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	expectPartyProgress(mockDB, TestPartyID, 1040)
+
+	party := CreateSampleParty()
+	if err := models.LoadPartyProgress(mockDB, &party); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if party.Xp != 1040 || len(party.XpAwards) != 1 || party.XpAwards[0].EncounterName != "Test Encounter" {
+		t.Errorf("expected 1040 XP from one award, got %d from %+v", party.Xp, party.XpAwards)
+	}
+	if !party.CanLevelUp() {
+		t.Error("expected the party to be able to level up")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLevelUpParty(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectExec("UPDATE parties SET xp = xp - \\$1 WHERE id = \\$2 AND xp >= \\$1").
+		WithArgs(models.XpPerLevel, TestPartyID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectExec("UPDATE players SET level = level \\+ 1 WHERE party_id = \\$1 AND level < \\$2").
+		WithArgs(TestPartyID, models.MaxLevel).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mockDB.Mock.ExpectCommit()
+
+	if err := models.LevelUpParty(mockDB, TestPartyID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Without 1000 XP nothing changes
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectExec("UPDATE parties SET xp = xp - \\$1").
+		WithArgs(models.XpPerLevel, TestPartyID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.Mock.ExpectRollback()
+
+	if err := models.LevelUpParty(mockDB, TestPartyID); err == nil {
+		t.Error("expected an error without enough XP")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createRuledCondition creates a condition carrying the given rule elements,
+// the way they appear in the condition JSON
+func createRuledCondition(id int, name string, value int, rules ...map[string]any) models.Condition {
+	condition := createValuedCondition(id, name, value)
+	for _, rule := range rules {
+		condition.Data.System.Rules = append(condition.Data.System.Rules, rule)
+	}
+	return condition
+}
+
+func frightened(value int) models.Condition {
+	return createRuledCondition(1, "Frightened", value, map[string]any{
+		"key": "FlatModifier", "selector": "all", "slug": "frightened", "type": "status", "value": "-@item.badge.value",
+	})
+}
+
+func sickened(value int) models.Condition {
+	return createRuledCondition(2, "Sickened", value, map[string]any{
+		"key": "FlatModifier", "selector": "all", "slug": "sickened", "type": "status", "value": "-@item.badge.value",
+	})
+}
+
+func offGuard() models.Condition {
+	condition := createRuledCondition(3, "Off-Guard", 0, map[string]any{
+		"key": "FlatModifier", "selector": "ac", "slug": "off-guard", "type": "circumstance", "value": float64(-2),
+	})
+	condition.Data.System.Value.IsValued = false
+	return condition
+}
+
+func clumsy(value int) models.Condition {
+	return createRuledCondition(4, "Clumsy", value, map[string]any{
+		"key": "FlatModifier", "selector": "dex-based", "slug": "clumsy", "type": "status", "value": "-@item.badge.value",
+	})
+}
+
+func bless() models.Effect {
+	return models.Effect{Name: "Bless", Rounds: 10, Modifier: models.EffectModifier{Selector: "attack", Type: "status", Value: 1}}
+}
+
+func TestCondition_GetFlatModifiers(t *testing.T) {
+	drained := createRuledCondition(5, "Drained", 2,
+		map[string]any{"key": "FlatModifier", "selector": "con-based", "slug": "drained", "type": "status", "value": "-1 * @item.badge.value"},
+		map[string]any{"key": "FlatModifier", "selector": "hp", "slug": "drained", "type": "status", "value": "min(-1 * @actor.level,-1) * @item.badge.value"},
+		map[string]any{"key": "LoseHitPoints", "value": "max(1,@actor.level) * @item.badge.value"},
+	)
+
+	modifiers := drained.GetFlatModifiers()
+	if len(modifiers) != 1 {
+		t.Fatalf("expected only the evaluable modifier, got %+v", modifiers)
+	}
+
+	if modifiers[0].Value != -2 || modifiers[0].Type != "status" || modifiers[0].Selectors[0] != "con-based" {
+		t.Errorf("unexpected modifier %+v", modifiers[0])
+	}
+}
+
+func TestCondition_GetFlatModifiers_SkipsPredicates(t *testing.T) {
+	deafened := createRuledCondition(6, "Deafened", 0, map[string]any{
+		"key": "FlatModifier", "predicate": []any{"check:statistic:initiative"}, "selector": []any{"perception", "skill-check"}, "type": "status", "value": float64(-2),
+	})
+
+	if len(deafened.GetFlatModifiers()) != 0 {
+		t.Errorf("expected modifiers with predicates to be skipped, got %+v", deafened.GetFlatModifiers())
+	}
+}
+
+func TestConditionModifier_StackingRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []models.Condition
+		effects    []models.Effect
+		selectors  []string
+		expected   int
+	}{
+		{
+			name:       "only the worst status penalty counts",
+			conditions: []models.Condition{frightened(2), sickened(1)},
+			selectors:  []string{"all", "ac"},
+			expected:   -2,
+		},
+		{
+			name:       "status and circumstance penalties stack",
+			conditions: []models.Condition{frightened(1), offGuard()},
+			selectors:  []string{"all", "ac"},
+			expected:   -3,
+		},
+		{
+			name:       "selectors that don't match are ignored",
+			conditions: []models.Condition{offGuard()},
+			selectors:  []string{"all", "saving-throw", "fortitude"},
+			expected:   0,
+		},
+		{
+			name:       "an effect's bonus offsets a condition's penalty",
+			conditions: []models.Condition{frightened(1)},
+			effects:    []models.Effect{bless()},
+			selectors:  []string{"all", "attack"},
+			expected:   0,
+		},
+		{
+			name:      "only the highest status bonus of the effects counts",
+			effects:   []models.Effect{bless(), {Name: "Heroism", Modifier: models.EffectModifier{Selector: "all", Type: "status", Value: 2}}},
+			selectors: []string{"all", "attack"},
+			expected:  2,
+		},
+		{
+			name:      "effects without a modifier change nothing",
+			effects:   []models.Effect{{Name: "Shield"}},
+			selectors: []string{"all", "ac"},
+			expected:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := models.ConditionModifier(tt.conditions, tt.effects, tt.selectors); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMonster_ConditionsAdjustStatistics(t *testing.T) {
+	monster := CreateSampleMonster()
+	baseAc := monster.GetAc()
+	baseFort := monster.GetFort()
+	baseRef := monster.GetRef()
+	basePerception := monster.GetPerceptionMod()
+
+	monster.Conditions = []models.Condition{clumsy(1), offGuard()}
+
+	if monster.GetAc() != baseAc-3 {
+		t.Errorf("expected AC %d, got %d", baseAc-3, monster.GetAc())
+	}
+	if monster.GetRef() != baseRef-1 {
+		t.Errorf("expected Ref %d, got %d", baseRef-1, monster.GetRef())
+	}
+	if monster.GetFort() != baseFort {
+		t.Errorf("expected Fort %d, got %d", baseFort, monster.GetFort())
+	}
+	if monster.GetPerceptionMod() != basePerception {
+		t.Errorf("expected perception %d, got %d", basePerception, monster.GetPerceptionMod())
+	}
+	if !monster.IsOffGuard() {
+		t.Error("expected monster to be off-guard")
+	}
+}
+
+func TestPlayer_ConditionsAdjustStatistics(t *testing.T) {
+	player := CreateSamplePlayer()
+	player.Conditions = []models.Condition{frightened(2)}
+
+	if player.GetAc() != player.Ac-2 {
+		t.Errorf("expected AC %d, got %d", player.Ac-2, player.GetAc())
+	}
+	if player.GetWill() != player.Will-2 {
+		t.Errorf("expected Will %d, got %d", player.Will-2, player.GetWill())
+	}
+	if player.GetPerceptionMod() != player.Perception-2 {
+		t.Errorf("expected perception %d, got %d", player.Perception-2, player.GetPerceptionMod())
+	}
+}
+
+func TestPlayer_EffectsAdjustStatistics(t *testing.T) {
+	player := CreateSamplePlayer()
+	player.Effects = []models.Effect{{Name: "Shield", Modifier: models.EffectModifier{Selector: "ac", Type: "circumstance", Value: 1}}}
+	player.Conditions = []models.Condition{offGuard()}
+
+	if player.GetAc() != player.Ac-1 {
+		t.Errorf("expected Shield and off-guard to count towards AC %d, got %d", player.Ac-1, player.GetAc())
+	}
+
+	player.Conditions = nil
+	if player.GetAc() != player.Ac+1 {
+		t.Errorf("expected AC %d with Shield, got %d", player.Ac+1, player.GetAc())
+	}
+	if player.GetWill() != player.Will {
+		t.Errorf("expected Shield not to change Will %d, got %d", player.Will, player.GetWill())
+	}
+}
+
+func TestMonster_GetSkills_IncludesConditionPenalties(t *testing.T) {
+	monster := CreateSampleMonster()
+	monster.Data.System.Skills = map[string]struct {
+		Base int `json:"base"`
+	}{"stealth": {Base: 1}}
+	monster.Conditions = []models.Condition{frightened(2)}
+
+	if skills := monster.GetSkills(); !strings.Contains(skills, "Stealth -1") {
+		t.Errorf("expected stealth to include the frightened penalty, got %q", skills)
+	}
+}
+
+func TestAttackAndSpellSelectors(t *testing.T) {
+	conditions := []models.Condition{clumsy(2)}
+
+	ranged := models.Item{}
+	ranged.System.Range = map[string]any{"increment": float64(60)}
+	melee := models.Item{}
+
+	if models.ConditionModifier(conditions, nil, models.AttackSelectors(ranged)) != -2 {
+		t.Error("expected clumsy to apply to ranged attacks")
+	}
+	if models.ConditionModifier(conditions, nil, models.AttackSelectors(melee)) != 0 {
+		t.Error("expected clumsy not to apply to melee attacks")
+	}
+
+	entry := models.Item{}
+	entry.System.Tradition.Value = "occult"
+	if entry.GetSpellcastingAttribute() != "cha" {
+		t.Errorf("expected occult casters to use cha, got %s", entry.GetSpellcastingAttribute())
+	}
+	if models.ConditionModifier([]models.Condition{frightened(1)}, nil, models.SpellDCSelectors(entry)) != -1 {
+		t.Error("expected frightened to apply to spell DCs")
+	}
+}
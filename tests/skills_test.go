@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"testing"
+
+	"pf2.encounterbrew.com/internal/models"
+)
+
+func TestParseSkills(t *testing.T) {
+	skills, err := models.ParseSkills(" Stealth +9, deception 7,Lore -1 ")
+	if err == nil {
+		t.Fatalf("expected an error for an unknown skill, got %v", skills)
+	}
+
+	skills, err = models.ParseSkills("Stealth +9, deception 7, Society -1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(skills) != 3 || skills["stealth"] != 9 || skills["deception"] != 7 || skills["society"] != -1 {
+		t.Errorf("unexpected skills %v", skills)
+	}
+
+	if formatted := models.FormatSkills(skills); formatted != "Deception +7, Society -1, Stealth +9" {
+		t.Errorf("unexpected formatted skills %q", formatted)
+	}
+
+	for _, input := range []string{"Stealth", "Stealth +x"} {
+		if _, err := models.ParseSkills(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+
+	if skills, err := models.ParseSkills(""); err != nil || len(skills) != 0 {
+		t.Errorf("expected no skills for an empty input, got %v, %v", skills, err)
+	}
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// expectCreateEncounter expects a new encounter for a party of one player
+func expectCreateEncounter(mockDB *StandardMockDB, name string, partyID int, encounterID int) {
+	mockDB.Mock.ExpectQuery("INSERT INTO encounters").
+		WithArgs(name, partyID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(encounterID))
+	mockDB.Mock.ExpectQuery("SELECT id, hp FROM players WHERE party_id = \\$1").
+		WithArgs(partyID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hp"}).AddRow(1, 25))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_players").
+		WithArgs(encounterID, 1, 0, 25).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestDuplicateEncounter(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	mockDB.Mock.ExpectQuery("SELECT name, party_id FROM encounters").
+		WithArgs(1, TestEncounterID).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "party_id"}).AddRow("Patrol", TestPartyID))
+	mockDB.Mock.ExpectBegin()
+	expectCreateEncounter(mockDB, "Patrol (copy)", TestPartyID, 2)
+
+	// Monsters come back at the HP from their data, without minions
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_monsters .+ SELECT \\$1, em.monster_id, em.level_adjustment, .+'hp'.+ FROM encounter_monsters em .+ em.minion_kind = ''").
+		WithArgs(2, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_custom_combatants .+ SELECT \\$1, name, level, max_hp, max_hp, .+ FROM encounter_custom_combatants").
+		WithArgs(2, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.Mock.ExpectCommit()
+
+	encounter, err := models.DuplicateEncounter(mockDB, TestEncounterID, " ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.ID != 2 || encounter.Name != "Patrol (copy)" || encounter.Round != 0 {
+		t.Errorf("expected a fresh copy at round 0, got %+v", encounter)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDuplicateEncounter_RollsBack(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	// The copy isn't kept when its monsters can't be copied
+	mockDB.Mock.ExpectQuery("SELECT name, party_id FROM encounters").
+		WithArgs(1, TestEncounterID).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "party_id"}).AddRow("Patrol", TestPartyID))
+	mockDB.Mock.ExpectBegin()
+	expectCreateEncounter(mockDB, "Patrol (copy)", TestPartyID, 2)
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_monsters").
+		WithArgs(2, TestEncounterID).
+		WillReturnError(sqlmock.ErrCancelled)
+	mockDB.Mock.ExpectRollback()
+
+	if _, err := models.DuplicateEncounter(mockDB, TestEncounterID, ""); err == nil {
+		t.Fatal("expected an error when the monsters can't be copied")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSaveEncounterTemplate(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	mockDB.Mock.ExpectBegin()
+	mockDB.Mock.ExpectQuery("INSERT INTO encounter_templates").
+		WithArgs("Patrol", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_template_monsters .+ FROM encounter_monsters").
+		WithArgs(7, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_template_customs .+ FROM encounter_custom_combatants").
+		WithArgs(7, TestEncounterID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.Mock.ExpectCommit()
+
+	template, err := models.SaveEncounterTemplate(mockDB, TestEncounterID, " Patrol ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if template.ID != 7 || template.Name != "Patrol" {
+		t.Errorf("expected template 7 named Patrol, got %+v", template)
+	}
+
+	// A template needs a name
+	if _, err := models.SaveEncounterTemplate(mockDB, TestEncounterID, ""); err == nil {
+		t.Error("expected an error without a name")
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetAllEncounterTemplates(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	mockDB.Mock.ExpectQuery("SELECT id, name FROM encounter_templates").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "Patrol"))
+	mockDB.Mock.ExpectQuery("SELECT name, level_adjustment, COUNT\\(\\*\\)").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "level_adjustment", "count"}).
+			AddRow("Hobgoblin Soldier", 1, 3).
+			AddRow("City Guard", 0, 1))
+
+	templates, err := models.GetAllEncounterTemplates(mockDB)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(templates) != 1 || len(templates[0].Creatures) != 2 {
+		t.Fatalf("expected one template with two creatures, got %+v", templates)
+	}
+
+	creatures := templates[0].Creatures
+	if creatures[0].String() != "3 × Elite Hobgoblin Soldier" || creatures[1].String() != "City Guard" {
+		t.Errorf("unexpected creatures %s and %s", creatures[0], creatures[1])
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateEncounterFromTemplate(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	// Any party can use the template, the encounter is named after it
+	mockDB.Mock.ExpectQuery("SELECT name FROM encounter_templates").
+		WithArgs(1, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Patrol"))
+	mockDB.Mock.ExpectBegin()
+	expectCreateEncounter(mockDB, "Patrol", 3, 5)
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_monsters .+ FROM encounter_template_monsters tm").
+		WithArgs(5, 7).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mockDB.Mock.ExpectExec("INSERT INTO encounter_custom_combatants .+ FROM encounter_template_customs").
+		WithArgs(5, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.Mock.ExpectCommit()
+
+	encounter, err := models.CreateEncounterFromTemplate(mockDB, 7, "", 3)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.ID != 5 || encounter.PartyID != 3 {
+		t.Errorf("expected encounter 5 for party 3, got %+v", encounter)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
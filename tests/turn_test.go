@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"pf2.encounterbrew.com/internal/models"
+)
+
+func createValuedCondition(id int, name string, value int) models.Condition {
+	condition := models.Condition{ID: id}
+	condition.Data.Name = name
+	condition.Data.System.Value.IsValued = true
+	condition.Data.System.Value.Value = value
+	return condition
+}
+
+func expectActions(mockDB *StandardMockDB, table string, actions int, reactionUsed bool, associationID int) {
+	mockDB.Mock.ExpectExec("UPDATE "+table+" SET actions_remaining").
+		WithArgs(actions, reactionUsed, associationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestProcessTurnEnd_ReducesFrightened(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	monster.Conditions = []models.Condition{createValuedCondition(5, "Frightened", 2)}
+
+	mockDB.Mock.ExpectExec("UPDATE combatant_conditions").
+		WithArgs(1, TestEncounterID, monster.AssociationID, 5).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	changes, err := models.ProcessTurnEnd(mockDB, TestEncounterID, &monster)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(changes) != 1 || !strings.Contains(changes[0], "frightened 2 → 1") {
+		t.Errorf("expected frightened reduction to be reported, got %v", changes)
+	}
+
+	if monster.GetConditions()[0].GetValue() != 1 {
+		t.Errorf("expected frightened 1, got %d", monster.GetConditions()[0].GetValue())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProcessTurnEnd_RemovesFrightenedAtZero(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.Conditions = []models.Condition{
+		createValuedCondition(5, "Frightened", 1),
+		createValuedCondition(6, "Sickened", 1),
+	}
+
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+		WithArgs(TestEncounterID, player.AssociationID, 5).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	changes, err := models.ProcessTurnEnd(mockDB, TestEncounterID, &player)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(changes) != 1 || !strings.Contains(changes[0], "no longer frightened") {
+		t.Errorf("expected frightened removal to be reported, got %v", changes)
+	}
+
+	if len(player.GetConditions()) != 1 || player.GetConditions()[0].GetSlug() != "sickened" {
+		t.Errorf("expected only sickened to remain, got %v", player.GetConditions())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProcessTurnStart_StunnedOverridesSlowed(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	monster := CreateSampleMonster()
+	monster.Conditions = []models.Condition{
+		createValuedCondition(7, "Stunned", 2),
+		createValuedCondition(8, "Slowed", 1),
+	}
+
+	mockDB.Mock.ExpectExec("DELETE FROM combatant_conditions").
+		WithArgs(TestEncounterID, monster.AssociationID, 7).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectActions(mockDB, "encounter_monsters", 1, false, monster.AssociationID)
+
+	changes, err := models.ProcessTurnStart(mockDB, TestEncounterID, &monster)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	summary := strings.Join(changes, "\n")
+	if !strings.Contains(summary, "loses 2 actions to stunned") {
+		t.Errorf("expected stunned action loss, got %v", changes)
+	}
+	if strings.Contains(summary, "to slowed") {
+		t.Errorf("expected slowed to be covered by stunned, got %v", changes)
+	}
+	if !strings.Contains(summary, "has 1 action this turn") {
+		t.Errorf("expected 1 remaining action, got %v", changes)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProcessTurnStart_Slowed(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.Conditions = []models.Condition{createValuedCondition(8, "Slowed", 1)}
+
+	expectActions(mockDB, "encounter_players", 2, false, player.AssociationID)
+
+	changes, err := models.ProcessTurnStart(mockDB, TestEncounterID, &player)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	summary := strings.Join(changes, "\n")
+	if !strings.Contains(summary, "loses 1 action to slowed 1") || !strings.Contains(summary, "has 2 actions this turn") {
+		t.Errorf("expected slowed action loss, got %v", changes)
+	}
+
+	// Slowed is not reduced by the turn start
+	if player.GetConditions()[0].GetValue() != 1 {
+		t.Errorf("expected slowed 1, got %d", player.GetConditions()[0].GetValue())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProcessTurnStart_NoConditions(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	player := CreateSamplePlayer()
+	player.ActionsRemaining = 0
+	player.ReactionUsed = true
+
+	expectActions(mockDB, "encounter_players", 3, false, player.AssociationID)
+
+	changes, err := models.ProcessTurnStart(mockDB, TestEncounterID, &player)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+	if player.GetActionsRemaining() != 3 || player.IsReactionUsed() {
+		t.Errorf("expected 3 actions and the reaction back, got %d", player.GetActionsRemaining())
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestNextGroupTurn(t *testing.T) {
+	mockDB, cleanup := NewStandardMockDB(t)
+	defer cleanup()
+
+	first := CreateSampleMonster()
+	first.InitiativeGroup = 1
+	second := CreateSampleMonster()
+	second.AssociationID = 201
+	second.Enumeration = 2
+	second.InitiativeGroup = 1
+	player := CreateSamplePlayer()
+
+	encounter := CreateSampleEncounter()
+	encounter.Combatants = []models.Combatant{&first, &second, &player}
+
+	// Both monsters of the group take their turn before the player's starts
+	expectTurnAndRound(mockDB, &second, 1)
+	expectActions(mockDB, "encounter_monsters", 3, false, second.AssociationID)
+	expectTurnAndRound(mockDB, &player, 1)
+	expectActions(mockDB, "encounter_players", 3, false, player.AssociationID)
+
+	if _, err := models.NextGroupTurn(mockDB, &encounter); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.Turn != 2 {
+		t.Errorf("expected the player's turn after the group, got turn %d", encounter.Turn)
+	}
+
+	// Outside of a group only the active combatant's turn ends
+	expectTurnAndRound(mockDB, &first, 2)
+	expectActions(mockDB, "encounter_monsters", 3, false, first.AssociationID)
+
+	if _, err := models.NextGroupTurn(mockDB, &encounter); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encounter.Turn != 0 || encounter.Round != 2 {
+		t.Errorf("expected the first turn of round 2, got turn %d round %d", encounter.Turn, encounter.Round)
+	}
+
+	if err := mockDB.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
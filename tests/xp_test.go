@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"testing"
+
+	"pf2.encounterbrew.com/internal/models"
+)
+
+// createSampleParty creates players of the given levels
+func createSampleParty(levels ...int) []*models.Player {
+	var players []*models.Player
+	for i, level := range levels {
+		player := CreateSamplePlayer()
+		player.AssociationID += i
+		player.Level = level
+		players = append(players, &player)
+	}
+
+	return players
+}
+
+func TestGetXpBudget_CharacterAdjustments(t *testing.T) {
+	tests := []struct {
+		size    int
+		budgets []int
+	}{
+		{4, []int{40, 60, 80, 120, 160}},
+		{3, []int{30, 45, 60, 90, 120}},
+		{6, []int{60, 90, 120, 180, 240}},
+		{1, []int{10, 15, 20, 30, 40}},
+	}
+
+	for _, tt := range tests {
+		levels := make([]int, tt.size)
+		for i := range levels {
+			levels[i] = 3
+		}
+
+		encounter := CreateSampleEncounter()
+		encounter.Players = createSampleParty(levels...)
+		budget := encounter.GetXpBudget()
+
+		for i, tier := range budget.Tiers {
+			if tier.Budget != tt.budgets[i] {
+				t.Errorf("%d players: expected %s budget %d, got %d", tt.size, tier.Name, tt.budgets[i], tier.Budget)
+			}
+		}
+	}
+}
+
+func TestGetXpBudget_CreatureXp(t *testing.T) {
+	// The average level 4.67 rounds to 5 rather than being cut to 4
+	encounter := CreateSampleEncounter()
+	encounter.Players = createSampleParty(4, 5, 5)
+
+	boss := CreateSampleMonster()
+	boss.Data.System.Details.Level.Value = 7
+	minion := CreateSampleMonster()
+	minion.AssociationID = 201
+	minion.Data.System.Details.Level.Value = 0
+	minion.Enumeration = 2
+	ally := CreateSampleMonster()
+	ally.AssociationID = 202
+	ally.Conditions = []models.Condition{friendly()}
+	encounter.Combatants = []models.Combatant{&boss, &minion, &ally, encounter.Players[0]}
+
+	budget := encounter.GetXpBudget()
+	if budget.PartyLevel != 5 || budget.PartySize != 3 {
+		t.Fatalf("expected 3 players of level 5, got %d of level %d", budget.PartySize, budget.PartyLevel)
+	}
+
+	// The ally and the player aren't counted, a creature more than 4 levels
+	// below the party is worth nothing
+	expected := []models.CreatureXp{
+		{Name: "Test Monster 1", Level: 7, Xp: 80},
+		{Name: "Test Monster 2", Level: 0, Xp: 0},
+	}
+	if len(budget.Creatures) != len(expected) {
+		t.Fatalf("expected %d creatures, got %+v", len(expected), budget.Creatures)
+	}
+	for i, creature := range expected {
+		if budget.Creatures[i] != creature {
+			t.Errorf("expected %+v, got %+v", creature, budget.Creatures[i])
+		}
+	}
+
+	// 80 XP reaches the moderate budget of 60 for three players, not the
+	// severe one of 90
+	if budget.Xp != 80 || budget.Threat() != models.ThreatModerate {
+		t.Errorf("expected a moderate threat with 80 XP, got %s with %d XP", budget.ThreatName(), budget.Xp)
+	}
+}
+
+func TestXpBudget_ThreatBoundaries(t *testing.T) {
+	encounter := CreateSampleEncounter()
+	encounter.Players = createSampleParty(3, 3, 3, 3)
+	budget := encounter.GetXpBudget()
+
+	// A tier is reached once its whole budget is spent
+	tests := []struct {
+		xp     int
+		threat int
+	}{
+		{0, models.ThreatTrivial},
+		{40, models.ThreatTrivial},
+		{59, models.ThreatTrivial},
+		{60, models.ThreatLow},
+		{61, models.ThreatLow},
+		{70, models.ThreatLow},
+		{79, models.ThreatLow},
+		{80, models.ThreatModerate},
+		{119, models.ThreatModerate},
+		{120, models.ThreatSevere},
+		{159, models.ThreatSevere},
+		{160, models.ThreatExtreme},
+		{240, models.ThreatExtreme},
+	}
+
+	for _, tt := range tests {
+		budget.Xp = tt.xp
+		if threat := budget.Threat(); threat != tt.threat {
+			t.Errorf("%d XP: expected threat %d, got %d (%s)", tt.xp, tt.threat, threat, budget.ThreatName())
+		}
+	}
+}
+
+func TestGetXpBudget_LevelDifferences(t *testing.T) {
+	tests := []struct {
+		difference int
+		xp         int
+	}{
+		{-5, 0},
+		{-4, 10},
+		{-3, 15},
+		{-2, 20},
+		{-1, 30},
+		{0, 40},
+		{1, 60},
+		{2, 80},
+		{3, 120},
+		{4, 160},
+		{5, 160},
+	}
+
+	for _, tt := range tests {
+		encounter := CreateSampleEncounter()
+		encounter.Players = createSampleParty(10, 10, 10, 10)
+
+		monster := CreateSampleMonster()
+		monster.Data.System.Details.Level.Value = 10 + tt.difference
+		encounter.Combatants = []models.Combatant{&monster}
+
+		if budget := encounter.GetXpBudget(); budget.Xp != tt.xp {
+			t.Errorf("level difference %d: expected %d XP, got %d", tt.difference, tt.xp, budget.Xp)
+		}
+	}
+}
+
+func TestGetXpBudget_NoPlayers(t *testing.T) {
+	encounter := CreateSampleEncounter()
+	monster := CreateSampleMonster()
+	encounter.Combatants = []models.Combatant{&monster}
+
+	budget := encounter.GetXpBudget()
+	if budget.Threat() != -1 || encounter.GetDifficulty() != -1 {
+		t.Errorf("expected no threat without players, got %d", budget.Threat())
+	}
+	if budget.ThreatName() != "No party" {
+		t.Errorf("expected no party, got %s", budget.ThreatName())
+	}
+}